@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryWarehouseClient is a WarehouseClient backed by a map instead of the Warehouse service.
+type InMemoryWarehouseClient struct {
+	mu         sync.RWMutex
+	warehouses map[primitive.ObjectID]Warehouse
+}
+
+// NewInMemoryWarehouseClient creates an InMemoryWarehouseClient seeded with the given warehouses.
+func NewInMemoryWarehouseClient(warehouses ...Warehouse) *InMemoryWarehouseClient {
+	c := &InMemoryWarehouseClient{warehouses: make(map[primitive.ObjectID]Warehouse)}
+	for _, warehouse := range warehouses {
+		c.Add(warehouse)
+	}
+	return c
+}
+
+// Add registers a warehouse, replacing any existing entry with the same ID.
+func (c *InMemoryWarehouseClient) Add(warehouse Warehouse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warehouses[warehouse.ID] = warehouse
+}
+
+func (c *InMemoryWarehouseClient) GetWarehouse(ctx context.Context, id primitive.ObjectID) (*Warehouse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	warehouse, ok := c.warehouses[id]
+	if !ok {
+		return nil, ErrWarehouseNotFound
+	}
+	return &warehouse, nil
+}
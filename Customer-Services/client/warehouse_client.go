@@ -0,0 +1,70 @@
+package client
+
+import (
+	"Customer-Services/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/tracing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrWarehouseNotFound is returned when the Warehouse service has no record for the requested ID.
+var ErrWarehouseNotFound = apperrors.NotFound("warehouse not found")
+
+// Warehouse is the subset of a Warehouse service record that customers rely on.
+type Warehouse struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// WarehouseClient looks up warehouses owned by the Warehouse service.
+type WarehouseClient interface {
+	GetWarehouse(ctx context.Context, id primitive.ObjectID) (*Warehouse, error)
+}
+
+// httpWarehouseClient implements WarehouseClient over the Warehouse service's REST API.
+type httpWarehouseClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewWarehouseClient creates a WarehouseClient that calls the Warehouse service at config.Cfg.WarehouseServiceURL.
+func NewWarehouseClient() WarehouseClient {
+	return &httpWarehouseClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.WarehouseServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpWarehouseClient) GetWarehouse(ctx context.Context, id primitive.ObjectID) (*Warehouse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/warehouses/%s", c.baseURL, id.Hex()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build warehouse request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach warehouse service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var warehouse Warehouse
+		if err := json.NewDecoder(resp.Body).Decode(&warehouse); err != nil {
+			return nil, fmt.Errorf("failed to decode warehouse response: %w", err)
+		}
+		return &warehouse, nil
+	case http.StatusNotFound:
+		return nil, ErrWarehouseNotFound
+	default:
+		return nil, fmt.Errorf("warehouse service returned status %d", resp.StatusCode)
+	}
+}
@@ -12,8 +12,20 @@ type Config struct {
 	GinMode      string `json:"gin_mode"`
 	MongoDBURI   string `json:"mongodb_uri"`
 	DatabaseName string `json:"database_name"`
+
+	// Where repositories keep their data: StorageBackendMongo (default) or StorageBackendMemory
+	StorageBackend string `json:"storage_backend"`
+
+	// Base URL of the Warehouse service, used to validate warehouse associations
+	WarehouseServiceURL string `json:"warehouse_service_url"`
 }
 
+// Storage backends selectable through STORAGE_BACKEND.
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory" // Data lives in process memory and is lost on restart
+)
+
 // Cfg is the global configuration instance.
 var Cfg *Config
 
@@ -24,6 +36,10 @@ func LoadConfig() error {
 		GinMode:      "debug",
 		MongoDBURI:   "mongodb://mongodb-wms:27017", // Default for Docker Compose local
 		DatabaseName: "wms_customer_db",
+
+		StorageBackend: StorageBackendMongo,
+
+		WarehouseServiceURL: "http://warehouse-service:8085",
 	}
 
 	// Override with environment variables if set (Render will set these)
@@ -42,8 +58,24 @@ func LoadConfig() error {
 		Cfg.DatabaseName = dbName
 	}
 
-	fmt.Printf("Customer Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s\n",
-		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName)
+	if warehouseURL := os.Getenv("WAREHOUSE_SERVICE_URL"); warehouseURL != "" {
+		Cfg.WarehouseServiceURL = warehouseURL
+	}
+
+	if storage := os.Getenv("STORAGE_BACKEND"); storage != "" {
+		Cfg.StorageBackend = storage
+	}
+	if Cfg.StorageBackend != StorageBackendMongo && Cfg.StorageBackend != StorageBackendMemory {
+		return fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", Cfg.StorageBackend, StorageBackendMongo, StorageBackendMemory)
+	}
+
+	fmt.Printf("Customer Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s, WarehouseServiceURL=%s, StorageBackend=%s\n",
+		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName, Cfg.WarehouseServiceURL, Cfg.StorageBackend)
 
 	return nil
 }
+
+// UseMemoryStorage reports whether repositories keep their data in process memory instead of MongoDB.
+func (c *Config) UseMemoryStorage() bool {
+	return c.StorageBackend == StorageBackendMemory
+}
@@ -1,11 +1,14 @@
 package controller
 
 import (
-	"Customer-Services/model"   // Corrected import path
-	"Customer-Services/service" // Corrected import path
+	"Customer-Services/model"      // Corrected import path
+	"Customer-Services/repository" // Corrected import path
+	"Customer-Services/service"    // Corrected import path
 	"context"
 	"net/http"
 	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,12 +27,12 @@ func NewCustomerController(s service.CustomerService) *CustomerController {
 func (c *CustomerController) CreateCustomer(ctx *gin.Context) {
 	var customer model.Customer
 	if err := ctx.ShouldBindJSON(&customer); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
 	if customer.FirstName == "" || customer.LastName == "" || customer.Email == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "First name, last name, and email are required"})
+		apperrors.Respond(ctx, apperrors.Validation("First name, last name, and email are required"))
 		return
 	}
 
@@ -38,22 +41,41 @@ func (c *CustomerController) CreateCustomer(ctx *gin.Context) {
 
 	createdCustomer, err := c.customerService.CreateCustomer(timeoutCtx, &customer)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, createdCustomer)
 }
 
 // GetAllCustomers handles GET /customers requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.CustomerListSpec, and
+// ?warehouseId= to list only the customers linked to a warehouse.
 func (c *CustomerController) GetAllCustomers(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.CustomerListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	customers, err := c.customerService.GetAllCustomers(timeoutCtx)
+	var (
+		customers  []model.Customer
+		nextCursor string
+	)
+	if warehouseID := ctx.Query("warehouseId"); warehouseID != "" {
+		customers, nextCursor, err = c.customerService.GetCustomersByWarehouseID(timeoutCtx, warehouseID, params)
+	} else {
+		customers, nextCursor, err = c.customerService.GetAllCustomers(timeoutCtx, params)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
 	ctx.JSON(http.StatusOK, customers)
 }
 
@@ -66,11 +88,7 @@ func (c *CustomerController) GetCustomerByID(ctx *gin.Context) {
 
 	customer, err := c.customerService.GetCustomerByID(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "customer not found" || err.Error() == "invalid customer ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, customer)
@@ -81,7 +99,7 @@ func (c *CustomerController) UpdateCustomer(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var customer model.Customer
 	if err := ctx.ShouldBindJSON(&customer); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -90,11 +108,7 @@ func (c *CustomerController) UpdateCustomer(ctx *gin.Context) {
 
 	updatedCustomer, err := c.customerService.UpdateCustomer(timeoutCtx, id, &customer)
 	if err != nil {
-		if err.Error() == "customer not found" || err.Error() == "invalid customer ID format" || err.Error() == "customer not found or no changes made" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, updatedCustomer)
@@ -109,12 +123,40 @@ func (c *CustomerController) DeleteCustomer(ctx *gin.Context) {
 
 	err := c.customerService.DeleteCustomer(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "customer not found" || err.Error() == "invalid customer ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusNoContent, nil) // 204 No Content for successful deletion
 }
+
+// AddWarehouseToCustomer handles POST /customers/:id/warehouses/:warehouseId requests.
+func (c *CustomerController) AddWarehouseToCustomer(ctx *gin.Context) {
+	id := ctx.Param("id")
+	warehouseID := ctx.Param("warehouseId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	customer, err := c.customerService.AddWarehouseToCustomer(timeoutCtx, id, warehouseID)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, customer)
+}
+
+// RemoveWarehouseFromCustomer handles DELETE /customers/:id/warehouses/:warehouseId requests.
+func (c *CustomerController) RemoveWarehouseFromCustomer(ctx *gin.Context) {
+	id := ctx.Param("id")
+	warehouseID := ctx.Param("warehouseId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	customer, err := c.customerService.RemoveWarehouseFromCustomer(timeoutCtx, id, warehouseID)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, customer)
+}
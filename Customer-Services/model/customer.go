@@ -4,10 +4,11 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 // Customer represents a customer in the database.
 type Customer struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	FirstName string             `bson:"first_name" json:"firstName"`
-	LastName  string             `bson:"last_name" json:"lastName"`
-	Email     string             `bson:"email" json:"email"`
-	Phone     string             `bson:"phone" json:"phone"`
-	Address   string             `bson:"address" json:"address"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	FirstName    string               `bson:"first_name" json:"firstName"`
+	LastName     string               `bson:"last_name" json:"lastName"`
+	Email        string               `bson:"email" json:"email"`
+	Phone        string               `bson:"phone" json:"phone"`
+	Address      string               `bson:"address" json:"address"`
+	WarehouseIDs []primitive.ObjectID `bson:"warehouse_ids,omitempty" json:"warehouseIds"` // Warehouses serving this customer; managed via /customers/:id/warehouses
 }
@@ -1,126 +1,217 @@
 package repository
 
 import (
+	"Customer-Services/config"
+	"Customer-Services/database"
 	"Customer-Services/model" // Fixed import path
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrCustomerNotFound is returned when no customer matches the given ID.
+var ErrCustomerNotFound = apperrors.NotFound("customer not found")
+
+// CustomerListSpec lists the fields clients may sort and filter customers on.
+var CustomerListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"firstName": "first_name",
+		"lastName":  "last_name",
+		"email":     "email",
+	},
+	FilterFields: map[string]pagination.Field{
+		"firstName": {BSON: "first_name", Kind: pagination.String},
+		"lastName":  {BSON: "last_name", Kind: pagination.String},
+		"email":     {BSON: "email", Kind: pagination.String},
+	},
+}
+
 // CustomerRepository defines the interface for customer data operations.
 type CustomerRepository interface {
-	Create(ctx context.Context, customer model.Customer) error
-	FindByID(ctx context.Context, id string) (model.Customer, error)
-	FindAll(ctx context.Context) ([]model.Customer, error)
-	Update(ctx context.Context, id string, update interface{}) error
-	// New methods for array manipulation
-	AddWarehouseToCustomer(ctx context.Context, customerID, warehouseID string) error
-	RemoveWarehouseFromCustomer(ctx context.Context, customerID, warehouseID string) error
-	FindCustomersByWarehouseID(ctx context.Context, warehouseID string) ([]model.Customer, error)
+	CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error)
+	GetAllCustomers(ctx context.Context, params *pagination.Params) ([]model.Customer, string, error)
+	GetCustomerByID(ctx context.Context, id primitive.ObjectID) (*model.Customer, error)
+	UpdateCustomer(ctx context.Context, id primitive.ObjectID, customer *model.Customer) (*model.Customer, error)
+	DeleteCustomer(ctx context.Context, id primitive.ObjectID) error
+	// Warehouse links live in the customer's warehouse_ids array.
+	AddWarehouseToCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error
+	RemoveWarehouseFromCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error
+	// FindCustomersByWarehouseID returns one page of the customers linked to a warehouse and the
+	// cursor for the next page.
+	FindCustomersByWarehouseID(ctx context.Context, warehouseID primitive.ObjectID, params *pagination.Params) ([]model.Customer, string, error)
 }
 
-// mongoCustomerRepository implements CustomerRepository for MongoDB.
-type mongoCustomerRepository struct {
+// customerRepositoryImpl implements CustomerRepository for MongoDB.
+type customerRepositoryImpl struct {
 	collection *mongo.Collection
 }
 
-// NewMongoCustomerRepository creates a new MongoDB repository for customers.
-func NewMongoCustomerRepository(collection *mongo.Collection) CustomerRepository {
-	return &mongoCustomerRepository{
-		collection: collection,
+// NewCustomerRepository creates a new instance of CustomerRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewCustomerRepository() CustomerRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryCustomerRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
 	}
+	collection := database.GetCollection(database.Client, "customers")
+	return &customerRepositoryImpl{collection: collection}
 }
 
-// Create inserts a new customer into the database.
-func (r *mongoCustomerRepository) Create(ctx context.Context, customer model.Customer) error {
-	_, err := r.collection.InsertOne(ctx, customer)
-	return err
-}
+func (r *customerRepositoryImpl) CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
+	ctx, done := instrument.Repository(ctx, "customer", "CreateCustomer")
+	defer done()
 
-// FindByID retrieves a customer by its ID.
-func (r *mongoCustomerRepository) FindByID(ctx context.Context, id string) (model.Customer, error) {
-	var customer model.Customer
-	filter := bson.M{"_id": id}
-	err := r.collection.FindOne(ctx, filter).Decode(&customer)
-	if err == mongo.ErrNoDocuments {
-		return model.Customer{}, mongo.ErrNoDocuments // Ensure this specific error is returned for 'not found'
+	result, err := r.collection.InsertOne(ctx, customer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer in repository: %w", err)
 	}
-	return customer, err
+	customer.ID = result.InsertedID.(primitive.ObjectID)
+	return customer, nil
 }
 
-// FindAll retrieves all customers from the database.
-func (r *mongoCustomerRepository) FindAll(ctx context.Context) ([]model.Customer, error) {
-	var customers []model.Customer
-	cursor, err := r.collection.Find(ctx, bson.M{}) // Empty filter to get all
+// GetAllCustomers returns one page of customers and the cursor for the next page.
+func (r *customerRepositoryImpl) GetAllCustomers(ctx context.Context, params *pagination.Params) ([]model.Customer, string, error) {
+	ctx, done := instrument.Repository(ctx, "customer", "GetAllCustomers")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to retrieve customers from repository: %w", err)
 	}
-	defer cursor.Close(ctx) // Always close the cursor
+	defer cursor.Close(ctx)
 
+	customers := []model.Customer{}
 	if err = cursor.All(ctx, &customers); err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to decode customers from cursor: %w", err)
 	}
-	return customers, nil
+	return pagination.Page(customers, params)
 }
 
-// Update updates an existing customer by its ID.
-// This general update allows setting a new array of warehouse_ids directly, or other fields.
-func (r *mongoCustomerRepository) Update(ctx context.Context, id string, update interface{}) error {
-	// filter := bson.M{"_id": id} // Removed unused filter declaration
-	result, err := r.collection.UpdateByID(ctx, id, bson.D{{Key: "$set", Value: update}}) // Uses 'id' directly
+func (r *customerRepositoryImpl) GetCustomerByID(ctx context.Context, id primitive.ObjectID) (*model.Customer, error) {
+	ctx, done := instrument.Repository(ctx, "customer", "GetCustomerByID")
+	defer done()
+
+	var customer model.Customer
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&customer)
 	if err != nil {
-		return err
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve customer by ID from repository: %w", err)
+	}
+	return &customer, nil
+}
+
+// UpdateCustomer replaces the customer's contact details. Warehouse links are left untouched.
+func (r *customerRepositoryImpl) UpdateCustomer(ctx context.Context, id primitive.ObjectID, customer *model.Customer) (*model.Customer, error) {
+	ctx, done := instrument.Repository(ctx, "customer", "UpdateCustomer")
+	defer done()
+
+	updateDoc := bson.M{
+		"$set": bson.M{
+			"first_name": customer.FirstName,
+			"last_name":  customer.LastName,
+			"email":      customer.Email,
+			"phone":      customer.Phone,
+			"address":    customer.Address,
+		},
+	}
+
+	result, err := r.collection.UpdateByID(ctx, id, updateDoc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments // Return specific error if not found
+		return nil, ErrCustomerNotFound
+	}
+
+	return r.GetCustomerByID(ctx, id)
+}
+
+func (r *customerRepositoryImpl) DeleteCustomer(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "customer", "DeleteCustomer")
+	defer done()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrCustomerNotFound
 	}
 	return nil
 }
 
 // AddWarehouseToCustomer adds a warehouse ID to a customer's list of associated warehouses.
 // Uses $addToSet to add only if the ID is not already present, ensuring uniqueness within the array.
-func (r *mongoCustomerRepository) AddWarehouseToCustomer(ctx context.Context, customerID, warehouseID string) error {
-	filter := bson.M{"_id": customerID}
+func (r *customerRepositoryImpl) AddWarehouseToCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "customer", "AddWarehouseToCustomer")
+	defer done()
+
 	update := bson.M{"$addToSet": bson.M{"warehouse_ids": warehouseID}}
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateByID(ctx, customerID, update)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to add warehouse to customer: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+		return ErrCustomerNotFound
 	}
 	return nil
 }
 
 // RemoveWarehouseFromCustomer removes a warehouse ID from a customer's list.
 // Uses $pull to remove all instances of the specified ID.
-func (r *mongoCustomerRepository) RemoveWarehouseFromCustomer(ctx context.Context, customerID, warehouseID string) error {
-	filter := bson.M{"_id": customerID}
+func (r *customerRepositoryImpl) RemoveWarehouseFromCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "customer", "RemoveWarehouseFromCustomer")
+	defer done()
+
 	update := bson.M{"$pull": bson.M{"warehouse_ids": warehouseID}}
-	result, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateByID(ctx, customerID, update)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to remove warehouse from customer: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+		return ErrCustomerNotFound
 	}
 	return nil
 }
 
-// FindCustomersByWarehouseID finds all customers associated with a given warehouse ID.
+// FindCustomersByWarehouseID finds the customers associated with a given warehouse ID.
 // This is used by the Warehouse Service to query its associated customers.
-func (r *mongoCustomerRepository) FindCustomersByWarehouseID(ctx context.Context, warehouseID string) ([]model.Customer, error) {
-	var customers []model.Customer
-	filter := bson.M{"warehouse_ids": warehouseID}
-	cursor, err := r.collection.Find(ctx, filter)
+func (r *customerRepositoryImpl) FindCustomersByWarehouseID(ctx context.Context, warehouseID primitive.ObjectID, params *pagination.Params) ([]model.Customer, string, error) {
+	ctx, done := instrument.Repository(ctx, "customer", "FindCustomersByWarehouseID")
+	defer done()
+
+	params = linkedTo(params, warehouseID)
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to find customers by warehouse in repository: %w", err)
 	}
 	defer cursor.Close(ctx)
 
+	customers := []model.Customer{}
 	if err = cursor.All(ctx, &customers); err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("failed to decode customers from cursor: %w", err)
+	}
+	return pagination.Page(customers, params)
+}
+
+// linkedTo narrows a page request to the customers whose warehouse_ids contain warehouseID.
+func linkedTo(params *pagination.Params, warehouseID primitive.ObjectID) *pagination.Params {
+	narrowed := *params
+	narrowed.Filters = bson.M{"warehouse_ids": warehouseID}
+	for key, value := range params.Filters {
+		narrowed.Filters[key] = value
 	}
-	return customers, nil
+	return &narrowed
 }
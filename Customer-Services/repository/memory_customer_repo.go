@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"Customer-Services/model"
+	"context"
+	"slices"
+	"sync"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryCustomerRepository is a CustomerRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryCustomerRepository struct {
+	mu        sync.RWMutex
+	customers map[primitive.ObjectID]model.Customer
+}
+
+// NewInMemoryCustomerRepository creates an empty InMemoryCustomerRepository.
+func NewInMemoryCustomerRepository() *InMemoryCustomerRepository {
+	return &InMemoryCustomerRepository{customers: map[primitive.ObjectID]model.Customer{}}
+}
+
+func (r *InMemoryCustomerRepository) CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if customer.ID.IsZero() {
+		customer.ID = primitive.NewObjectID()
+	}
+	r.customers[customer.ID] = cloneCustomer(*customer)
+	return customer, nil
+}
+
+func (r *InMemoryCustomerRepository) GetAllCustomers(ctx context.Context, params *pagination.Params) ([]model.Customer, string, error) {
+	r.mu.RLock()
+	customers := make([]model.Customer, 0, len(r.customers))
+	for _, customer := range r.customers {
+		customers = append(customers, cloneCustomer(customer))
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(customers, params)
+}
+
+func (r *InMemoryCustomerRepository) GetCustomerByID(ctx context.Context, id primitive.ObjectID) (*model.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	customer, ok := r.customers[id]
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+	customer = cloneCustomer(customer)
+	return &customer, nil
+}
+
+func (r *InMemoryCustomerRepository) UpdateCustomer(ctx context.Context, id primitive.ObjectID, customer *model.Customer) (*model.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.customers[id]
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+	stored.FirstName = customer.FirstName
+	stored.LastName = customer.LastName
+	stored.Email = customer.Email
+	stored.Phone = customer.Phone
+	stored.Address = customer.Address
+	r.customers[id] = stored
+
+	updated := cloneCustomer(stored)
+	return &updated, nil
+}
+
+func (r *InMemoryCustomerRepository) DeleteCustomer(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.customers[id]; !ok {
+		return ErrCustomerNotFound
+	}
+	delete(r.customers, id)
+	return nil
+}
+
+func (r *InMemoryCustomerRepository) AddWarehouseToCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return ErrCustomerNotFound
+	}
+	if !slices.Contains(customer.WarehouseIDs, warehouseID) {
+		customer.WarehouseIDs = append(slices.Clone(customer.WarehouseIDs), warehouseID)
+		r.customers[customerID] = customer
+	}
+	return nil
+}
+
+func (r *InMemoryCustomerRepository) RemoveWarehouseFromCustomer(ctx context.Context, customerID, warehouseID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return ErrCustomerNotFound
+	}
+	customer.WarehouseIDs = slices.DeleteFunc(slices.Clone(customer.WarehouseIDs), func(id primitive.ObjectID) bool {
+		return id == warehouseID
+	})
+	r.customers[customerID] = customer
+	return nil
+}
+
+func (r *InMemoryCustomerRepository) FindCustomersByWarehouseID(ctx context.Context, warehouseID primitive.ObjectID, params *pagination.Params) ([]model.Customer, string, error) {
+	return r.GetAllCustomers(ctx, linkedTo(params, warehouseID))
+}
+
+// cloneCustomer copies a customer so callers cannot mutate stored warehouse links.
+func cloneCustomer(customer model.Customer) model.Customer {
+	customer.WarehouseIDs = slices.Clone(customer.WarehouseIDs)
+	return customer
+}
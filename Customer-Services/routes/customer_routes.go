@@ -24,6 +24,10 @@ func CustomerRoutes(router *gin.Engine) {
 		customerGroup.GET("/:id", customerController.GetCustomerByID) // Matches /customers/:id
 		customerGroup.PUT("/:id", customerController.UpdateCustomer)
 		customerGroup.DELETE("/:id", customerController.DeleteCustomer)
+
+		// Warehouses serving a customer
+		customerGroup.POST("/:id/warehouses/:warehouseId", customerController.AddWarehouseToCustomer)
+		customerGroup.DELETE("/:id/warehouses/:warehouseId", customerController.RemoveWarehouseFromCustomer)
 	}
 
 	// Add explicit 301 redirects for paths that might come in WITH trailing slashes.
@@ -1,121 +1,154 @@
 package service
 
 import (
-	"Customer-Services/database" // Corrected import path
-	"Customer-Services/model"    // Corrected import path
+	"Customer-Services/client"
+	"Customer-Services/model"      // Corrected import path
+	"Customer-Services/repository" // Corrected import path
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrUnknownWarehouse is returned when a customer is linked to a warehouse that does not exist in the Warehouse service.
+var ErrUnknownWarehouse = apperrors.InvalidReference("warehouse ID does not reference an existing warehouse")
+
+// ErrInvalidCustomerID is returned when a customer ID is not a valid ObjectID.
+var ErrInvalidCustomerID = apperrors.InvalidID("invalid customer ID format")
+
+// ErrInvalidWarehouseID is returned when a warehouse ID is not a valid ObjectID.
+var ErrInvalidWarehouseID = apperrors.InvalidID("invalid warehouse ID format")
+
 // CustomerService defines the interface for customer business logic.
 type CustomerService interface {
 	CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error)
-	GetAllCustomers(ctx context.Context) ([]model.Customer, error)
+	GetAllCustomers(ctx context.Context, params *pagination.Params) ([]model.Customer, string, error)
+	GetCustomersByWarehouseID(ctx context.Context, warehouseID string, params *pagination.Params) ([]model.Customer, string, error)
 	GetCustomerByID(ctx context.Context, id string) (*model.Customer, error)
 	UpdateCustomer(ctx context.Context, id string, customer *model.Customer) (*model.Customer, error)
 	DeleteCustomer(ctx context.Context, id string) error
+	AddWarehouseToCustomer(ctx context.Context, id, warehouseID string) (*model.Customer, error)
+	RemoveWarehouseFromCustomer(ctx context.Context, id, warehouseID string) (*model.Customer, error)
+}
+
+// Dependencies groups the collaborators a CustomerService is built from.
+type Dependencies struct {
+	Customers  repository.CustomerRepository
+	Warehouses client.WarehouseClient
 }
 
 // customerServiceImpl implements CustomerService.
 type customerServiceImpl struct {
-	collection *mongo.Collection
+	repository repository.CustomerRepository
+	warehouses client.WarehouseClient
 }
 
 // NewCustomerService creates a new instance of CustomerService.
 func NewCustomerService() CustomerService {
-	// Ensure database.Client is initialized before calling GetCollection
-	if database.Client == nil {
-		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	return NewCustomerServiceWithDependencies(Dependencies{
+		Customers:  repository.NewCustomerRepository(),
+		Warehouses: client.NewWarehouseClient(),
+	})
+}
+
+// NewCustomerServiceWithDependencies creates a CustomerService from explicit collaborators,
+// so tests can substitute in-memory repositories and clients.
+func NewCustomerServiceWithDependencies(deps Dependencies) CustomerService {
+	return &customerServiceImpl{
+		repository: deps.Customers,
+		warehouses: deps.Warehouses,
 	}
-	collection := database.GetCollection(database.Client, "customers")
-	return &customerServiceImpl{collection: collection}
 }
 
 func (s *customerServiceImpl) CreateCustomer(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
-	result, err := s.collection.InsertOne(ctx, customer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create customer: %w", err)
-	}
-	customer.ID = result.InsertedID.(primitive.ObjectID)
-	return customer, nil
+	customer.WarehouseIDs = nil // Links are only made through AddWarehouseToCustomer, which validates them
+	return s.repository.CreateCustomer(ctx, customer)
 }
 
-func (s *customerServiceImpl) GetAllCustomers(ctx context.Context) ([]model.Customer, error) {
-	cursor, err := s.collection.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve customers: %w", err)
-	}
-	defer cursor.Close(ctx)
+// GetAllCustomers returns one page of customers and the cursor for the next page.
+func (s *customerServiceImpl) GetAllCustomers(ctx context.Context, params *pagination.Params) ([]model.Customer, string, error) {
+	return s.repository.GetAllCustomers(ctx, params)
+}
 
-	var customers []model.Customer
-	if err = cursor.All(ctx, &customers); err != nil {
-		return nil, fmt.Errorf("failed to decode customers: %w", err)
+// GetCustomersByWarehouseID returns one page of the customers linked to a warehouse and the
+// cursor for the next page. The warehouse is not looked up: one without customers lists none.
+func (s *customerServiceImpl) GetCustomersByWarehouseID(ctx context.Context, warehouseID string, params *pagination.Params) ([]model.Customer, string, error) {
+	objID, err := primitive.ObjectIDFromHex(warehouseID)
+	if err != nil {
+		return nil, "", ErrInvalidWarehouseID
 	}
-	return customers, nil
+	return s.repository.FindCustomersByWarehouseID(ctx, objID, params)
 }
 
 func (s *customerServiceImpl) GetCustomerByID(ctx context.Context, id string) (*model.Customer, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid customer ID format")
+		return nil, ErrInvalidCustomerID
 	}
-
-	var customer model.Customer
-	err = s.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&customer)
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("customer not found")
-		}
-		return nil, fmt.Errorf("failed to retrieve customer by ID: %w", err)
-	}
-	return &customer, nil
+	return s.repository.GetCustomerByID(ctx, objID)
 }
 
 func (s *customerServiceImpl) UpdateCustomer(ctx context.Context, id string, customer *model.Customer) (*model.Customer, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid customer ID format")
+		return nil, ErrInvalidCustomerID
 	}
+	return s.repository.UpdateCustomer(ctx, objID, customer)
+}
 
-	updateDoc := bson.M{
-		"$set": bson.M{
-			"first_name": customer.FirstName,
-			"last_name":  customer.LastName,
-			"email":      customer.Email,
-			"phone":      customer.Phone,
-			"address":    customer.Address,
-		},
+func (s *customerServiceImpl) DeleteCustomer(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidCustomerID
 	}
+	return s.repository.DeleteCustomer(ctx, objID)
+}
 
-	result, err := s.collection.UpdateByID(ctx, objID, updateDoc)
+// AddWarehouseToCustomer links a customer to a warehouse after confirming the warehouse exists.
+// Linking an already linked warehouse is a no-op.
+func (s *customerServiceImpl) AddWarehouseToCustomer(ctx context.Context, id, warehouseID string) (*model.Customer, error) {
+	customerObjID, warehouseObjID, err := parseLinkIDs(id, warehouseID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update customer: %w", err)
+		return nil, err
 	}
-	if result.ModifiedCount == 0 {
-		return nil, errors.New("customer not found or no changes made")
+	if _, err := s.warehouses.GetWarehouse(ctx, warehouseObjID); err != nil {
+		if errors.Is(err, client.ErrWarehouseNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWarehouse, warehouseID)
+		}
+		return nil, fmt.Errorf("failed to verify warehouse %s: %w", warehouseID, err)
 	}
 
-	return s.GetCustomerByID(ctx, id)
+	if err := s.repository.AddWarehouseToCustomer(ctx, customerObjID, warehouseObjID); err != nil {
+		return nil, err
+	}
+	return s.repository.GetCustomerByID(ctx, customerObjID)
 }
 
-func (s *customerServiceImpl) DeleteCustomer(ctx context.Context, id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+// RemoveWarehouseFromCustomer unlinks a customer from a warehouse. The warehouse is not looked up,
+// so links to warehouses that have since been deleted can still be removed.
+func (s *customerServiceImpl) RemoveWarehouseFromCustomer(ctx context.Context, id, warehouseID string) (*model.Customer, error) {
+	customerObjID, warehouseObjID, err := parseLinkIDs(id, warehouseID)
 	if err != nil {
-		return errors.New("invalid customer ID format")
+		return nil, err
 	}
+	if err := s.repository.RemoveWarehouseFromCustomer(ctx, customerObjID, warehouseObjID); err != nil {
+		return nil, err
+	}
+	return s.repository.GetCustomerByID(ctx, customerObjID)
+}
 
-	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": objID})
+// parseLinkIDs converts the hex IDs of a customer/warehouse link into ObjectIDs.
+func parseLinkIDs(customerID, warehouseID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	customerObjID, err := primitive.ObjectIDFromHex(customerID)
 	if err != nil {
-		return fmt.Errorf("failed to delete customer: %w", err)
+		return primitive.NilObjectID, primitive.NilObjectID, ErrInvalidCustomerID
 	}
-	if result.DeletedCount == 0 {
-		return errors.New("customer not found")
+	warehouseObjID, err := primitive.ObjectIDFromHex(warehouseID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, ErrInvalidWarehouseID
 	}
-	return nil
+	return customerObjID, warehouseObjID, nil
 }
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"Customer-Services/client"
+	"Customer-Services/model"
+	"Customer-Services/repository"
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestService builds a CustomerService on memory storage whose Warehouse service knows warehouses.
+func newTestService(warehouses ...client.Warehouse) CustomerService {
+	return NewCustomerServiceWithDependencies(Dependencies{
+		Customers:  repository.NewInMemoryCustomerRepository(),
+		Warehouses: client.NewInMemoryWarehouseClient(warehouses...),
+	})
+}
+
+func TestCreateCustomerIgnoresWarehouseLinks(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+
+	created, err := s.CreateCustomer(ctx, &model.Customer{FirstName: "Ada", WarehouseIDs: []primitive.ObjectID{primitive.NewObjectID()}})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	if len(created.WarehouseIDs) != 0 {
+		t.Errorf("WarehouseIDs = %v, want none: links are only made through AddWarehouseToCustomer", created.WarehouseIDs)
+	}
+}
+
+func TestAddWarehouseToCustomer(t *testing.T) {
+	ctx := context.Background()
+	known := client.Warehouse{ID: primitive.NewObjectID(), Name: "Main"}
+
+	tests := []struct {
+		name        string
+		customerID  func(existing string) string
+		warehouseID string
+		linkTwice   bool
+		wantErr     error
+		wantLinks   int
+	}{
+		{name: "links a known warehouse", warehouseID: known.ID.Hex(), wantLinks: 1},
+		{name: "linking twice keeps one link", warehouseID: known.ID.Hex(), linkTwice: true, wantLinks: 1},
+		{name: "unknown warehouse", warehouseID: primitive.NewObjectID().Hex(), wantErr: ErrUnknownWarehouse},
+		{name: "malformed warehouse ID", warehouseID: "not-an-id", wantErr: ErrInvalidWarehouseID},
+		{
+			name:        "malformed customer ID",
+			customerID:  func(string) string { return "not-an-id" },
+			warehouseID: known.ID.Hex(),
+			wantErr:     ErrInvalidCustomerID,
+		},
+		{
+			name:        "missing customer",
+			customerID:  func(string) string { return primitive.NewObjectID().Hex() },
+			warehouseID: known.ID.Hex(),
+			wantErr:     repository.ErrCustomerNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(known)
+			created, err := s.CreateCustomer(ctx, &model.Customer{FirstName: "Ada"})
+			if err != nil {
+				t.Fatalf("CreateCustomer: %v", err)
+			}
+			customerID := created.ID.Hex()
+			if tt.customerID != nil {
+				customerID = tt.customerID(customerID)
+			}
+			if tt.linkTwice {
+				if _, err := s.AddWarehouseToCustomer(ctx, customerID, tt.warehouseID); err != nil {
+					t.Fatalf("first AddWarehouseToCustomer: %v", err)
+				}
+			}
+
+			customer, err := s.AddWarehouseToCustomer(ctx, customerID, tt.warehouseID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddWarehouseToCustomer error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(customer.WarehouseIDs) != tt.wantLinks {
+				t.Errorf("WarehouseIDs = %v, want %d link(s)", customer.WarehouseIDs, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestAddUnknownWarehouseIsInvalidReference(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+	created, err := s.CreateCustomer(ctx, &model.Customer{FirstName: "Ada"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+
+	_, err = s.AddWarehouseToCustomer(ctx, created.ID.Hex(), primitive.NewObjectID().Hex())
+	if !errors.Is(err, apperrors.ErrInvalidReference) {
+		t.Errorf("error = %v, want an invalid reference (422) rather than not found", err)
+	}
+}
+
+func TestRemoveWarehouseFromCustomerSkipsLookup(t *testing.T) {
+	ctx := context.Background()
+	customers := repository.NewInMemoryCustomerRepository()
+	warehouse := client.Warehouse{ID: primitive.NewObjectID(), Name: "Main"}
+	s := NewCustomerServiceWithDependencies(Dependencies{
+		Customers:  customers,
+		Warehouses: client.NewInMemoryWarehouseClient(warehouse),
+	})
+	created, err := s.CreateCustomer(ctx, &model.Customer{FirstName: "Ada"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	if _, err := s.AddWarehouseToCustomer(ctx, created.ID.Hex(), warehouse.ID.Hex()); err != nil {
+		t.Fatalf("AddWarehouseToCustomer: %v", err)
+	}
+
+	// Once the warehouse is gone from the Warehouse service, the stale link can still be removed.
+	s = NewCustomerServiceWithDependencies(Dependencies{
+		Customers:  customers,
+		Warehouses: client.NewInMemoryWarehouseClient(),
+	})
+	customer, err := s.RemoveWarehouseFromCustomer(ctx, created.ID.Hex(), warehouse.ID.Hex())
+	if err != nil {
+		t.Fatalf("RemoveWarehouseFromCustomer: %v", err)
+	}
+	if len(customer.WarehouseIDs) != 0 {
+		t.Errorf("WarehouseIDs = %v, want none", customer.WarehouseIDs)
+	}
+}
+
+func TestGetCustomersByWarehouseID(t *testing.T) {
+	ctx := context.Background()
+	warehouse := client.Warehouse{ID: primitive.NewObjectID(), Name: "Main"}
+	s := newTestService(warehouse)
+	var linked []primitive.ObjectID
+	for _, name := range []string{"Ada", "Grace", "Edsger"} {
+		created, err := s.CreateCustomer(ctx, &model.Customer{FirstName: name})
+		if err != nil {
+			t.Fatalf("CreateCustomer: %v", err)
+		}
+		if name == "Grace" {
+			continue
+		}
+		if _, err := s.AddWarehouseToCustomer(ctx, created.ID.Hex(), warehouse.ID.Hex()); err != nil {
+			t.Fatalf("AddWarehouseToCustomer: %v", err)
+		}
+		linked = append(linked, created.ID)
+	}
+
+	// Page through the warehouse's customers one at a time.
+	var got []primitive.ObjectID
+	params := &pagination.Params{Limit: 1, SortField: "_id", Filters: bson.M{}}
+	for {
+		customers, next, err := s.GetCustomersByWarehouseID(ctx, warehouse.ID.Hex(), params)
+		if err != nil {
+			t.Fatalf("GetCustomersByWarehouseID: %v", err)
+		}
+		for _, customer := range customers {
+			got = append(got, customer.ID)
+		}
+		if next == "" {
+			break
+		}
+		if params, err = pagination.Parse(url.Values{"limit": {"1"}, "after": {next}}, repository.CustomerListSpec); err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+	}
+	if !slices.Equal(got, linked) {
+		t.Errorf("customers = %v, want %v", got, linked)
+	}
+
+	params = &pagination.Params{Limit: pagination.MaxLimit, SortField: "_id", Filters: bson.M{}}
+	if customers, _, err := s.GetCustomersByWarehouseID(ctx, primitive.NewObjectID().Hex(), params); err != nil || len(customers) != 0 {
+		t.Errorf("customers of another warehouse = %v, %v, want none", customers, err)
+	}
+	if _, _, err := s.GetCustomersByWarehouseID(ctx, "not-an-id", params); !errors.Is(err, ErrInvalidWarehouseID) {
+		t.Errorf("malformed warehouse ID error = %v, want %v", err, ErrInvalidWarehouseID)
+	}
+}
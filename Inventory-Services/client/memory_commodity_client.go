@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryCommodityClient is a CommodityClient backed by a map instead of the Commodity service.
+// It lets the inventory service run and be tested without its neighbours.
+type InMemoryCommodityClient struct {
+	mu          sync.RWMutex
+	commodities map[primitive.ObjectID]Commodity
+}
+
+// NewInMemoryCommodityClient creates an InMemoryCommodityClient seeded with the given commodities.
+func NewInMemoryCommodityClient(commodities ...Commodity) *InMemoryCommodityClient {
+	c := &InMemoryCommodityClient{commodities: make(map[primitive.ObjectID]Commodity)}
+	for _, commodity := range commodities {
+		c.Add(commodity)
+	}
+	return c
+}
+
+// Add registers a commodity, replacing any existing entry with the same ID.
+func (c *InMemoryCommodityClient) Add(commodity Commodity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commodities[commodity.ID] = commodity
+}
+
+func (c *InMemoryCommodityClient) GetCommodity(ctx context.Context, id primitive.ObjectID) (*Commodity, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	commodity, ok := c.commodities[id]
+	if !ok {
+		return nil, ErrCommodityNotFound
+	}
+	return &commodity, nil
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"Inventory-Services/model"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds the application configuration for this microservice.
@@ -12,8 +14,31 @@ type Config struct {
 	GinMode      string `json:"gin_mode"`
 	MongoDBURI   string `json:"mongodb_uri"`
 	DatabaseName string `json:"database_name"`
+
+	// Where repositories keep their data: StorageBackendMongo (default) or StorageBackendMemory
+	StorageBackend string `json:"storage_backend"`
+
+	// Base URLs of the services that inventory records refer to
+	CommodityServiceURL string `json:"commodity_service_url"`
+	WarehouseServiceURL string `json:"warehouse_service_url"`
+
+	// How long a reservation holds stock when the request gives no TTL
+	ReservationDefaultTTLSeconds int `json:"reservation_default_ttl_seconds"`
+	// How often expired reservations are released
+	ReservationSweepIntervalSeconds int `json:"reservation_sweep_interval_seconds"`
+
+	// Location that received stock is posted to when an ASN line names none
+	ReceivingLocation string `json:"receiving_location"`
+	// Putaway strategies tried in order when a request names none
+	PutawayStrategies []string `json:"putaway_strategies"`
 }
 
+// Storage backends selectable through STORAGE_BACKEND.
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory" // Data lives in process memory and is lost on restart
+)
+
 // Cfg is the global configuration instance.
 var Cfg *Config
 
@@ -24,6 +49,17 @@ func LoadConfig() error {
 		GinMode:      "debug",
 		MongoDBURI:   "mongodb://mongodb-wms:27017", // Default for Docker Compose local
 		DatabaseName: "wms_inventory_db",
+
+		StorageBackend: StorageBackendMongo,
+
+		CommodityServiceURL: "http://commodity-service:8086",
+		WarehouseServiceURL: "http://warehouse-service:8085",
+
+		ReservationDefaultTTLSeconds:    900,
+		ReservationSweepIntervalSeconds: 30,
+
+		ReceivingLocation: "RECEIVING",
+		PutawayStrategies: []string{model.PutawayHome, model.PutawayConsolidate, model.PutawayNearestEmpty},
 	}
 
 	// Override with environment variables if set (Render will set these)
@@ -42,8 +78,54 @@ func LoadConfig() error {
 		Cfg.DatabaseName = dbName
 	}
 
-	fmt.Printf("Inventory Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s\n",
-		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName)
+	if commodityURL := os.Getenv("COMMODITY_SERVICE_URL"); commodityURL != "" {
+		Cfg.CommodityServiceURL = commodityURL
+	}
+	if warehouseURL := os.Getenv("WAREHOUSE_SERVICE_URL"); warehouseURL != "" {
+		Cfg.WarehouseServiceURL = warehouseURL
+	}
+
+	if receivingLocation := os.Getenv("RECEIVING_LOCATION"); receivingLocation != "" {
+		Cfg.ReceivingLocation = receivingLocation
+	}
+
+	if strategies := os.Getenv("PUTAWAY_STRATEGIES"); strategies != "" {
+		Cfg.PutawayStrategies = strings.Split(strategies, ",")
+		for _, strategy := range Cfg.PutawayStrategies {
+			if !model.IsValidPutawayStrategy(strategy) {
+				return fmt.Errorf("invalid PUTAWAY_STRATEGIES %q: unknown strategy %q", strategies, strategy)
+			}
+		}
+	}
+
+	for env, target := range map[string]*int{
+		"RESERVATION_DEFAULT_TTL_SECONDS":    &Cfg.ReservationDefaultTTLSeconds,
+		"RESERVATION_SWEEP_INTERVAL_SECONDS": &Cfg.ReservationSweepIntervalSeconds,
+	} {
+		if valueStr := os.Getenv(env); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil || value < 1 {
+				return fmt.Errorf("invalid %s %q: must be a positive number of seconds", env, valueStr)
+			}
+			*target = value
+		}
+	}
+
+	if storage := os.Getenv("STORAGE_BACKEND"); storage != "" {
+		Cfg.StorageBackend = storage
+	}
+	if Cfg.StorageBackend != StorageBackendMongo && Cfg.StorageBackend != StorageBackendMemory {
+		return fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", Cfg.StorageBackend, StorageBackendMongo, StorageBackendMemory)
+	}
+
+	fmt.Printf("Inventory Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s, CommodityServiceURL=%s, WarehouseServiceURL=%s, StorageBackend=%s, ReservationDefaultTTLSeconds=%d, ReservationSweepIntervalSeconds=%d, ReceivingLocation=%s, PutawayStrategies=%s\n",
+		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName, Cfg.CommodityServiceURL, Cfg.WarehouseServiceURL, Cfg.StorageBackend,
+		Cfg.ReservationDefaultTTLSeconds, Cfg.ReservationSweepIntervalSeconds, Cfg.ReceivingLocation, strings.Join(Cfg.PutawayStrategies, ","))
 
 	return nil
 }
+
+// UseMemoryStorage reports whether repositories keep their data in process memory instead of MongoDB.
+func (c *Config) UseMemoryStorage() bool {
+	return c.StorageBackend == StorageBackendMemory
+}
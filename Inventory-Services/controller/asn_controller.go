@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateASN handles POST /inventory/asns requests.
+func (c *InventoryController) CreateASN(ctx *gin.Context) {
+	var asn model.ASN
+	if err := ctx.ShouldBindJSON(&asn); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if asn.Supplier == "" || asn.WarehouseID.IsZero() {
+		apperrors.Respond(ctx, apperrors.Validation("Supplier and warehouse ID are required"))
+		return
+	}
+	if len(asn.Lines) == 0 {
+		apperrors.Respond(ctx, apperrors.Validation("An ASN needs at least one line"))
+		return
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, line := range asn.Lines {
+		if line.ProductID.IsZero() || line.ExpectedQuantity <= 0 {
+			apperrors.Respond(ctx, apperrors.Validation("Every line needs a product ID and a positive expected quantity"))
+			return
+		}
+		if seen[line.ProductID] {
+			apperrors.Respond(ctx, apperrors.Validation(fmt.Sprintf("Product %s appears on more than one line", line.ProductID.Hex())))
+			return
+		}
+		seen[line.ProductID] = true
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	created, err := c.inventoryService.CreateASN(timeoutCtx, &asn)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// GetAllASNs handles GET /inventory/asns requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.ASNListSpec,
+// such as ?status= and ?warehouseId=.
+func (c *InventoryController) GetAllASNs(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.ASNListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	asns, nextCursor, err := c.inventoryService.GetAllASNs(timeoutCtx, params)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, asns)
+}
+
+// GetASNByID handles GET /inventory/asns/:asnId requests.
+func (c *InventoryController) GetASNByID(ctx *gin.Context) {
+	id := ctx.Param("asnId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	asn, err := c.inventoryService.GetASNByID(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, asn)
+}
+
+// RecordASNReceipt handles POST /inventory/asns/:asnId/receive requests.
+func (c *InventoryController) RecordASNReceipt(ctx *gin.Context) {
+	id := ctx.Param("asnId")
+	var receipt model.ASNReceipt
+	if err := ctx.ShouldBindJSON(&receipt); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if len(receipt.Lines) == 0 {
+		apperrors.Respond(ctx, apperrors.Validation("A receipt needs at least one line"))
+		return
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, count := range receipt.Lines {
+		if count.ProductID.IsZero() || count.ReceivedQuantity < 0 {
+			apperrors.Respond(ctx, apperrors.Validation("Every line needs a product ID and a received quantity that is not negative"))
+			return
+		}
+		if seen[count.ProductID] {
+			apperrors.Respond(ctx, apperrors.Validation(fmt.Sprintf("Product %s appears on more than one line", count.ProductID.Hex())))
+			return
+		}
+		seen[count.ProductID] = true
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	asn, err := c.inventoryService.RecordASNReceipt(timeoutCtx, id, &receipt)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, asn)
+}
+
+// CloseASN handles POST /inventory/asns/:asnId/close requests.
+func (c *InventoryController) CloseASN(ctx *gin.Context) {
+	id := ctx.Param("asnId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	result, err := c.inventoryService.CloseASN(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// CancelASN handles POST /inventory/asns/:asnId/cancel requests.
+func (c *InventoryController) CancelASN(ctx *gin.Context) {
+	id := ctx.Param("asnId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	asn, err := c.inventoryService.CancelASN(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, asn)
+}
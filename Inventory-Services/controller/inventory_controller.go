@@ -2,10 +2,13 @@ package controller
 
 import (
 	"Inventory-Services/model"
+	"Inventory-Services/repository"
 	"Inventory-Services/service"
 	"context" // Added context import
 	"net/http"
 	"time" // Added time import
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +27,7 @@ func NewInventoryController(s service.InventoryService) *InventoryController {
 func (c *InventoryController) CreateInventory(ctx *gin.Context) {
 	var inventory model.Inventory
 	if err := ctx.ShouldBindJSON(&inventory); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -33,22 +36,32 @@ func (c *InventoryController) CreateInventory(ctx *gin.Context) {
 
 	createdInventory, err := c.inventoryService.CreateInventory(timeoutCtx, &inventory)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, createdInventory)
 }
 
 // GetAllInventories handles GET /inventory requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.InventoryListSpec.
 func (c *InventoryController) GetAllInventories(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.InventoryListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	inventories, err := c.inventoryService.GetAllInventories(timeoutCtx)
+	inventories, nextCursor, err := c.inventoryService.GetAllInventories(timeoutCtx, params)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
 	ctx.JSON(http.StatusOK, inventories)
 }
 
@@ -61,11 +74,7 @@ func (c *InventoryController) GetInventoryByID(ctx *gin.Context) {
 
 	inventory, err := c.inventoryService.GetInventoryByID(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "inventory not found" || err.Error() == "invalid inventory ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, inventory)
@@ -76,7 +85,7 @@ func (c *InventoryController) UpdateInventory(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var inventory model.Inventory
 	if err := ctx.ShouldBindJSON(&inventory); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -85,11 +94,7 @@ func (c *InventoryController) UpdateInventory(ctx *gin.Context) {
 
 	updatedInventory, err := c.inventoryService.UpdateInventory(timeoutCtx, id, &inventory)
 	if err != nil {
-		if err.Error() == "inventory not found" || err.Error() == "invalid inventory ID format" || err.Error() == "inventory not found or no changes made" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, updatedInventory)
@@ -104,12 +109,118 @@ func (c *InventoryController) DeleteInventory(ctx *gin.Context) {
 
 	err := c.inventoryService.DeleteInventory(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "inventory not found" || err.Error() == "invalid inventory ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+// AdjustInventory handles POST /inventory/:id/adjust requests.
+func (c *InventoryController) AdjustInventory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var adjustment model.StockAdjustment
+	if err := ctx.ShouldBindJSON(&adjustment); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	adjustedInventory, err := c.inventoryService.AdjustInventory(timeoutCtx, id, &adjustment)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, adjustedInventory)
+}
+
+// GetMovements handles GET /inventory/:id/movements requests.
+// Optional "from" and "to" query parameters (RFC 3339) bound the time range; paging follows
+// repository.MovementListSpec.
+func (c *InventoryController) GetMovements(ctx *gin.Context) {
+	id := ctx.Param("id")
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.MovementListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	var from, to time.Time
+	if fromStr := ctx.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Validation("Invalid 'from' timestamp, expected RFC 3339"))
+			return
+		}
+		from = parsed
+	}
+	if toStr := ctx.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			apperrors.Respond(ctx, apperrors.Validation("Invalid 'to' timestamp, expected RFC 3339"))
+			return
+		}
+		to = parsed
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	movements, nextCursor, err := c.inventoryService.GetMovements(timeoutCtx, id, from, to, params)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, movements)
+}
+
+// TransferStock handles POST /inventory/transfers requests.
+func (c *InventoryController) TransferStock(ctx *gin.Context) {
+	var transfer model.StockTransfer
+	if err := ctx.ShouldBindJSON(&transfer); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if transfer.ProductID.IsZero() || transfer.FromLocation == "" || transfer.ToLocation == "" {
+		apperrors.Respond(ctx, apperrors.Validation("Product ID, source location, and destination location are required"))
+		return
+	}
+	if !transfer.ToWarehouseID.IsZero() && transfer.FromWarehouseID.IsZero() {
+		apperrors.Respond(ctx, apperrors.Validation("Source warehouse ID is required when a destination warehouse is given"))
+		return
+	}
+	if transfer.Quantity <= 0 {
+		apperrors.Respond(ctx, apperrors.Validation("Quantity must be positive"))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	result, err := c.inventoryService.TransferStock(timeoutCtx, &transfer)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetWarehouseUtilization handles GET /inventory/warehouses/:warehouseId/utilization requests.
+func (c *InventoryController) GetWarehouseUtilization(ctx *gin.Context) {
+	warehouseID := ctx.Param("warehouseId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	utilization, err := c.inventoryService.GetWarehouseUtilization(timeoutCtx, warehouseID)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, utilization)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"net/http"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateLocation handles POST /inventory/locations requests.
+func (c *InventoryController) CreateLocation(ctx *gin.Context) {
+	var location model.StorageLocation
+	if err := ctx.ShouldBindJSON(&location); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+	if err := validateLocation(&location); err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	created, err := c.inventoryService.CreateLocation(timeoutCtx, &location)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// GetAllLocations handles GET /inventory/locations requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.LocationListSpec,
+// such as ?warehouseId=.
+func (c *InventoryController) GetAllLocations(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.LocationListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	locations, nextCursor, err := c.inventoryService.GetAllLocations(timeoutCtx, params)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, locations)
+}
+
+// GetLocationByID handles GET /inventory/locations/:locationId requests.
+func (c *InventoryController) GetLocationByID(ctx *gin.Context) {
+	id := ctx.Param("locationId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	location, err := c.inventoryService.GetLocationByID(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, location)
+}
+
+// UpdateLocation handles PUT /inventory/locations/:locationId requests.
+func (c *InventoryController) UpdateLocation(ctx *gin.Context) {
+	id := ctx.Param("locationId")
+	var location model.StorageLocation
+	if err := ctx.ShouldBindJSON(&location); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+	if err := validateLocation(&location); err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	updated, err := c.inventoryService.UpdateLocation(timeoutCtx, id, &location)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteLocation handles DELETE /inventory/locations/:locationId requests.
+func (c *InventoryController) DeleteLocation(ctx *gin.Context) {
+	id := ctx.Param("locationId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := c.inventoryService.DeleteLocation(timeoutCtx, id); err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// validateLocation checks the fields of a storage location that need no other service.
+func validateLocation(location *model.StorageLocation) error {
+	if location.WarehouseID.IsZero() || location.Code == "" {
+		return apperrors.Validation("Warehouse ID and code are required")
+	}
+	if location.Capacity < 0 || location.Distance < 0 {
+		return apperrors.Validation("Capacity and distance must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SuggestPutaway handles GET /inventory/putaway/suggestions requests.
+// Requires ?productId=, ?warehouseId= and ?quantity=. Optional ?fromLocation= names where the
+// units are now, and ?strategies= a comma-separated order of putaway strategies to try.
+func (c *InventoryController) SuggestPutaway(ctx *gin.Context) {
+	productID, productErr := primitive.ObjectIDFromHex(ctx.Query("productId"))
+	warehouseID, warehouseErr := primitive.ObjectIDFromHex(ctx.Query("warehouseId"))
+	if productErr != nil || warehouseErr != nil {
+		apperrors.Respond(ctx, apperrors.Validation("Valid productId and warehouseId query parameters are required"))
+		return
+	}
+	quantity, err := strconv.Atoi(ctx.Query("quantity"))
+	if err != nil || quantity <= 0 {
+		apperrors.Respond(ctx, apperrors.Validation("Quantity must be a positive number"))
+		return
+	}
+	request := model.PutawayRequest{
+		ProductID:    productID,
+		WarehouseID:  warehouseID,
+		FromLocation: ctx.Query("fromLocation"),
+		Quantity:     quantity,
+	}
+	if strategies := ctx.Query("strategies"); strategies != "" {
+		request.Strategies = strings.Split(strategies, ",")
+		for _, strategy := range request.Strategies {
+			if !model.IsValidPutawayStrategy(strategy) {
+				apperrors.Respond(ctx, apperrors.Validation(fmt.Sprintf("Unknown putaway strategy %q", strategy)))
+				return
+			}
+		}
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	plan, err := c.inventoryService.SuggestPutaway(timeoutCtx, &request)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, plan)
+}
+
+// ConfirmPutaway handles POST /inventory/putaway requests.
+func (c *InventoryController) ConfirmPutaway(ctx *gin.Context) {
+	var confirmation model.PutawayConfirmation
+	if err := ctx.ShouldBindJSON(&confirmation); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if confirmation.ProductID.IsZero() || confirmation.WarehouseID.IsZero() || confirmation.ToLocation == "" {
+		apperrors.Respond(ctx, apperrors.Validation("Product ID, warehouse ID, and destination location are required"))
+		return
+	}
+	if confirmation.Quantity <= 0 {
+		apperrors.Respond(ctx, apperrors.Validation("Quantity must be positive"))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	result, err := c.inventoryService.ConfirmPutaway(timeoutCtx, &confirmation)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
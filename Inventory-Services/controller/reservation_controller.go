@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"net/http"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReserveStock handles POST /inventory/reservations requests.
+func (c *InventoryController) ReserveStock(ctx *gin.Context) {
+	var request model.ReservationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if request.ProductID.IsZero() || request.ReferenceID == "" {
+		apperrors.Respond(ctx, apperrors.Validation("Product ID and reference ID are required"))
+		return
+	}
+	if request.Quantity <= 0 {
+		apperrors.Respond(ctx, apperrors.Validation("Quantity must be positive"))
+		return
+	}
+	if request.TTLSeconds < 0 {
+		apperrors.Respond(ctx, apperrors.Validation("TTL must not be negative"))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := c.inventoryService.ReserveStock(timeoutCtx, &request)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, reservation)
+}
+
+// GetAllReservations handles GET /inventory/reservations requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.ReservationListSpec,
+// such as ?referenceId= and ?status=.
+func (c *InventoryController) GetAllReservations(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.ReservationListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	reservations, nextCursor, err := c.inventoryService.GetAllReservations(timeoutCtx, params)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, reservations)
+}
+
+// GetReservationByID handles GET /inventory/reservations/:reservationId requests.
+func (c *InventoryController) GetReservationByID(ctx *gin.Context) {
+	id := ctx.Param("reservationId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := c.inventoryService.GetReservationByID(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, reservation)
+}
+
+// CommitReservation handles POST /inventory/reservations/:reservationId/commit requests.
+func (c *InventoryController) CommitReservation(ctx *gin.Context) {
+	id := ctx.Param("reservationId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := c.inventoryService.CommitReservation(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation handles POST /inventory/reservations/:reservationId/release requests.
+func (c *InventoryController) ReleaseReservation(ctx *gin.Context) {
+	id := ctx.Param("reservationId")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := c.inventoryService.ReleaseReservation(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, reservation)
+}
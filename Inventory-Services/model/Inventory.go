@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,7 +11,62 @@ import (
 type Inventory struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	ProductID   primitive.ObjectID `bson:"product_id" json:"productId"`
-	Quantity    int                `bson:"quantity" json:"quantity"`
-	Location    string             `bson:"location" json:"location"`
-	LastUpdated time.Time          `bson:"last_updated" json:"lastUpdated"`
+	WarehouseID primitive.ObjectID `bson:"warehouse_id" json:"warehouseId"`
+	Quantity    int                `bson:"quantity" json:"quantity"` // Units on hand, reserved or not
+	Reserved    int                `bson:"reserved" json:"reserved"` // Units held by active reservations; managed via /inventory/reservations
+	Location    string             `bson:"location" json:"location"` // Bin or zone within the warehouse
+	// UnitVolume is the storage one unit takes, copied from the commodity whenever the record is
+	// written with it, so capacity checks need not ask the Commodity service. Zero on records
+	// written before it was stored.
+	UnitVolume  int       `bson:"unit_volume,omitempty" json:"unitVolume,omitempty"`
+	LastUpdated time.Time `bson:"last_updated" json:"lastUpdated"`
+}
+
+// Available returns the units on hand that no reservation holds.
+func (i Inventory) Available() int {
+	return i.Quantity - i.Reserved
+}
+
+// MarshalJSON adds the available quantity, which is derived rather than stored.
+func (i Inventory) MarshalJSON() ([]byte, error) {
+	type inventory Inventory // Drops the methods, so encoding does not recurse
+	return json.Marshal(struct {
+		inventory
+		Available int `json:"available"`
+	}{inventory(i), i.Available()})
+}
+
+// Reason codes accepted for stock adjustments.
+const (
+	ReasonReceipt    = "receipt"
+	ReasonPick       = "pick"
+	ReasonReturn     = "return"
+	ReasonDamage     = "damage"
+	ReasonCycleCount = "cycle_count"
+	ReasonCorrection = "correction"
+)
+
+// StockAdjustment is a signed change to an inventory record's quantity.
+type StockAdjustment struct {
+	Delta  int    `json:"delta"`
+	Reason string `json:"reason"`
+}
+
+// IsValidAdjustmentReason reports whether reason is one of the known adjustment reason codes.
+func IsValidAdjustmentReason(reason string) bool {
+	switch reason {
+	case ReasonReceipt, ReasonPick, ReasonReturn, ReasonDamage, ReasonCycleCount, ReasonCorrection:
+		return true
+	}
+	return false
+}
+
+// WarehouseUtilization reports how much of a warehouse's storage capacity is in use.
+// Volumes are in the unit of the warehouse's Storage field.
+type WarehouseUtilization struct {
+	WarehouseID primitive.ObjectID `json:"warehouseId"`
+	Capacity    int                `json:"capacity"`
+	Used        int                `json:"used"`
+	Free        int                `json:"free"`
+	FillPercent float64            `json:"fillPercent"`
 }
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ASNStatus is the stage an advance shipping notice has reached.
+type ASNStatus string
+
+const (
+	ASNOpen      ASNStatus = "open"      // Announced; nothing counted yet
+	ASNReceiving ASNStatus = "receiving" // Counts are being recorded
+	ASNClosed    ASNStatus = "closed"    // Received stock was posted to inventory; final
+	ASNCancelled ASNStatus = "cancelled" // The delivery will not be received; final
+)
+
+// Discrepancies between what an ASN line expected and what was counted.
+const (
+	DiscrepancyNone  = ""
+	DiscrepancyOver  = "over"
+	DiscrepancyUnder = "under"
+)
+
+// ASNLine is one commodity on an advance shipping notice.
+type ASNLine struct {
+	ProductID        primitive.ObjectID `bson:"product_id" json:"productId"`
+	ExpectedQuantity int                `bson:"expected_quantity" json:"expectedQuantity"` // Zero for commodities delivered without being announced
+	ReceivedQuantity int                `bson:"received_quantity" json:"receivedQuantity"` // Counted total so far
+	Location         string             `bson:"location" json:"location"`                  // Where received units are posted; empty means the receiving location
+	Variance         int                `bson:"variance" json:"variance"`                  // ReceivedQuantity minus ExpectedQuantity
+	Discrepancy      string             `bson:"discrepancy" json:"discrepancy,omitempty"`  // DiscrepancyOver or DiscrepancyUnder when the counts differ
+}
+
+// Reconcile recomputes the line's variance and discrepancy from its quantities.
+func (l *ASNLine) Reconcile() {
+	l.Variance = l.ReceivedQuantity - l.ExpectedQuantity
+	switch {
+	case l.Variance > 0:
+		l.Discrepancy = DiscrepancyOver
+	case l.Variance < 0:
+		l.Discrepancy = DiscrepancyUnder
+	default:
+		l.Discrepancy = DiscrepancyNone
+	}
+}
+
+// ASN is an advance shipping notice: a supplier's announcement of the commodities and quantities
+// a delivery to a warehouse will contain. Counts are recorded against it as the delivery is
+// received, and closing it posts what was counted into inventory.
+type ASN struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Supplier    string             `bson:"supplier" json:"supplier"`
+	Reference   string             `bson:"reference" json:"reference"` // The supplier's ASN or delivery note number
+	WarehouseID primitive.ObjectID `bson:"warehouse_id" json:"warehouseId"`
+	ExpectedAt  *time.Time         `bson:"expected_at,omitempty" json:"expectedAt,omitempty"`
+	Status      ASNStatus          `bson:"status" json:"status"`
+	Lines       []ASNLine          `bson:"lines" json:"lines"`
+	Version     int                `bson:"version" json:"version"` // Incremented on every change, so concurrent counts cannot overwrite each other
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+	ClosedAt    *time.Time         `bson:"closed_at,omitempty" json:"closedAt,omitempty"`
+}
+
+// ASNCount records the counted total for one commodity of an ASN. Location, if given,
+// replaces the line's location.
+type ASNCount struct {
+	ProductID        primitive.ObjectID `json:"productId"`
+	ReceivedQuantity int                `json:"receivedQuantity"`
+	Location         string             `json:"location"`
+}
+
+// ASNReceipt is a batch of counts recorded against an ASN.
+type ASNReceipt struct {
+	Lines []ASNCount `json:"lines"`
+}
+
+// ASNCloseResult reports a closed ASN and the inventory records its stock was posted to.
+type ASNCloseResult struct {
+	ASN       *ASN        `json:"asn"`
+	Inventory []Inventory `json:"inventory"`
+}
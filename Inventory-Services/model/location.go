@@ -0,0 +1,14 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// StorageLocation describes a place in a warehouse that stock can be put away to. Its Code is
+// the value inventory records carry in their Location field.
+type StorageLocation struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	WarehouseID   primitive.ObjectID  `bson:"warehouse_id" json:"warehouseId"`
+	Code          string              `bson:"code" json:"code"`
+	Capacity      int                 `bson:"capacity" json:"capacity"`                                 // In the unit of Warehouse.Storage and commodity UnitVolume; 0 means unlimited
+	Distance      int                 `bson:"distance" json:"distance"`                                 // Travel distance from the receiving dock; lower is nearer
+	HomeProductID *primitive.ObjectID `bson:"home_product_id,omitempty" json:"homeProductId,omitempty"` // Commodity this location is reserved for, if any
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reason codes recorded by the service itself for lifecycle changes.
+const (
+	ReasonCreate            = "create"
+	ReasonUpdate            = "update"
+	ReasonDelete            = "delete"
+	ReasonTransferOut       = "transfer_out"
+	ReasonTransferIn        = "transfer_in"
+	ReasonReservationCommit = "reservation_commit" // Reserved units taken off the shelf
+	ReasonPutaway           = "putaway"            // Received units moved to their storage location
+)
+
+// Movement is an append-only ledger entry describing one change to an inventory record.
+type Movement struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	InventoryID    primitive.ObjectID `bson:"inventory_id" json:"inventoryId"`
+	ProductID      primitive.ObjectID `bson:"product_id" json:"productId"`
+	Location       string             `bson:"location" json:"location"`
+	QuantityBefore int                `bson:"quantity_before" json:"quantityBefore"`
+	QuantityAfter  int                `bson:"quantity_after" json:"quantityAfter"`
+	Delta          int                `bson:"delta" json:"delta"`
+	Reason         string             `bson:"reason" json:"reason"`
+	Actor          string             `bson:"actor" json:"actor"`
+	Timestamp      time.Time          `bson:"timestamp" json:"timestamp"`
+}
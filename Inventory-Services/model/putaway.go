@@ -0,0 +1,59 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Putaway strategies, tried in the configured or requested order until the quantity is placed.
+const (
+	PutawayHome         = "home"          // The commodity's fixed home locations
+	PutawayConsolidate  = "consolidate"   // Locations already holding the same commodity
+	PutawayNearestEmpty = "nearest_empty" // Empty locations, nearest first
+)
+
+// IsValidPutawayStrategy reports whether strategy is one of the known putaway strategies.
+func IsValidPutawayStrategy(strategy string) bool {
+	switch strategy {
+	case PutawayHome, PutawayConsolidate, PutawayNearestEmpty:
+		return true
+	}
+	return false
+}
+
+// PutawayRequest asks where Quantity units of a product should be put away in a warehouse.
+// FromLocation is where the units are now and is never suggested; it defaults to the
+// receiving location. Strategies overrides the configured order when given.
+type PutawayRequest struct {
+	ProductID    primitive.ObjectID
+	WarehouseID  primitive.ObjectID
+	FromLocation string
+	Quantity     int
+	Strategies   []string
+}
+
+// PutawaySuggestion proposes putting Quantity units into one location.
+type PutawaySuggestion struct {
+	Location     string `json:"location"`
+	Quantity     int    `json:"quantity"`
+	Strategy     string `json:"strategy"`               // The strategy that chose the location
+	FreeCapacity *int   `json:"freeCapacity,omitempty"` // Capacity left before this suggestion; absent for unlimited locations
+}
+
+// PutawayPlan lists the suggested locations for a putaway request. Unplaced is the part of the
+// quantity no location had room for.
+type PutawayPlan struct {
+	ProductID    primitive.ObjectID  `json:"productId"`
+	WarehouseID  primitive.ObjectID  `json:"warehouseId"`
+	FromLocation string              `json:"fromLocation"`
+	Quantity     int                 `json:"quantity"`
+	Suggestions  []PutawaySuggestion `json:"suggestions"`
+	Unplaced     int                 `json:"unplaced"`
+}
+
+// PutawayConfirmation records that Quantity units of a product were put away from FromLocation,
+// which defaults to the receiving location, to ToLocation.
+type PutawayConfirmation struct {
+	ProductID    primitive.ObjectID `json:"productId"`
+	WarehouseID  primitive.ObjectID `json:"warehouseId"`
+	FromLocation string             `json:"fromLocation"`
+	ToLocation   string             `json:"toLocation"`
+	Quantity     int                `json:"quantity"`
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReservationStatus is the state of a reservation. Only active reservations hold stock.
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"    // Holding units until committed, released or expired
+	ReservationCommitted ReservationStatus = "committed" // The held units were taken off the shelf
+	ReservationReleased  ReservationStatus = "released"  // The held units were given back
+	ReservationExpired   ReservationStatus = "expired"   // The TTL passed and the sweeper gave the units back
+)
+
+// Reservation holds a quantity of one product in one warehouse for a reference such as an
+// order ID, so the units stay on the shelf but can no longer be adjusted, transferred or
+// reserved by anyone else. The units are held on one or more inventory records of the
+// warehouse, one allocation per record.
+type Reservation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID   primitive.ObjectID `bson:"product_id" json:"productId"`
+	WarehouseID primitive.ObjectID `bson:"warehouse_id" json:"warehouseId"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	Allocations []Allocation       `bson:"allocations" json:"allocations"`
+	ReferenceID string             `bson:"reference_id" json:"referenceId"`
+	Status      ReservationStatus  `bson:"status" json:"status"`
+	Actor       string             `bson:"actor" json:"actor"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expiresAt"`
+	ClosedAt    *time.Time         `bson:"closed_at,omitempty" json:"closedAt,omitempty"` // When it stopped being active
+
+	// InventoryID is the one record that reservations written before allocations existed hold their units on.
+	InventoryID primitive.ObjectID `bson:"inventory_id,omitempty" json:"-"`
+}
+
+// Allocation is the part of a reservation held on one inventory record.
+type Allocation struct {
+	InventoryID primitive.ObjectID `bson:"inventory_id" json:"inventoryId"`
+	Location    string             `bson:"location" json:"location"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+}
+
+// Holdings returns the allocations the reservation's units are held on.
+func (r *Reservation) Holdings() []Allocation {
+	if len(r.Allocations) == 0 && !r.InventoryID.IsZero() {
+		return []Allocation{{InventoryID: r.InventoryID, Quantity: r.Quantity}}
+	}
+	return r.Allocations
+}
+
+// ReservationRequest asks to hold Quantity units of a product. WarehouseID and Location are
+// optional and narrow which inventory records the units are taken from. TTLSeconds defaults to
+// the service's RESERVATION_DEFAULT_TTL_SECONDS.
+type ReservationRequest struct {
+	ProductID   primitive.ObjectID `json:"productId"`
+	WarehouseID primitive.ObjectID `json:"warehouseId"`
+	Location    string             `json:"location"`
+	Quantity    int                `json:"quantity"`
+	ReferenceID string             `json:"referenceId"`
+	TTLSeconds  int                `json:"ttlSeconds"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// StockTransfer moves a quantity of one product from a source location to a destination location.
+// FromWarehouseID narrows the source when the same location name exists in several warehouses;
+// ToWarehouseID defaults to the source record's warehouse.
+type StockTransfer struct {
+	ProductID       primitive.ObjectID `json:"productId"`
+	FromWarehouseID primitive.ObjectID `json:"fromWarehouseId"`
+	FromLocation    string             `json:"fromLocation"`
+	ToWarehouseID   primitive.ObjectID `json:"toWarehouseId"`
+	ToLocation      string             `json:"toLocation"`
+	Quantity        int                `json:"quantity"`
+}
+
+// TransferResult reports the source and destination records after a transfer.
+type TransferResult struct {
+	Source      *Inventory `json:"source"`
+	Destination *Inventory `json:"destination"`
+}
@@ -1,25 +1,181 @@
 package repository
 
 import (
+	"Inventory-Services/config"
 	"Inventory-Services/database"
 	"Inventory-Services/model"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrInventoryNotFound is returned when no inventory record matches the given ID.
+	ErrInventoryNotFound = apperrors.NotFound("inventory not found")
+	// ErrInsufficientStock is returned when an adjustment would take more than a record's available
+	// quantity, i.e. drive it below zero or below what is reserved.
+	ErrInsufficientStock = apperrors.Conflict("insufficient stock for adjustment")
+	// ErrTransferSourceNotFound is returned when a transfer's source location holds no record for the product.
+	ErrTransferSourceNotFound = apperrors.NotFound("no inventory for product at source location")
+	// ErrReservationSourceNotFound is returned when no inventory record matches a reservation request.
+	ErrReservationSourceNotFound = apperrors.NotFound("no inventory for product at the requested warehouse and location")
+	// ErrInsufficientAvailable is returned when no single matching record has enough unreserved stock for a reservation.
+	ErrInsufficientAvailable = apperrors.Conflict("insufficient available stock to reserve")
+	// ErrStockReserved is returned when a change would remove or move stock that active reservations hold.
+	ErrStockReserved = apperrors.Conflict("inventory record has reserved stock")
+	// ErrReservedStockMismatch is returned when a record holds fewer reserved units than a
+	// reservation being closed says it should. It means the two collections disagree.
+	ErrReservedStockMismatch = apperrors.Conflict("inventory record holds fewer reserved units than the reservation")
+)
+
+// InventoryListSpec lists the fields clients may sort and filter inventory records on.
+var InventoryListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"quantity":    "quantity",
+		"location":    "location",
+		"lastUpdated": "last_updated",
+	},
+	FilterFields: map[string]pagination.Field{
+		"productId":   {BSON: "product_id", Kind: pagination.ObjectID},
+		"warehouseId": {BSON: "warehouse_id", Kind: pagination.ObjectID},
+		"location":    {BSON: "location", Kind: pagination.String},
+	},
+}
+
 // InventoryRepository defines the interface for inventory data operations.
 type InventoryRepository interface {
 	CreateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error)
-	GetAllInventories(ctx context.Context) ([]model.Inventory, error)
+	GetAllInventories(ctx context.Context, params *pagination.Params) ([]model.Inventory, string, error)
 	GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*model.Inventory, error)
 	UpdateInventory(ctx context.Context, id primitive.ObjectID, inventory *model.Inventory) (*model.Inventory, error)
 	DeleteInventory(ctx context.Context, id primitive.ObjectID) error
+	AdjustQuantity(ctx context.Context, id primitive.ObjectID, delta int) (*model.Inventory, error)
+	TransferStock(ctx context.Context, transfer *model.StockTransfer) (*model.Inventory, *model.Inventory, error)
+	// GetStockVolume totals the storage taken by the stock held in a warehouse.
+	GetStockVolume(ctx context.Context, warehouseID primitive.ObjectID) (*StockVolume, error)
+	// ClaimWarehouse must be called inside a transaction that adds stock to a warehouse, or to one
+	// of its locations, before checking capacity. Transactions claiming the same warehouse conflict, and all but one
+	// are retried, so a capacity check cannot be invalidated by a concurrent one before commit.
+	ClaimWarehouse(ctx context.Context, warehouseID primitive.ObjectID) error
+	GetQuantitiesByWarehouse(ctx context.Context) (map[primitive.ObjectID]int, error)
+	// GetStockByLocation totals the stock at each location of a warehouse.
+	GetStockByLocation(ctx context.Context, warehouseID primitive.ObjectID) (map[string]*LocationStock, error)
+	// AddStock puts quantity units of a product, each taking unitVolume of storage, on the record
+	// for a warehouse and location, creating the record when the product is not yet stocked there.
+	AddStock(ctx context.Context, productID, warehouseID primitive.ObjectID, location string, quantity, unitVolume int) (*model.Inventory, error)
+	// ReserveStock holds the requested quantity on records that match the request, as allocate
+	// picks them, and returns the reservation they make up for the caller to complete and store.
+	// It may write several records, so callers run it in a transaction.
+	ReserveStock(ctx context.Context, request *model.ReservationRequest) (*model.Reservation, error)
+	// ReleaseReserved gives quantity reserved units of a record back to its available stock.
+	ReleaseReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error)
+	// CommitReserved removes quantity reserved units from a record's stock altogether.
+	CommitReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error)
+}
+
+// StockVolume is the storage taken by a warehouse's stock.
+type StockVolume struct {
+	// Used is the volume of the records that carry their unit volume.
+	Used int
+	// Unsized totals, per product, the units on records written before unit volumes were stored.
+	Unsized map[primitive.ObjectID]int
+}
+
+// LocationStock is what one location of a warehouse holds.
+type LocationStock struct {
+	// Quantities totals the units of each product.
+	Quantities map[primitive.ObjectID]int
+	// Volume is the storage the units take.
+	Volume StockVolume
+}
+
+// add counts quantity units of a product, each taking unitVolume of storage, or of unknown size when it is zero.
+func (l *LocationStock) add(productID primitive.ObjectID, unitVolume, quantity int) {
+	l.Quantities[productID] += quantity
+	if unitVolume > 0 {
+		l.Volume.Used += quantity * unitVolume
+	} else {
+		l.Volume.Unsized[productID] += quantity
+	}
+}
+
+func newLocationStock() *LocationStock {
+	return &LocationStock{Quantities: map[primitive.ObjectID]int{}, Volume: StockVolume{Unsized: map[primitive.ObjectID]int{}}}
+}
+
+// availableAtLeast matches records whose unreserved stock is at least quantity. Records written
+// before reservations existed have no reserved field and count as having none reserved.
+func availableAtLeast(quantity int) bson.M {
+	return bson.M{"$gte": bson.A{
+		bson.M{"$subtract": bson.A{"$quantity", bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+		quantity,
+	}}
+}
+
+// reservationMatch matches the records a reservation request may take units from.
+func reservationMatch(request *model.ReservationRequest) bson.M {
+	match := bson.M{"product_id": request.ProductID}
+	if !request.WarehouseID.IsZero() {
+		match["warehouse_id"] = request.WarehouseID
+	}
+	if request.Location != "" {
+		match["location"] = request.Location
+	}
+	return match
+}
+
+// allocate picks which of candidates, the records matching a request in _id order, hold the
+// requested units. The first record with enough available holds them all; failing that they are
+// split, in order, across the records of the first warehouse that has enough available in total,
+// so a reservation is never picked from two warehouses. It returns nil when neither is possible.
+func allocate(request *model.ReservationRequest, candidates []model.Inventory) *model.Reservation {
+	for _, inventory := range candidates {
+		if inventory.Available() >= request.Quantity {
+			return &model.Reservation{
+				ProductID:   request.ProductID,
+				WarehouseID: inventory.WarehouseID,
+				Quantity:    request.Quantity,
+				Allocations: []model.Allocation{{InventoryID: inventory.ID, Location: inventory.Location, Quantity: request.Quantity}},
+			}
+		}
+	}
+
+	var warehouses []primitive.ObjectID
+	available := map[primitive.ObjectID]int{}
+	for _, inventory := range candidates {
+		if _, seen := available[inventory.WarehouseID]; !seen {
+			warehouses = append(warehouses, inventory.WarehouseID)
+		}
+		available[inventory.WarehouseID] += max(inventory.Available(), 0)
+	}
+	for _, warehouseID := range warehouses {
+		if available[warehouseID] < request.Quantity {
+			continue
+		}
+		reservation := &model.Reservation{ProductID: request.ProductID, WarehouseID: warehouseID, Quantity: request.Quantity}
+		remaining := request.Quantity
+		for _, inventory := range candidates {
+			if inventory.WarehouseID != warehouseID || inventory.Available() <= 0 {
+				continue
+			}
+			held := min(inventory.Available(), remaining)
+			reservation.Allocations = append(reservation.Allocations, model.Allocation{InventoryID: inventory.ID, Location: inventory.Location, Quantity: held})
+			if remaining -= held; remaining == 0 {
+				return reservation
+			}
+		}
+	}
+	return nil
 }
 
 // inventoryRepositoryImpl implements InventoryRepository.
@@ -27,8 +183,12 @@ type inventoryRepositoryImpl struct {
 	collection *mongo.Collection
 }
 
-// NewInventoryRepository creates a new instance of InventoryRepository.
+// NewInventoryRepository creates a new instance of InventoryRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
 func NewInventoryRepository() InventoryRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryInventoryRepository()
+	}
 	// Ensure database.Client is initialized before calling GetCollection
 	if database.Client == nil {
 		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
@@ -38,6 +198,9 @@ func NewInventoryRepository() InventoryRepository {
 }
 
 func (r *inventoryRepositoryImpl) CreateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "CreateInventory")
+	defer done()
+
 	result, err := r.collection.InsertOne(ctx, inventory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create inventory in repository: %w", err)
@@ -46,60 +209,418 @@ func (r *inventoryRepositoryImpl) CreateInventory(ctx context.Context, inventory
 	return inventory, nil
 }
 
-func (r *inventoryRepositoryImpl) GetAllInventories(ctx context.Context) ([]model.Inventory, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// GetAllInventories returns one page of inventory records and the cursor for the next page.
+func (r *inventoryRepositoryImpl) GetAllInventories(ctx context.Context, params *pagination.Params) ([]model.Inventory, string, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "GetAllInventories")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve inventories from repository: %w", err)
+		return nil, "", fmt.Errorf("failed to retrieve inventories from repository: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var inventories []model.Inventory
+	inventories := []model.Inventory{}
 	if err = cursor.All(ctx, &inventories); err != nil {
-		return nil, fmt.Errorf("failed to decode inventories from cursor: %w", err)
+		return nil, "", fmt.Errorf("failed to decode inventories from cursor: %w", err)
 	}
-	return inventories, nil
+	return pagination.Page(inventories, params)
 }
 
 func (r *inventoryRepositoryImpl) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "GetInventoryByID")
+	defer done()
+
 	var inventory model.Inventory
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&inventory)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("inventory not found in repository")
+			return nil, ErrInventoryNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve inventory by ID from repository: %w", err)
 	}
 	return &inventory, nil
 }
 
+// UpdateInventory replaces a record's fields under a guard on its reserved units: the quantity
+// may not drop below them, and product, warehouse and location may only change while none are
+// reserved. A reservation taken since the caller read the record therefore fails the update.
 func (r *inventoryRepositoryImpl) UpdateInventory(ctx context.Context, id primitive.ObjectID, inventory *model.Inventory) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "UpdateInventory")
+	defer done()
+
+	filter := bson.M{
+		"_id":   id,
+		"$expr": bson.M{"$gte": bson.A{inventory.Quantity, bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+		"$or": bson.A{
+			bson.M{"reserved": bson.M{"$not": bson.M{"$gt": 0}}},
+			bson.M{"product_id": inventory.ProductID, "warehouse_id": inventory.WarehouseID, "location": inventory.Location},
+		},
+	}
 	updateDoc := bson.M{
 		"$set": bson.M{
 			"product_id":   inventory.ProductID,
+			"warehouse_id": inventory.WarehouseID,
 			"quantity":     inventory.Quantity,
 			"location":     inventory.Location,
+			"unit_volume":  inventory.UnitVolume,
 			"last_updated": inventory.LastUpdated,
 		},
 	}
 
-	result, err := r.collection.UpdateByID(ctx, id, updateDoc)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated model.Inventory
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&updated)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Nothing matched: either the record is gone or the reservation guard rejected the update.
+			if _, getErr := r.GetInventoryByID(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrStockReserved
+		}
 		return nil, fmt.Errorf("failed to update inventory in repository: %w", err)
 	}
-	if result.ModifiedCount == 0 {
-		return nil, errors.New("inventory not found or no changes made in repository")
-	}
-
-	return r.GetInventoryByID(ctx, id)
+	return &updated, nil
 }
 
 func (r *inventoryRepositoryImpl) DeleteInventory(ctx context.Context, id primitive.ObjectID) error {
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	ctx, done := instrument.Repository(ctx, "inventory", "DeleteInventory")
+	defer done()
+
+	// A record holding reserved units is kept, even if a reservation was taken after the caller checked.
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "reserved": bson.M{"$not": bson.M{"$gt": 0}}})
 	if err != nil {
 		return fmt.Errorf("failed to delete inventory from repository: %w", err)
 	}
 	if result.DeletedCount == 0 {
-		return errors.New("inventory not found in repository")
+		if _, getErr := r.GetInventoryByID(ctx, id); getErr != nil {
+			return getErr
+		}
+		return ErrStockReserved
 	}
 	return nil
 }
+
+// AdjustQuantity atomically applies a signed delta to an inventory record's quantity.
+// A negative delta only matches while enough unreserved stock is on hand, so concurrent picks
+// can never drive the quantity below zero or take units held by a reservation.
+func (r *inventoryRepositoryImpl) AdjustQuantity(ctx context.Context, id primitive.ObjectID, delta int) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "AdjustQuantity")
+	defer done()
+
+	filter := bson.M{"_id": id}
+	if delta < 0 {
+		filter["$expr"] = availableAtLeast(-delta)
+	}
+	updateDoc := bson.M{
+		"$inc": bson.M{"quantity": delta},
+		"$set": bson.M{"last_updated": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inventory model.Inventory
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&inventory)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Nothing matched: either the record is gone or the stock guard rejected the delta.
+			if _, getErr := r.GetInventoryByID(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrInsufficientStock
+		}
+		return nil, fmt.Errorf("failed to adjust inventory quantity in repository: %w", err)
+	}
+	return &inventory, nil
+}
+
+// TransferStock moves stock of a product between two locations in a single transaction, or as
+// part of the caller's transaction when ctx carries one.
+// The source record is decremented under the same available-stock guard as AdjustQuantity, and the
+// destination record is incremented, or created when the product is not yet stocked there.
+func (r *inventoryRepositoryImpl) TransferStock(ctx context.Context, transfer *model.StockTransfer) (*model.Inventory, *model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "TransferStock")
+	defer done()
+
+	var source, destination model.Inventory
+	err := withTransaction(ctx, r.collection.Database().Client(), func(sessCtx context.Context) error {
+		now := time.Now()
+
+		sourceMatch := bson.M{"product_id": transfer.ProductID, "location": transfer.FromLocation}
+		if !transfer.FromWarehouseID.IsZero() {
+			sourceMatch["warehouse_id"] = transfer.FromWarehouseID
+		}
+		sourceFilter := bson.M{"$expr": availableAtLeast(transfer.Quantity)}
+		for key, value := range sourceMatch {
+			sourceFilter[key] = value
+		}
+		sourceUpdate := bson.M{"$inc": bson.M{"quantity": -transfer.Quantity}, "$set": bson.M{"last_updated": now}}
+		err := r.collection.FindOneAndUpdate(sessCtx, sourceFilter, sourceUpdate,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&source)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				count, countErr := r.collection.CountDocuments(sessCtx, sourceMatch)
+				if countErr != nil {
+					return countErr
+				}
+				if count == 0 {
+					return ErrTransferSourceNotFound
+				}
+				return ErrInsufficientStock
+			}
+			return err
+		}
+
+		destinationWarehouseID := transfer.ToWarehouseID
+		if destinationWarehouseID.IsZero() {
+			destinationWarehouseID = source.WarehouseID
+		}
+		destinationFilter := bson.M{"product_id": transfer.ProductID, "warehouse_id": destinationWarehouseID, "location": transfer.ToLocation}
+		destinationSet := bson.M{"last_updated": now}
+		if source.UnitVolume > 0 {
+			destinationSet["unit_volume"] = source.UnitVolume
+		}
+		destinationUpdate := bson.M{"$inc": bson.M{"quantity": transfer.Quantity}, "$set": destinationSet}
+		return r.collection.FindOneAndUpdate(sessCtx, destinationFilter, destinationUpdate,
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&destination)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTransferSourceNotFound) || errors.Is(err, ErrInsufficientStock) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("failed to transfer stock in repository: %w", err)
+	}
+	return &source, &destination, nil
+}
+
+// GetStockVolume sums quantity times unit volume over a warehouse's records in one aggregation.
+func (r *inventoryRepositoryImpl) GetStockVolume(ctx context.Context, warehouseID primitive.ObjectID) (*StockVolume, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "GetStockVolume")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"warehouse_id": warehouseID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"product_id": "$product_id", "unit_volume": bson.M{"$ifNull": bson.A{"$unit_volume", 0}}},
+			"quantity": bson.M{"$sum": "$quantity"},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate warehouse stock volume in repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Key struct {
+			ProductID  primitive.ObjectID `bson:"product_id"`
+			UnitVolume int                `bson:"unit_volume"`
+		} `bson:"_id"`
+		Quantity int `bson:"quantity"`
+	}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, fmt.Errorf("failed to decode warehouse stock volume from cursor: %w", err)
+	}
+
+	volume := &StockVolume{Unsized: map[primitive.ObjectID]int{}}
+	for _, total := range totals {
+		if total.Key.UnitVolume > 0 {
+			volume.Used += total.Quantity * total.Key.UnitVolume
+		} else {
+			volume.Unsized[total.Key.ProductID] += total.Quantity
+		}
+	}
+	return volume, nil
+}
+
+// ClaimWarehouse bumps a counter on the warehouse's document in warehouse_claims. Two open
+// transactions writing the same document conflict, so MongoDB retries one of them.
+func (r *inventoryRepositoryImpl) ClaimWarehouse(ctx context.Context, warehouseID primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "inventory", "ClaimWarehouse")
+	defer done()
+
+	claims := r.collection.Database().Collection("warehouse_claims")
+	_, err := claims.UpdateOne(ctx, bson.M{"_id": warehouseID}, bson.M{"$inc": bson.M{"claims": 1}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to claim warehouse in repository: %w", err)
+	}
+	return nil
+}
+
+// GetQuantitiesByWarehouse totals the units on hand in each warehouse.
+func (r *inventoryRepositoryImpl) GetQuantitiesByWarehouse(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "GetQuantitiesByWarehouse")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$warehouse_id", "quantity": bson.M{"$sum": "$quantity"}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate stock per warehouse in repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		WarehouseID primitive.ObjectID `bson:"_id"`
+		Quantity    int                `bson:"quantity"`
+	}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, fmt.Errorf("failed to decode stock per warehouse from cursor: %w", err)
+	}
+
+	quantities := make(map[primitive.ObjectID]int, len(totals))
+	for _, total := range totals {
+		quantities[total.WarehouseID] = total.Quantity
+	}
+	return quantities, nil
+}
+
+func (r *inventoryRepositoryImpl) GetStockByLocation(ctx context.Context, warehouseID primitive.ObjectID) (map[string]*LocationStock, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "GetStockByLocation")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"warehouse_id": warehouseID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"location":    "$location",
+				"product_id":  "$product_id",
+				"unit_volume": bson.M{"$ifNull": bson.A{"$unit_volume", 0}},
+			},
+			"quantity": bson.M{"$sum": "$quantity"},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate stock per location in repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Key struct {
+			Location   string             `bson:"location"`
+			ProductID  primitive.ObjectID `bson:"product_id"`
+			UnitVolume int                `bson:"unit_volume"`
+		} `bson:"_id"`
+		Quantity int `bson:"quantity"`
+	}
+	if err = cursor.All(ctx, &totals); err != nil {
+		return nil, fmt.Errorf("failed to decode stock per location from cursor: %w", err)
+	}
+
+	stock := map[string]*LocationStock{}
+	for _, total := range totals {
+		if stock[total.Key.Location] == nil {
+			stock[total.Key.Location] = newLocationStock()
+		}
+		stock[total.Key.Location].add(total.Key.ProductID, total.Key.UnitVolume, total.Quantity)
+	}
+	return stock, nil
+}
+
+func (r *inventoryRepositoryImpl) AddStock(ctx context.Context, productID, warehouseID primitive.ObjectID, location string, quantity, unitVolume int) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "AddStock")
+	defer done()
+
+	filter := bson.M{"product_id": productID, "warehouse_id": warehouseID, "location": location}
+	updateDoc := bson.M{"$inc": bson.M{"quantity": quantity}, "$set": bson.M{"unit_volume": unitVolume, "last_updated": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var inventory model.Inventory
+	if err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&inventory); err != nil {
+		return nil, fmt.Errorf("failed to add stock in repository: %w", err)
+	}
+	return &inventory, nil
+}
+
+// ReserveStock reads the matching records that have stock available and raises the reserved
+// count of each one allocate picks in a guarded update, so concurrent reservations can never
+// hold more than is on hand.
+func (r *inventoryRepositoryImpl) ReserveStock(ctx context.Context, request *model.ReservationRequest) (*model.Reservation, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "ReserveStock")
+	defer done()
+
+	match := reservationMatch(request)
+	filter := bson.M{"$expr": availableAtLeast(1)}
+	for key, value := range match {
+		filter[key] = value
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reservation candidates in repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	candidates := []model.Inventory{}
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation candidates from cursor: %w", err)
+	}
+	reservation := allocate(request, candidates)
+	if reservation == nil {
+		count, countErr := r.collection.CountDocuments(ctx, match)
+		if countErr != nil {
+			return nil, fmt.Errorf("failed to count reservation candidates in repository: %w", countErr)
+		}
+		if count == 0 {
+			return nil, ErrReservationSourceNotFound
+		}
+		return nil, ErrInsufficientAvailable
+	}
+
+	for _, allocation := range reservation.Allocations {
+		guarded := bson.M{"_id": allocation.InventoryID, "$expr": availableAtLeast(allocation.Quantity)}
+		updateDoc := bson.M{
+			"$inc": bson.M{"reserved": allocation.Quantity},
+			"$set": bson.M{"last_updated": time.Now()},
+		}
+		result, err := r.collection.UpdateOne(ctx, guarded, updateDoc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve stock in repository: %w", err)
+		}
+		if result.MatchedCount == 0 {
+			// The units were taken since the candidates were read. Failing rolls back the
+			// allocations already made with the caller's transaction.
+			return nil, ErrInsufficientAvailable
+		}
+	}
+	return reservation, nil
+}
+
+func (r *inventoryRepositoryImpl) ReleaseReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "ReleaseReserved")
+	defer done()
+
+	return r.decrementReserved(ctx, id, quantity, bson.M{"reserved": -quantity})
+}
+
+func (r *inventoryRepositoryImpl) CommitReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error) {
+	ctx, done := instrument.Repository(ctx, "inventory", "CommitReserved")
+	defer done()
+
+	return r.decrementReserved(ctx, id, quantity, bson.M{"reserved": -quantity, "quantity": -quantity})
+}
+
+// decrementReserved applies increments to a record that holds at least quantity reserved units.
+func (r *inventoryRepositoryImpl) decrementReserved(ctx context.Context, id primitive.ObjectID, quantity int, increments bson.M) (*model.Inventory, error) {
+	filter := bson.M{"_id": id, "reserved": bson.M{"$gte": quantity}}
+	updateDoc := bson.M{
+		"$inc": increments,
+		"$set": bson.M{"last_updated": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inventory model.Inventory
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&inventory)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if _, getErr := r.GetInventoryByID(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrReservedStockMismatch
+		}
+		return nil, fmt.Errorf("failed to update reserved stock in repository: %w", err)
+	}
+	return &inventory, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/database"
+	"Inventory-Services/model"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrASNNotFound is returned when no ASN matches the given ID.
+	ErrASNNotFound = apperrors.NotFound("ASN not found")
+	// ErrASNChanged is returned when an ASN was changed by another request between being read and written.
+	ErrASNChanged = apperrors.Conflict("ASN was changed by another request; reload it and retry")
+)
+
+// ASNListSpec lists the fields clients may sort and filter ASNs on. Newest ASNs come first by default.
+var ASNListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"createdAt":  "created_at",
+		"expectedAt": "expected_at",
+		"supplier":   "supplier",
+	},
+	FilterFields: map[string]pagination.Field{
+		"status":      {BSON: "status", Kind: pagination.String},
+		"supplier":    {BSON: "supplier", Kind: pagination.String},
+		"reference":   {BSON: "reference", Kind: pagination.String},
+		"warehouseId": {BSON: "warehouse_id", Kind: pagination.ObjectID},
+	},
+	DefaultSort: "-createdAt",
+}
+
+// ASNRepository defines the interface for advance shipping notice data operations.
+type ASNRepository interface {
+	CreateASN(ctx context.Context, asn *model.ASN) (*model.ASN, error)
+	GetAllASNs(ctx context.Context, params *pagination.Params) ([]model.ASN, string, error)
+	GetASNByID(ctx context.Context, id primitive.ObjectID) (*model.ASN, error)
+	// SaveASN writes the status, lines and closing time of asn, which must have been read at
+	// asn.Version. It fails with ErrASNChanged if the stored ASN has moved on since, and bumps
+	// the version otherwise.
+	SaveASN(ctx context.Context, asn *model.ASN) (*model.ASN, error)
+}
+
+// asnRepositoryImpl implements ASNRepository.
+type asnRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewASNRepository creates a new instance of ASNRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewASNRepository() ASNRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryASNRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	collection := database.GetCollection(database.Client, "inbound_asns")
+	return &asnRepositoryImpl{collection: collection}
+}
+
+func (r *asnRepositoryImpl) CreateASN(ctx context.Context, asn *model.ASN) (*model.ASN, error) {
+	ctx, done := instrument.Repository(ctx, "asn", "CreateASN")
+	defer done()
+
+	result, err := r.collection.InsertOne(ctx, asn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ASN in repository: %w", err)
+	}
+	asn.ID = result.InsertedID.(primitive.ObjectID)
+	return asn, nil
+}
+
+// GetAllASNs returns one page of ASNs and the cursor for the next page.
+func (r *asnRepositoryImpl) GetAllASNs(ctx context.Context, params *pagination.Params) ([]model.ASN, string, error) {
+	ctx, done := instrument.Repository(ctx, "asn", "GetAllASNs")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve ASNs from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	asns := []model.ASN{}
+	if err = cursor.All(ctx, &asns); err != nil {
+		return nil, "", fmt.Errorf("failed to decode ASNs from cursor: %w", err)
+	}
+	return pagination.Page(asns, params)
+}
+
+func (r *asnRepositoryImpl) GetASNByID(ctx context.Context, id primitive.ObjectID) (*model.ASN, error) {
+	ctx, done := instrument.Repository(ctx, "asn", "GetASNByID")
+	defer done()
+
+	var asn model.ASN
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&asn)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrASNNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve ASN by ID from repository: %w", err)
+	}
+	return &asn, nil
+}
+
+func (r *asnRepositoryImpl) SaveASN(ctx context.Context, asn *model.ASN) (*model.ASN, error) {
+	ctx, done := instrument.Repository(ctx, "asn", "SaveASN")
+	defer done()
+
+	set := bson.M{
+		"status":     asn.Status,
+		"lines":      asn.Lines,
+		"updated_at": time.Now(),
+	}
+	if asn.ClosedAt != nil {
+		set["closed_at"] = asn.ClosedAt
+	}
+	filter := bson.M{"_id": asn.ID, "version": asn.Version}
+	updateDoc := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var saved model.ASN
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&saved)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if _, getErr := r.GetASNByID(ctx, asn.ID); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrASNChanged
+		}
+		return nil, fmt.Errorf("failed to save ASN in repository: %w", err)
+	}
+	return &saved, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/database"
+	"Inventory-Services/model"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLocationNotFound is returned when no storage location matches the given ID.
+var ErrLocationNotFound = apperrors.NotFound("storage location not found")
+
+// LocationListSpec lists the fields clients may sort and filter storage locations on.
+var LocationListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"code":     "code",
+		"distance": "distance",
+		"capacity": "capacity",
+	},
+	FilterFields: map[string]pagination.Field{
+		"warehouseId":   {BSON: "warehouse_id", Kind: pagination.ObjectID},
+		"code":          {BSON: "code", Kind: pagination.String},
+		"homeProductId": {BSON: "home_product_id", Kind: pagination.ObjectID},
+	},
+}
+
+// LocationRepository defines the interface for storage location data operations.
+type LocationRepository interface {
+	CreateLocation(ctx context.Context, location *model.StorageLocation) (*model.StorageLocation, error)
+	GetAllLocations(ctx context.Context, params *pagination.Params) ([]model.StorageLocation, string, error)
+	GetLocationByID(ctx context.Context, id primitive.ObjectID) (*model.StorageLocation, error)
+	// GetLocationByCode returns the location of a warehouse with the given code.
+	GetLocationByCode(ctx context.Context, warehouseID primitive.ObjectID, code string) (*model.StorageLocation, error)
+	// GetLocationsByWarehouse returns every location of a warehouse, nearest first.
+	GetLocationsByWarehouse(ctx context.Context, warehouseID primitive.ObjectID) ([]model.StorageLocation, error)
+	UpdateLocation(ctx context.Context, id primitive.ObjectID, location *model.StorageLocation) (*model.StorageLocation, error)
+	DeleteLocation(ctx context.Context, id primitive.ObjectID) error
+}
+
+// locationRepositoryImpl implements LocationRepository.
+type locationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewLocationRepository creates a new instance of LocationRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewLocationRepository() LocationRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryLocationRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	collection := database.GetCollection(database.Client, "storage_locations")
+	return &locationRepositoryImpl{collection: collection}
+}
+
+func (r *locationRepositoryImpl) CreateLocation(ctx context.Context, location *model.StorageLocation) (*model.StorageLocation, error) {
+	ctx, done := instrument.Repository(ctx, "location", "CreateLocation")
+	defer done()
+
+	result, err := r.collection.InsertOne(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage location in repository: %w", err)
+	}
+	location.ID = result.InsertedID.(primitive.ObjectID)
+	return location, nil
+}
+
+// GetAllLocations returns one page of storage locations and the cursor for the next page.
+func (r *locationRepositoryImpl) GetAllLocations(ctx context.Context, params *pagination.Params) ([]model.StorageLocation, string, error) {
+	ctx, done := instrument.Repository(ctx, "location", "GetAllLocations")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve storage locations from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	locations := []model.StorageLocation{}
+	if err = cursor.All(ctx, &locations); err != nil {
+		return nil, "", fmt.Errorf("failed to decode storage locations from cursor: %w", err)
+	}
+	return pagination.Page(locations, params)
+}
+
+func (r *locationRepositoryImpl) GetLocationByID(ctx context.Context, id primitive.ObjectID) (*model.StorageLocation, error) {
+	ctx, done := instrument.Repository(ctx, "location", "GetLocationByID")
+	defer done()
+
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *locationRepositoryImpl) GetLocationByCode(ctx context.Context, warehouseID primitive.ObjectID, code string) (*model.StorageLocation, error) {
+	ctx, done := instrument.Repository(ctx, "location", "GetLocationByCode")
+	defer done()
+
+	return r.findOne(ctx, bson.M{"warehouse_id": warehouseID, "code": code})
+}
+
+func (r *locationRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*model.StorageLocation, error) {
+	var location model.StorageLocation
+	err := r.collection.FindOne(ctx, filter).Decode(&location)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve storage location from repository: %w", err)
+	}
+	return &location, nil
+}
+
+func (r *locationRepositoryImpl) GetLocationsByWarehouse(ctx context.Context, warehouseID primitive.ObjectID) ([]model.StorageLocation, error) {
+	ctx, done := instrument.Repository(ctx, "location", "GetLocationsByWarehouse")
+	defer done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "distance", Value: 1}, {Key: "code", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"warehouse_id": warehouseID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve warehouse locations from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	locations := []model.StorageLocation{}
+	if err = cursor.All(ctx, &locations); err != nil {
+		return nil, fmt.Errorf("failed to decode warehouse locations from cursor: %w", err)
+	}
+	return locations, nil
+}
+
+func (r *locationRepositoryImpl) UpdateLocation(ctx context.Context, id primitive.ObjectID, location *model.StorageLocation) (*model.StorageLocation, error) {
+	ctx, done := instrument.Repository(ctx, "location", "UpdateLocation")
+	defer done()
+
+	set := bson.M{
+		"warehouse_id": location.WarehouseID,
+		"code":         location.Code,
+		"capacity":     location.Capacity,
+		"distance":     location.Distance,
+	}
+	updateDoc := bson.M{"$set": set}
+	if location.HomeProductID == nil {
+		updateDoc["$unset"] = bson.M{"home_product_id": ""}
+	} else {
+		set["home_product_id"] = location.HomeProductID
+	}
+
+	result, err := r.collection.UpdateByID(ctx, id, updateDoc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update storage location in repository: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrLocationNotFound
+	}
+
+	return r.GetLocationByID(ctx, id)
+}
+
+func (r *locationRepositoryImpl) DeleteLocation(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "location", "DeleteLocation")
+	defer done()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete storage location from repository: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrLocationNotFound
+	}
+	return nil
+}
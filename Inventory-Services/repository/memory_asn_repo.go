@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"slices"
+	"sync"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryASNRepository is an ASNRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryASNRepository struct {
+	mu   sync.RWMutex
+	asns map[primitive.ObjectID]model.ASN
+}
+
+// NewInMemoryASNRepository creates an empty InMemoryASNRepository.
+func NewInMemoryASNRepository() *InMemoryASNRepository {
+	return &InMemoryASNRepository{asns: map[primitive.ObjectID]model.ASN{}}
+}
+
+func (r *InMemoryASNRepository) CreateASN(ctx context.Context, asn *model.ASN) (*model.ASN, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if asn.ID.IsZero() {
+		asn.ID = primitive.NewObjectID()
+	}
+	r.remember(ctx, asn.ID)
+	r.asns[asn.ID] = cloneASN(*asn)
+	return asn, nil
+}
+
+func (r *InMemoryASNRepository) GetAllASNs(ctx context.Context, params *pagination.Params) ([]model.ASN, string, error) {
+	r.mu.RLock()
+	asns := make([]model.ASN, 0, len(r.asns))
+	for _, asn := range r.asns {
+		asns = append(asns, cloneASN(asn))
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(asns, params)
+}
+
+func (r *InMemoryASNRepository) GetASNByID(ctx context.Context, id primitive.ObjectID) (*model.ASN, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	asn, ok := r.asns[id]
+	if !ok {
+		return nil, ErrASNNotFound
+	}
+	asn = cloneASN(asn)
+	return &asn, nil
+}
+
+func (r *InMemoryASNRepository) SaveASN(ctx context.Context, asn *model.ASN) (*model.ASN, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.asns[asn.ID]
+	if !ok {
+		return nil, ErrASNNotFound
+	}
+	if stored.Version != asn.Version {
+		return nil, ErrASNChanged
+	}
+	r.remember(ctx, asn.ID)
+	stored.Status = asn.Status
+	stored.Lines = slices.Clone(asn.Lines)
+	if asn.ClosedAt != nil {
+		stored.ClosedAt = asn.ClosedAt
+	}
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	r.asns[asn.ID] = stored
+
+	saved := cloneASN(stored)
+	return &saved, nil
+}
+
+// remember arranges for the ASN with id to be put back as it is now if the transaction ctx
+// belongs to fails. Callers hold r.mu.
+func (r *InMemoryASNRepository) remember(ctx context.Context, id primitive.ObjectID) {
+	previous, existed := r.asns[id]
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.asns[id] = previous
+		} else {
+			delete(r.asns, id)
+		}
+	})
+}
+
+// cloneASN copies an ASN so callers cannot mutate stored lines.
+func cloneASN(asn model.ASN) model.ASN {
+	asn.Lines = slices.Clone(asn.Lines)
+	return asn
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryInventoryRepository is an InventoryRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+// A single lock makes adjustments and transfers atomic, as the MongoDB implementation is, and
+// writes made inside an InMemoryTransactor transaction are undone if it fails.
+type InMemoryInventoryRepository struct {
+	mu          sync.RWMutex
+	inventories map[primitive.ObjectID]model.Inventory
+}
+
+// NewInMemoryInventoryRepository creates an empty InMemoryInventoryRepository.
+func NewInMemoryInventoryRepository() *InMemoryInventoryRepository {
+	return &InMemoryInventoryRepository{inventories: map[primitive.ObjectID]model.Inventory{}}
+}
+
+func (r *InMemoryInventoryRepository) CreateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inventory.ID.IsZero() {
+		inventory.ID = primitive.NewObjectID()
+	}
+	r.remember(ctx, inventory.ID)
+	r.inventories[inventory.ID] = *inventory
+	return inventory, nil
+}
+
+func (r *InMemoryInventoryRepository) GetAllInventories(ctx context.Context, params *pagination.Params) ([]model.Inventory, string, error) {
+	r.mu.RLock()
+	inventories := make([]model.Inventory, 0, len(r.inventories))
+	for _, inventory := range r.inventories {
+		inventories = append(inventories, inventory)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(inventories, params)
+}
+
+func (r *InMemoryInventoryRepository) GetInventoryByID(ctx context.Context, id primitive.ObjectID) (*model.Inventory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inventory, ok := r.inventories[id]
+	if !ok {
+		return nil, ErrInventoryNotFound
+	}
+	return &inventory, nil
+}
+
+func (r *InMemoryInventoryRepository) UpdateInventory(ctx context.Context, id primitive.ObjectID, inventory *model.Inventory) (*model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.inventories[id]
+	if !ok {
+		return nil, ErrInventoryNotFound
+	}
+	if stored.Reserved > 0 && (inventory.Quantity < stored.Reserved || inventory.ProductID != stored.ProductID ||
+		inventory.WarehouseID != stored.WarehouseID || inventory.Location != stored.Location) {
+		return nil, ErrStockReserved
+	}
+	r.remember(ctx, id)
+	stored.ProductID = inventory.ProductID
+	stored.WarehouseID = inventory.WarehouseID
+	stored.Quantity = inventory.Quantity
+	stored.Location = inventory.Location
+	stored.UnitVolume = inventory.UnitVolume
+	stored.LastUpdated = inventory.LastUpdated
+	r.inventories[id] = stored
+	return &stored, nil
+}
+
+func (r *InMemoryInventoryRepository) DeleteInventory(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.inventories[id]
+	if !ok {
+		return ErrInventoryNotFound
+	}
+	if stored.Reserved > 0 {
+		return ErrStockReserved
+	}
+	r.remember(ctx, id)
+	delete(r.inventories, id)
+	return nil
+}
+
+func (r *InMemoryInventoryRepository) AdjustQuantity(ctx context.Context, id primitive.ObjectID, delta int) (*model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inventory, ok := r.inventories[id]
+	if !ok {
+		return nil, ErrInventoryNotFound
+	}
+	if delta < 0 && inventory.Available() < -delta {
+		return nil, ErrInsufficientStock
+	}
+	r.remember(ctx, id)
+	inventory.Quantity += delta
+	inventory.LastUpdated = time.Now()
+	r.inventories[id] = inventory
+	return &inventory, nil
+}
+
+func (r *InMemoryInventoryRepository) TransferStock(ctx context.Context, transfer *model.StockTransfer) (*model.Inventory, *model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Match records in _id order so the choice of source is deterministic.
+	ids := make([]primitive.ObjectID, 0, len(r.inventories))
+	for id := range r.inventories {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Hex() < ids[j].Hex() })
+
+	var source *model.Inventory
+	sourceFound := false
+	for _, id := range ids {
+		inventory := r.inventories[id]
+		if inventory.ProductID != transfer.ProductID || inventory.Location != transfer.FromLocation {
+			continue
+		}
+		if !transfer.FromWarehouseID.IsZero() && inventory.WarehouseID != transfer.FromWarehouseID {
+			continue
+		}
+		sourceFound = true
+		if inventory.Available() >= transfer.Quantity {
+			source = &inventory
+			break
+		}
+	}
+	if !sourceFound {
+		return nil, nil, ErrTransferSourceNotFound
+	}
+	if source == nil {
+		return nil, nil, ErrInsufficientStock
+	}
+
+	now := time.Now()
+	source.Quantity -= transfer.Quantity
+	source.LastUpdated = now
+	r.remember(ctx, source.ID)
+	r.inventories[source.ID] = *source
+
+	destinationWarehouseID := transfer.ToWarehouseID
+	if destinationWarehouseID.IsZero() {
+		destinationWarehouseID = source.WarehouseID
+	}
+	var destination *model.Inventory
+	for _, id := range ids {
+		inventory := r.inventories[id]
+		if inventory.ProductID == transfer.ProductID && inventory.WarehouseID == destinationWarehouseID && inventory.Location == transfer.ToLocation {
+			destination = &inventory
+			break
+		}
+	}
+	if destination == nil {
+		destination = &model.Inventory{
+			ID:          primitive.NewObjectID(),
+			ProductID:   transfer.ProductID,
+			WarehouseID: destinationWarehouseID,
+			Location:    transfer.ToLocation,
+		}
+	}
+	if source.UnitVolume > 0 {
+		destination.UnitVolume = source.UnitVolume
+	}
+	destination.Quantity += transfer.Quantity
+	destination.LastUpdated = now
+	r.remember(ctx, destination.ID)
+	r.inventories[destination.ID] = *destination
+
+	return source, destination, nil
+}
+
+func (r *InMemoryInventoryRepository) GetStockVolume(ctx context.Context, warehouseID primitive.ObjectID) (*StockVolume, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	volume := &StockVolume{Unsized: map[primitive.ObjectID]int{}}
+	for _, inventory := range r.inventories {
+		if inventory.WarehouseID != warehouseID {
+			continue
+		}
+		if inventory.UnitVolume > 0 {
+			volume.Used += inventory.Quantity * inventory.UnitVolume
+		} else {
+			volume.Unsized[inventory.ProductID] += inventory.Quantity
+		}
+	}
+	return volume, nil
+}
+
+// ClaimWarehouse has nothing to do: InMemoryTransactor already runs one transaction at a time.
+func (r *InMemoryInventoryRepository) ClaimWarehouse(ctx context.Context, warehouseID primitive.ObjectID) error {
+	return nil
+}
+
+func (r *InMemoryInventoryRepository) GetQuantitiesByWarehouse(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quantities := map[primitive.ObjectID]int{}
+	for _, inventory := range r.inventories {
+		quantities[inventory.WarehouseID] += inventory.Quantity
+	}
+	return quantities, nil
+}
+
+func (r *InMemoryInventoryRepository) GetStockByLocation(ctx context.Context, warehouseID primitive.ObjectID) (map[string]*LocationStock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock := map[string]*LocationStock{}
+	for _, inventory := range r.inventories {
+		if inventory.WarehouseID != warehouseID {
+			continue
+		}
+		if stock[inventory.Location] == nil {
+			stock[inventory.Location] = newLocationStock()
+		}
+		stock[inventory.Location].add(inventory.ProductID, inventory.UnitVolume, inventory.Quantity)
+	}
+	return stock, nil
+}
+
+func (r *InMemoryInventoryRepository) AddStock(ctx context.Context, productID, warehouseID primitive.ObjectID, location string, quantity, unitVolume int) (*model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var target *model.Inventory
+	for _, inventory := range r.inventories {
+		if inventory.ProductID == productID && inventory.WarehouseID == warehouseID && inventory.Location == location {
+			target = &inventory
+			break
+		}
+	}
+	if target == nil {
+		target = &model.Inventory{
+			ID:          primitive.NewObjectID(),
+			ProductID:   productID,
+			WarehouseID: warehouseID,
+			Location:    location,
+		}
+	}
+	target.Quantity += quantity
+	target.UnitVolume = unitVolume
+	target.LastUpdated = time.Now()
+	r.remember(ctx, target.ID)
+	r.inventories[target.ID] = *target
+
+	added := *target
+	return &added, nil
+}
+
+func (r *InMemoryInventoryRepository) ReserveStock(ctx context.Context, request *model.ReservationRequest) (*model.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Match records in _id order, as the MongoDB implementation does.
+	candidates := []model.Inventory{}
+	for _, inventory := range r.inventories {
+		if inventory.ProductID != request.ProductID {
+			continue
+		}
+		if (!request.WarehouseID.IsZero() && inventory.WarehouseID != request.WarehouseID) || (request.Location != "" && inventory.Location != request.Location) {
+			continue
+		}
+		candidates = append(candidates, inventory)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID.Hex() < candidates[j].ID.Hex() })
+
+	if len(candidates) == 0 {
+		return nil, ErrReservationSourceNotFound
+	}
+	reservation := allocate(request, candidates)
+	if reservation == nil {
+		return nil, ErrInsufficientAvailable
+	}
+	for _, allocation := range reservation.Allocations {
+		inventory := r.inventories[allocation.InventoryID]
+		r.remember(ctx, inventory.ID)
+		inventory.Reserved += allocation.Quantity
+		inventory.LastUpdated = time.Now()
+		r.inventories[inventory.ID] = inventory
+	}
+	return reservation, nil
+}
+
+func (r *InMemoryInventoryRepository) ReleaseReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error) {
+	return r.decrementReserved(ctx, id, quantity, false)
+}
+
+func (r *InMemoryInventoryRepository) CommitReserved(ctx context.Context, id primitive.ObjectID, quantity int) (*model.Inventory, error) {
+	return r.decrementReserved(ctx, id, quantity, true)
+}
+
+// decrementReserved lowers a record's reserved count and, when the units leave the shelf, its quantity.
+func (r *InMemoryInventoryRepository) decrementReserved(ctx context.Context, id primitive.ObjectID, quantity int, fromStock bool) (*model.Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inventory, ok := r.inventories[id]
+	if !ok {
+		return nil, ErrInventoryNotFound
+	}
+	if inventory.Reserved < quantity {
+		return nil, ErrReservedStockMismatch
+	}
+	r.remember(ctx, id)
+	inventory.Reserved -= quantity
+	if fromStock {
+		inventory.Quantity -= quantity
+	}
+	inventory.LastUpdated = time.Now()
+	r.inventories[id] = inventory
+	return &inventory, nil
+}
+
+// remember arranges for the record with id to be put back as it is now if the transaction ctx
+// belongs to fails. Callers hold r.mu.
+func (r *InMemoryInventoryRepository) remember(ctx context.Context, id primitive.ObjectID) {
+	previous, existed := r.inventories[id]
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.inventories[id] = previous
+		} else {
+			delete(r.inventories, id)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"sort"
+	"sync"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryLocationRepository is a LocationRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryLocationRepository struct {
+	mu        sync.RWMutex
+	locations map[primitive.ObjectID]model.StorageLocation
+}
+
+// NewInMemoryLocationRepository creates an empty InMemoryLocationRepository.
+func NewInMemoryLocationRepository() *InMemoryLocationRepository {
+	return &InMemoryLocationRepository{locations: map[primitive.ObjectID]model.StorageLocation{}}
+}
+
+func (r *InMemoryLocationRepository) CreateLocation(ctx context.Context, location *model.StorageLocation) (*model.StorageLocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if location.ID.IsZero() {
+		location.ID = primitive.NewObjectID()
+	}
+	r.locations[location.ID] = *location
+	return location, nil
+}
+
+func (r *InMemoryLocationRepository) GetAllLocations(ctx context.Context, params *pagination.Params) ([]model.StorageLocation, string, error) {
+	r.mu.RLock()
+	locations := make([]model.StorageLocation, 0, len(r.locations))
+	for _, location := range r.locations {
+		locations = append(locations, location)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(locations, params)
+}
+
+func (r *InMemoryLocationRepository) GetLocationByID(ctx context.Context, id primitive.ObjectID) (*model.StorageLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	location, ok := r.locations[id]
+	if !ok {
+		return nil, ErrLocationNotFound
+	}
+	return &location, nil
+}
+
+func (r *InMemoryLocationRepository) GetLocationByCode(ctx context.Context, warehouseID primitive.ObjectID, code string) (*model.StorageLocation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, location := range r.locations {
+		if location.WarehouseID == warehouseID && location.Code == code {
+			return &location, nil
+		}
+	}
+	return nil, ErrLocationNotFound
+}
+
+func (r *InMemoryLocationRepository) GetLocationsByWarehouse(ctx context.Context, warehouseID primitive.ObjectID) ([]model.StorageLocation, error) {
+	r.mu.RLock()
+	locations := []model.StorageLocation{}
+	for _, location := range r.locations {
+		if location.WarehouseID == warehouseID {
+			locations = append(locations, location)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Distance != locations[j].Distance {
+			return locations[i].Distance < locations[j].Distance
+		}
+		return locations[i].Code < locations[j].Code
+	})
+	return locations, nil
+}
+
+func (r *InMemoryLocationRepository) UpdateLocation(ctx context.Context, id primitive.ObjectID, location *model.StorageLocation) (*model.StorageLocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.locations[id]
+	if !ok {
+		return nil, ErrLocationNotFound
+	}
+	stored.WarehouseID = location.WarehouseID
+	stored.Code = location.Code
+	stored.Capacity = location.Capacity
+	stored.Distance = location.Distance
+	stored.HomeProductID = location.HomeProductID
+	r.locations[id] = stored
+	return &stored, nil
+}
+
+func (r *InMemoryLocationRepository) DeleteLocation(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.locations[id]; !ok {
+		return ErrLocationNotFound
+	}
+	delete(r.locations, id)
+	return nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"slices"
+	"sync"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryMovementRepository is a MovementRepository backed by a slice, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryMovementRepository struct {
+	mu        sync.RWMutex
+	movements []model.Movement
+}
+
+// NewInMemoryMovementRepository creates an empty InMemoryMovementRepository.
+func NewInMemoryMovementRepository() *InMemoryMovementRepository {
+	return &InMemoryMovementRepository{}
+}
+
+func (r *InMemoryMovementRepository) CreateMovement(ctx context.Context, movement *model.Movement) (*model.Movement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if movement.ID.IsZero() {
+		movement.ID = primitive.NewObjectID()
+	}
+	r.movements = append(r.movements, *movement)
+	id := movement.ID
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.movements = slices.DeleteFunc(r.movements, func(m model.Movement) bool { return m.ID == id })
+	})
+	return movement, nil
+}
+
+func (r *InMemoryMovementRepository) GetMovementsByInventoryID(ctx context.Context, inventoryID primitive.ObjectID, from, to time.Time, params *pagination.Params) ([]model.Movement, string, error) {
+	r.mu.RLock()
+	movements := []model.Movement{}
+	for _, movement := range r.movements {
+		if movement.InventoryID != inventoryID {
+			continue
+		}
+		if (!from.IsZero() && movement.Timestamp.Before(from)) || (!to.IsZero() && movement.Timestamp.After(to)) {
+			continue
+		}
+		movements = append(movements, movement)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(movements, params)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryReservationRepository is a ReservationRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryReservationRepository struct {
+	mu           sync.RWMutex
+	reservations map[primitive.ObjectID]model.Reservation
+}
+
+// NewInMemoryReservationRepository creates an empty InMemoryReservationRepository.
+func NewInMemoryReservationRepository() *InMemoryReservationRepository {
+	return &InMemoryReservationRepository{reservations: map[primitive.ObjectID]model.Reservation{}}
+}
+
+func (r *InMemoryReservationRepository) CreateReservation(ctx context.Context, reservation *model.Reservation) (*model.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reservation.ID.IsZero() {
+		reservation.ID = primitive.NewObjectID()
+	}
+	r.remember(ctx, reservation.ID)
+	r.reservations[reservation.ID] = *reservation
+	return reservation, nil
+}
+
+func (r *InMemoryReservationRepository) GetAllReservations(ctx context.Context, params *pagination.Params) ([]model.Reservation, string, error) {
+	r.mu.RLock()
+	reservations := make([]model.Reservation, 0, len(r.reservations))
+	for _, reservation := range r.reservations {
+		reservations = append(reservations, reservation)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(reservations, params)
+}
+
+func (r *InMemoryReservationRepository) GetReservationByID(ctx context.Context, id primitive.ObjectID) (*model.Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	return &reservation, nil
+}
+
+func (r *InMemoryReservationRepository) CloseReservation(ctx context.Context, id primitive.ObjectID, status model.ReservationStatus, closedAt time.Time) (*model.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, ErrReservationNotFound
+	}
+	if reservation.Status != model.ReservationActive {
+		return nil, fmt.Errorf("%w: reservation is %s", ErrReservationClosed, reservation.Status)
+	}
+	r.remember(ctx, id)
+	reservation.Status = status
+	reservation.ClosedAt = &closedAt
+	r.reservations[id] = reservation
+	return &reservation, nil
+}
+
+func (r *InMemoryReservationRepository) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.Reservation, error) {
+	r.mu.RLock()
+	reservations := []model.Reservation{}
+	for _, reservation := range r.reservations {
+		if reservation.Status == model.ReservationActive && !reservation.ExpiresAt.After(now) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ExpiresAt.Before(reservations[j].ExpiresAt) })
+	if len(reservations) > limit {
+		reservations = reservations[:limit]
+	}
+	return reservations, nil
+}
+
+// remember arranges for the reservation with id to be put back as it is now if the transaction
+// ctx belongs to fails. Callers hold r.mu.
+func (r *InMemoryReservationRepository) remember(ctx context.Context, id primitive.ObjectID) {
+	previous, existed := r.reservations[id]
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.reservations[id] = previous
+		} else {
+			delete(r.reservations, id)
+		}
+	})
+}
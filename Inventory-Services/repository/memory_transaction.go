@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryTransactor is the Transactor for the in-memory repositories. It runs one transaction
+// at a time, so reads and writes inside a transaction are not interleaved with another's, and
+// when fn fails it undoes the writes the repositories made on its behalf.
+type InMemoryTransactor struct {
+	mu sync.Mutex
+}
+
+// NewInMemoryTransactor creates an InMemoryTransactor.
+func NewInMemoryTransactor() *InMemoryTransactor {
+	return &InMemoryTransactor{}
+}
+
+type undoLogKey struct{}
+
+// undoLog collects the steps that revert a transaction's writes.
+type undoLog struct {
+	mu    sync.Mutex
+	steps []func()
+}
+
+func (t *InMemoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(undoLogKey{}).(*undoLog); ok {
+		return fn(ctx)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	undo := &undoLog{}
+	hooks := &commitHooks{}
+	ctx = context.WithValue(context.WithValue(ctx, undoLogKey{}, undo), commitHooksKey{}, hooks)
+	if err := fn(ctx); err != nil {
+		for i := len(undo.steps) - 1; i >= 0; i-- {
+			undo.steps[i]()
+		}
+		return err
+	}
+	hooks.run()
+	return nil
+}
+
+// onRollback registers step to run if the transaction ctx belongs to fails. Outside a
+// transaction it does nothing. Steps run without any repository lock held.
+func onRollback(ctx context.Context, step func()) {
+	undo, ok := ctx.Value(undoLogKey{}).(*undoLog)
+	if !ok {
+		return
+	}
+	undo.mu.Lock()
+	undo.steps = append(undo.steps, step)
+	undo.mu.Unlock()
+}
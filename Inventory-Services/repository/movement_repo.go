@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/database"
+	"Inventory-Services/model"
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"wms-common/instrument"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MovementListSpec lists the fields clients may sort and filter ledger entries on. Newest entries come first by default.
+var MovementListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"timestamp": "timestamp",
+		"delta":     "delta",
+	},
+	FilterFields: map[string]pagination.Field{
+		"reason": {BSON: "reason", Kind: pagination.String},
+		"actor":  {BSON: "actor", Kind: pagination.String},
+	},
+	DefaultSort: "-timestamp",
+}
+
+// MovementRepository defines the interface for the append-only inventory movement ledger.
+type MovementRepository interface {
+	CreateMovement(ctx context.Context, movement *model.Movement) (*model.Movement, error)
+	GetMovementsByInventoryID(ctx context.Context, inventoryID primitive.ObjectID, from, to time.Time, params *pagination.Params) ([]model.Movement, string, error)
+}
+
+// movementRepositoryImpl implements MovementRepository.
+type movementRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewMovementRepository creates a new instance of MovementRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewMovementRepository() MovementRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryMovementRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	collection := database.GetCollection(database.Client, "inventory_movements")
+	return &movementRepositoryImpl{collection: collection}
+}
+
+func (r *movementRepositoryImpl) CreateMovement(ctx context.Context, movement *model.Movement) (*model.Movement, error) {
+	ctx, done := instrument.Repository(ctx, "movement", "CreateMovement")
+	defer done()
+
+	result, err := r.collection.InsertOne(ctx, movement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record movement in repository: %w", err)
+	}
+	movement.ID = result.InsertedID.(primitive.ObjectID)
+	return movement, nil
+}
+
+// GetMovementsByInventoryID returns one page of movements for an inventory record.
+// A zero from or to leaves that end of the time range open.
+func (r *movementRepositoryImpl) GetMovementsByInventoryID(ctx context.Context, inventoryID primitive.ObjectID, from, to time.Time, params *pagination.Params) ([]model.Movement, string, error) {
+	ctx, done := instrument.Repository(ctx, "movement", "GetMovementsByInventoryID")
+	defer done()
+
+	filter := params.Filter()
+	filter["inventory_id"] = inventoryID
+	timeRange := bson.M{}
+	if !from.IsZero() {
+		timeRange["$gte"] = from
+	}
+	if !to.IsZero() {
+		timeRange["$lte"] = to
+	}
+	if len(timeRange) > 0 {
+		filter["timestamp"] = timeRange
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, params.FindOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve movements from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	movements := []model.Movement{}
+	if err = cursor.All(ctx, &movements); err != nil {
+		return nil, "", fmt.Errorf("failed to decode movements from cursor: %w", err)
+	}
+	return pagination.Page(movements, params)
+}
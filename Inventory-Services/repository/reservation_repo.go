@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/database"
+	"Inventory-Services/model"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrReservationNotFound is returned when no reservation matches the given ID.
+	ErrReservationNotFound = apperrors.NotFound("reservation not found")
+	// ErrReservationClosed is returned when a reservation that is no longer active is committed, released or expired.
+	ErrReservationClosed = apperrors.Conflict("reservation is no longer active")
+)
+
+// ReservationListSpec lists the fields clients may sort and filter reservations on. Newest reservations come first by default.
+var ReservationListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"createdAt": "created_at",
+		"expiresAt": "expires_at",
+		"quantity":  "quantity",
+	},
+	FilterFields: map[string]pagination.Field{
+		"status":      {BSON: "status", Kind: pagination.String},
+		"referenceId": {BSON: "reference_id", Kind: pagination.String},
+		"productId":   {BSON: "product_id", Kind: pagination.ObjectID},
+		"warehouseId": {BSON: "warehouse_id", Kind: pagination.ObjectID},
+		"inventoryId": {BSON: "allocations.inventory_id", Kind: pagination.ObjectID},
+	},
+	DefaultSort: "-createdAt",
+}
+
+// ReservationRepository defines the interface for reservation data operations. It only keeps
+// the reservation documents; the reserved counts they stand for live on the inventory records.
+type ReservationRepository interface {
+	CreateReservation(ctx context.Context, reservation *model.Reservation) (*model.Reservation, error)
+	GetAllReservations(ctx context.Context, params *pagination.Params) ([]model.Reservation, string, error)
+	GetReservationByID(ctx context.Context, id primitive.ObjectID) (*model.Reservation, error)
+	// CloseReservation moves an active reservation to status, failing with ErrReservationClosed
+	// if it is no longer active. Of two concurrent closes only one succeeds.
+	CloseReservation(ctx context.Context, id primitive.ObjectID, status model.ReservationStatus, closedAt time.Time) (*model.Reservation, error)
+	// GetExpiredReservations returns up to limit active reservations that expired at or before now, oldest first.
+	GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.Reservation, error)
+}
+
+// reservationRepositoryImpl implements ReservationRepository.
+type reservationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewReservationRepository creates a new instance of ReservationRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewReservationRepository() ReservationRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryReservationRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	collection := database.GetCollection(database.Client, "inventory_reservations")
+	return &reservationRepositoryImpl{collection: collection}
+}
+
+func (r *reservationRepositoryImpl) CreateReservation(ctx context.Context, reservation *model.Reservation) (*model.Reservation, error) {
+	ctx, done := instrument.Repository(ctx, "reservation", "CreateReservation")
+	defer done()
+
+	result, err := r.collection.InsertOne(ctx, reservation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reservation in repository: %w", err)
+	}
+	reservation.ID = result.InsertedID.(primitive.ObjectID)
+	return reservation, nil
+}
+
+// GetAllReservations returns one page of reservations and the cursor for the next page.
+func (r *reservationRepositoryImpl) GetAllReservations(ctx context.Context, params *pagination.Params) ([]model.Reservation, string, error) {
+	ctx, done := instrument.Repository(ctx, "reservation", "GetAllReservations")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve reservations from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []model.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, "", fmt.Errorf("failed to decode reservations from cursor: %w", err)
+	}
+	return pagination.Page(reservations, params)
+}
+
+func (r *reservationRepositoryImpl) GetReservationByID(ctx context.Context, id primitive.ObjectID) (*model.Reservation, error) {
+	ctx, done := instrument.Repository(ctx, "reservation", "GetReservationByID")
+	defer done()
+
+	var reservation model.Reservation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&reservation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve reservation by ID from repository: %w", err)
+	}
+	return &reservation, nil
+}
+
+func (r *reservationRepositoryImpl) CloseReservation(ctx context.Context, id primitive.ObjectID, status model.ReservationStatus, closedAt time.Time) (*model.Reservation, error) {
+	ctx, done := instrument.Repository(ctx, "reservation", "CloseReservation")
+	defer done()
+
+	filter := bson.M{"_id": id, "status": model.ReservationActive}
+	updateDoc := bson.M{"$set": bson.M{"status": status, "closed_at": closedAt}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var reservation model.Reservation
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateDoc, opts).Decode(&reservation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			existing, getErr := r.GetReservationByID(ctx, id)
+			if getErr != nil {
+				return nil, getErr
+			}
+			return nil, fmt.Errorf("%w: reservation is %s", ErrReservationClosed, existing.Status)
+		}
+		return nil, fmt.Errorf("failed to close reservation in repository: %w", err)
+	}
+	return &reservation, nil
+}
+
+func (r *reservationRepositoryImpl) GetExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.Reservation, error) {
+	ctx, done := instrument.Repository(ctx, "reservation", "GetExpiredReservations")
+	defer done()
+
+	filter := bson.M{"status": model.ReservationActive, "expires_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve expired reservations from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []model.Reservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, fmt.Errorf("failed to decode expired reservations from cursor: %w", err)
+	}
+	return reservations, nil
+}
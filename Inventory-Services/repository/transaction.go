@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/database"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs units of work that span several repositories atomically.
+type Transactor interface {
+	// WithTransaction runs fn in a transaction. Repository calls made with the context passed to
+	// fn take part in it: they are all applied if fn returns nil and none are if it fails. Called
+	// with a context already inside a transaction, it joins that transaction. fn may run more than
+	// once when MongoDB retries after a transient error, so it should not have other side effects.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// mongoTransactor implements Transactor with MongoDB session transactions, which need a replica set.
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewTransactor creates a Transactor for the configured storage backend.
+func NewTransactor() Transactor {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryTransactor()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	return &mongoTransactor{client: database.Client}
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTransaction(ctx, t.client, fn)
+}
+
+// withTransaction runs fn in a new transaction on client, or in the one ctx already carries.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session in repository: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	hooks := &commitHooks{}
+	ctx = context.WithValue(ctx, commitHooksKey{}, hooks)
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		hooks.reset() // A retried attempt starts over
+		return nil, fn(sessCtx)
+	})
+	if err != nil {
+		return err
+	}
+	hooks.run()
+	return nil
+}
+
+type commitHooksKey struct{}
+
+// commitHooks collects what a transaction runs once it has committed.
+type commitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+func (h *commitHooks) reset() {
+	h.mu.Lock()
+	h.hooks = nil
+	h.mu.Unlock()
+}
+
+func (h *commitHooks) run() {
+	for _, hook := range h.hooks {
+		hook()
+	}
+}
+
+// AfterCommit arranges for fn to run once the transaction ctx belongs to has committed, and not
+// at all if it fails. Outside a transaction fn runs at once. It suits side effects such as
+// metrics that must not count work a transaction rolled back or retried.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks)
+	if !ok {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	hooks.hooks = append(hooks.hooks, fn)
+	hooks.mu.Unlock()
+}
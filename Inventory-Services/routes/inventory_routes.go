@@ -1,29 +1,72 @@
 package routes
 
 import (
+	"Inventory-Services/config"
 	"Inventory-Services/controller"
 	"Inventory-Services/service"
+	"context"
 	"fmt"      // Import fmt for string formatting
 	"net/http" // Import http for redirects
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-// InventoryRoutes sets up the API routes for inventory operations.
-func InventoryRoutes(router *gin.Engine) {
-	inventoryController := controller.NewInventoryController(service.NewInventoryService())
+// InventoryRoutes sets up the API routes for inventory operations. Background work, such as
+// expiring reservations, runs until ctx is cancelled.
+func InventoryRoutes(ctx context.Context, router *gin.Engine) {
+	inventoryService := service.NewInventoryService()
+	inventoryController := controller.NewInventoryController(inventoryService)
+	prometheus.MustRegister(service.NewStockCollector(inventoryService))
+	go service.RunReservationSweeper(ctx, inventoryService, time.Duration(config.Cfg.ReservationSweepIntervalSeconds)*time.Second)
 
 	// Primary routes: define WITHOUT a trailing slash for collection endpoints
-	inventoryGroup := router.Group("/inventory")
+	inventoryGroup := router.Group("/inventory", controller.ActorMiddleware())
 	{
 		// Explicitly handle all HTTP methods for the base /inventory path (no trailing slash)
 		inventoryGroup.POST("", inventoryController.CreateInventory)  // Matches /inventory
 		inventoryGroup.GET("", inventoryController.GetAllInventories) // Matches /inventory
 
+		inventoryGroup.POST("/transfers", inventoryController.TransferStock) // Matches /inventory/transfers
+
+		// Reservations hold stock for a reference until committed, released or expired
+		inventoryGroup.POST("/reservations", inventoryController.ReserveStock)
+		inventoryGroup.GET("/reservations", inventoryController.GetAllReservations)
+		inventoryGroup.GET("/reservations/:reservationId", inventoryController.GetReservationByID)
+		inventoryGroup.POST("/reservations/:reservationId/commit", inventoryController.CommitReservation)
+		inventoryGroup.POST("/reservations/:reservationId/release", inventoryController.ReleaseReservation)
+
+		// Inbound receiving against advance shipping notices
+		inventoryGroup.POST("/asns", inventoryController.CreateASN)
+		inventoryGroup.GET("/asns", inventoryController.GetAllASNs)
+		inventoryGroup.GET("/asns/:asnId", inventoryController.GetASNByID)
+		inventoryGroup.POST("/asns/:asnId/receive", inventoryController.RecordASNReceipt)
+		inventoryGroup.POST("/asns/:asnId/close", inventoryController.CloseASN)
+		inventoryGroup.POST("/asns/:asnId/cancel", inventoryController.CancelASN)
+
+		// Storage locations and putaway into them
+		inventoryGroup.POST("/locations", inventoryController.CreateLocation)
+		inventoryGroup.GET("/locations", inventoryController.GetAllLocations)
+		inventoryGroup.GET("/locations/:locationId", inventoryController.GetLocationByID)
+		inventoryGroup.PUT("/locations/:locationId", inventoryController.UpdateLocation)
+		inventoryGroup.DELETE("/locations/:locationId", inventoryController.DeleteLocation)
+		inventoryGroup.GET("/putaway/suggestions", inventoryController.SuggestPutaway)
+		inventoryGroup.POST("/putaway", inventoryController.ConfirmPutaway)
+
+		// Capacity usage of a warehouse, based on commodity unit volumes
+		inventoryGroup.GET("/warehouses/:warehouseId/utilization", inventoryController.GetWarehouseUtilization)
+
 		// Routes for specific IDs
 		inventoryGroup.GET("/:id", inventoryController.GetInventoryByID) // Matches /inventory/:id
 		inventoryGroup.PUT("/:id", inventoryController.UpdateInventory)
 		inventoryGroup.DELETE("/:id", inventoryController.DeleteInventory)
+
+		// Atomic stock changes
+		inventoryGroup.POST("/:id/adjust", inventoryController.AdjustInventory)
+
+		// Movement ledger
+		inventoryGroup.GET("/:id/movements", inventoryController.GetMovements)
 	}
 
 	// Add explicit 301 redirects for paths that might come in WITH trailing slashes.
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"Inventory-Services/client"
+	"Inventory-Services/config"
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	config.Cfg = &config.Config{
+		StorageBackend:               config.StorageBackendMemory,
+		ReservationDefaultTTLSeconds: 900,
+		ReceivingLocation:            "RECEIVING",
+		PutawayStrategies:            []string{model.PutawayHome, model.PutawayConsolidate, model.PutawayNearestEmpty},
+	}
+	os.Exit(m.Run())
+}
+
+// testStore is an InventoryService on memory storage together with the repositories behind it,
+// one warehouse and one commodity taking 2 units of storage each.
+type testStore struct {
+	service     InventoryService
+	inventories *repository.InMemoryInventoryRepository
+	movements   *repository.InMemoryMovementRepository
+	ledger      *failingMovements
+	locations   *repository.InMemoryLocationRepository
+	warehouses  *client.InMemoryWarehouseClient
+	warehouse   client.Warehouse
+	commodity   client.Commodity
+}
+
+// newTestStore creates a testStore whose warehouse holds storage units; 0 means unlimited.
+func newTestStore(storage int) *testStore {
+	s := &testStore{
+		inventories: repository.NewInMemoryInventoryRepository(),
+		movements:   repository.NewInMemoryMovementRepository(),
+		locations:   repository.NewInMemoryLocationRepository(),
+		warehouse:   client.Warehouse{ID: primitive.NewObjectID(), Name: "Main", Storage: storage},
+		commodity:   client.Commodity{ID: primitive.NewObjectID(), Name: "Crate", UnitVolume: 2},
+	}
+	s.warehouses = client.NewInMemoryWarehouseClient(s.warehouse)
+	s.ledger = &failingMovements{InMemoryMovementRepository: s.movements}
+	s.service = NewInventoryServiceWithDependencies(Dependencies{
+		Inventories:  s.inventories,
+		Movements:    s.ledger,
+		Reservations: repository.NewInMemoryReservationRepository(),
+		ASNs:         repository.NewInMemoryASNRepository(),
+		Locations:    s.locations,
+		Transactions: repository.NewInMemoryTransactor(),
+		Commodities:  client.NewInMemoryCommodityClient(s.commodity),
+		Warehouses:   s.warehouses,
+	})
+	return s
+}
+
+// errLedgerDown is the failure of a movement the store's ledger was told to refuse.
+var errLedgerDown = errors.New("ledger unavailable")
+
+// failingMovements is the store's ledger: it records movements in memory, except those with
+// the reason it was told to refuse.
+type failingMovements struct {
+	*repository.InMemoryMovementRepository
+	refuse string
+}
+
+func (m *failingMovements) CreateMovement(ctx context.Context, movement *model.Movement) (*model.Movement, error) {
+	if m.refuse != "" && movement.Reason == m.refuse {
+		return nil, errLedgerDown
+	}
+	return m.InMemoryMovementRepository.CreateMovement(ctx, movement)
+}
+
+// stock creates an inventory record of the store's commodity at location.
+func (s *testStore) stock(t *testing.T, location string, quantity int) *model.Inventory {
+	t.Helper()
+	created, err := s.service.CreateInventory(context.Background(), &model.Inventory{
+		ProductID:   s.commodity.ID,
+		WarehouseID: s.warehouse.ID,
+		Location:    location,
+		Quantity:    quantity,
+	})
+	if err != nil {
+		t.Fatalf("CreateInventory: %v", err)
+	}
+	return created
+}
+
+// quantity returns the units an inventory record holds and how many of them are reserved.
+func (s *testStore) quantity(t *testing.T, id primitive.ObjectID) (quantity, reserved int) {
+	t.Helper()
+	inventory, err := s.service.GetInventoryByID(context.Background(), id.Hex())
+	if err != nil {
+		t.Fatalf("GetInventoryByID: %v", err)
+	}
+	return inventory.Quantity, inventory.Reserved
+}
+
+// reasons returns the ledger reasons recorded for an inventory record, oldest first.
+func (s *testStore) reasons(t *testing.T, id primitive.ObjectID) []string {
+	t.Helper()
+	movements, _, err := s.movements.GetMovementsByInventoryID(context.Background(), id, time.Time{}, time.Time{}, firstPage())
+	if err != nil {
+		t.Fatalf("GetMovementsByInventoryID: %v", err)
+	}
+	reasons := make([]string, len(movements))
+	for i, movement := range movements {
+		reasons[i] = movement.Reason
+	}
+	return reasons
+}
+
+// firstPage returns pagination parameters for the first page of the largest size, in _id order.
+func firstPage() *pagination.Params {
+	return &pagination.Params{Limit: pagination.MaxLimit, SortField: "_id", Filters: bson.M{}}
+}
@@ -1,46 +1,166 @@
 package service
 
 import (
+	"Inventory-Services/client"
 	"Inventory-Services/model"
 	"Inventory-Services/repository" // Added this import
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrUnknownCommodity is returned when an inventory record's product does not exist in the Commodity service.
+var ErrUnknownCommodity = apperrors.InvalidReference("product does not reference an existing commodity")
+
+// ErrUnknownWarehouse is returned when an inventory record's warehouse does not exist in the Warehouse service.
+var ErrUnknownWarehouse = apperrors.InvalidReference("warehouse ID does not reference an existing warehouse")
+
+// ErrCapacityExceeded is returned when a change would push a warehouse's stored volume above its Storage capacity.
+var ErrCapacityExceeded = apperrors.Conflict("warehouse capacity exceeded")
+
+// ErrInvalidInventoryID is returned when an inventory ID is not a valid ObjectID.
+var ErrInvalidInventoryID = apperrors.InvalidID("invalid inventory ID format")
+
+// ErrInvalidWarehouseID is returned when a warehouse ID is not a valid ObjectID.
+var ErrInvalidWarehouseID = apperrors.InvalidID("invalid warehouse ID format")
+
+// ErrZeroDelta is returned when a stock adjustment would not change the quantity.
+var ErrZeroDelta = apperrors.Validation("Delta must be non-zero")
+
+// ErrInvalidReason is returned when a stock adjustment carries no known reason code.
+var ErrInvalidReason = apperrors.Validation("Invalid or missing reason code")
+
+// ErrSameLocation is returned when a transfer would move stock to where it already is.
+var ErrSameLocation = apperrors.Validation("Source and destination must differ")
+
 // InventoryService defines the interface for inventory business logic.
 type InventoryService interface {
 	CreateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error)
-	GetAllInventories(ctx context.Context) ([]model.Inventory, error)
+	GetAllInventories(ctx context.Context, params *pagination.Params) ([]model.Inventory, string, error)
 	GetInventoryByID(ctx context.Context, id string) (*model.Inventory, error)
 	UpdateInventory(ctx context.Context, id string, inventory *model.Inventory) (*model.Inventory, error)
 	DeleteInventory(ctx context.Context, id string) error
+	AdjustInventory(ctx context.Context, id string, adjustment *model.StockAdjustment) (*model.Inventory, error)
+	GetMovements(ctx context.Context, id string, from, to time.Time, params *pagination.Params) ([]model.Movement, string, error)
+	TransferStock(ctx context.Context, transfer *model.StockTransfer) (*model.TransferResult, error)
+	GetWarehouseUtilization(ctx context.Context, warehouseID string) (*model.WarehouseUtilization, error)
+	GetStockByWarehouse(ctx context.Context) (map[primitive.ObjectID]int, error)
+	ReserveStock(ctx context.Context, request *model.ReservationRequest) (*model.Reservation, error)
+	GetAllReservations(ctx context.Context, params *pagination.Params) ([]model.Reservation, string, error)
+	GetReservationByID(ctx context.Context, id string) (*model.Reservation, error)
+	CommitReservation(ctx context.Context, id string) (*model.Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) (*model.Reservation, error)
+	ExpireReservations(ctx context.Context) (int, error)
+	CreateASN(ctx context.Context, asn *model.ASN) (*model.ASN, error)
+	GetAllASNs(ctx context.Context, params *pagination.Params) ([]model.ASN, string, error)
+	GetASNByID(ctx context.Context, id string) (*model.ASN, error)
+	RecordASNReceipt(ctx context.Context, id string, receipt *model.ASNReceipt) (*model.ASN, error)
+	CloseASN(ctx context.Context, id string) (*model.ASNCloseResult, error)
+	CancelASN(ctx context.Context, id string) (*model.ASN, error)
+	CreateLocation(ctx context.Context, location *model.StorageLocation) (*model.StorageLocation, error)
+	GetAllLocations(ctx context.Context, params *pagination.Params) ([]model.StorageLocation, string, error)
+	GetLocationByID(ctx context.Context, id string) (*model.StorageLocation, error)
+	UpdateLocation(ctx context.Context, id string, location *model.StorageLocation) (*model.StorageLocation, error)
+	DeleteLocation(ctx context.Context, id string) error
+	SuggestPutaway(ctx context.Context, request *model.PutawayRequest) (*model.PutawayPlan, error)
+	ConfirmPutaway(ctx context.Context, confirmation *model.PutawayConfirmation) (*model.TransferResult, error)
+}
+
+// Dependencies groups the collaborators an InventoryService is built from.
+type Dependencies struct {
+	Inventories  repository.InventoryRepository
+	Movements    repository.MovementRepository
+	Reservations repository.ReservationRepository
+	ASNs         repository.ASNRepository
+	Locations    repository.LocationRepository
+	Transactions repository.Transactor
+	Commodities  client.CommodityClient
+	Warehouses   client.WarehouseClient
 }
 
 // inventoryServiceImpl implements InventoryService.
 type inventoryServiceImpl struct {
-	repository repository.InventoryRepository // Changed to use repository
+	repository   repository.InventoryRepository // Changed to use repository
+	movements    repository.MovementRepository
+	reservations repository.ReservationRepository
+	asns         repository.ASNRepository
+	locations    repository.LocationRepository
+	transactions repository.Transactor
+	commodities  client.CommodityClient
+	warehouses   client.WarehouseClient
 }
 
 // NewInventoryService creates a new instance of InventoryService.
 func NewInventoryService() InventoryService {
 	// We now create the repository and pass it to the service
-	return &inventoryServiceImpl{repository: repository.NewInventoryRepository()}
+	return NewInventoryServiceWithDependencies(Dependencies{
+		Inventories:  repository.NewInventoryRepository(),
+		Movements:    repository.NewMovementRepository(),
+		Reservations: repository.NewReservationRepository(),
+		ASNs:         repository.NewASNRepository(),
+		Locations:    repository.NewLocationRepository(),
+		Transactions: repository.NewTransactor(),
+		Commodities:  client.NewCommodityClient(),
+		Warehouses:   client.NewWarehouseClient(),
+	})
+}
+
+// NewInventoryServiceWithDependencies creates an InventoryService from explicit collaborators,
+// so tests can substitute in-memory repositories and clients.
+func NewInventoryServiceWithDependencies(deps Dependencies) InventoryService {
+	return &inventoryServiceImpl{
+		repository:   deps.Inventories,
+		movements:    deps.Movements,
+		reservations: deps.Reservations,
+		asns:         deps.ASNs,
+		locations:    deps.Locations,
+		transactions: deps.Transactions,
+		commodities:  deps.Commodities,
+		warehouses:   deps.Warehouses,
+	}
 }
 
 func (s *inventoryServiceImpl) CreateInventory(ctx context.Context, inventory *model.Inventory) (*model.Inventory, error) {
-	return s.repository.CreateInventory(ctx, inventory)
+	inventory.Reserved = 0 // Stock is only reserved through ReserveStock
+	commodity, warehouse, err := s.verifyReferences(ctx, inventory)
+	if err != nil {
+		return nil, err
+	}
+	inventory.UnitVolume = commodity.Volume(1)
+	var created *model.Inventory
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.ensureCapacity(ctx, warehouse, commodity.Volume(inventory.Quantity)); err != nil {
+			return err
+		}
+		created, err = s.repository.CreateInventory(ctx, inventory)
+		if err != nil {
+			return err
+		}
+		if err := s.ensureLocationCapacity(ctx, created); err != nil {
+			return err
+		}
+		return s.recordMovement(ctx, created, 0, model.ReasonCreate)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-func (s *inventoryServiceImpl) GetAllInventories(ctx context.Context) ([]model.Inventory, error) {
-	return s.repository.GetAllInventories(ctx)
+func (s *inventoryServiceImpl) GetAllInventories(ctx context.Context, params *pagination.Params) ([]model.Inventory, string, error) {
+	return s.repository.GetAllInventories(ctx, params)
 }
 
 func (s *inventoryServiceImpl) GetInventoryByID(ctx context.Context, id string) (*model.Inventory, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid inventory ID format")
+		return nil, ErrInvalidInventoryID
 	}
 	return s.repository.GetInventoryByID(ctx, objID)
 }
@@ -48,15 +168,359 @@ func (s *inventoryServiceImpl) GetInventoryByID(ctx context.Context, id string)
 func (s *inventoryServiceImpl) UpdateInventory(ctx context.Context, id string, inventory *model.Inventory) (*model.Inventory, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid inventory ID format")
+		return nil, ErrInvalidInventoryID
+	}
+	commodity, warehouse, err := s.verifyReferences(ctx, inventory)
+	if err != nil {
+		return nil, err
+	}
+	inventory.UnitVolume = commodity.Volume(1)
+	var updated *model.Inventory
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repository.GetInventoryByID(ctx, objID)
+		if err != nil {
+			return err
+		}
+		if err := ensureReservationsKept(existing, inventory); err != nil {
+			return err
+		}
+		additionalVolume := commodity.Volume(inventory.Quantity)
+		if existing.WarehouseID == warehouse.ID {
+			// The record's current stock is already counted against this warehouse.
+			existingVolume, err := s.recordVolume(ctx, existing, existing.Quantity)
+			if err != nil {
+				return err
+			}
+			additionalVolume -= existingVolume
+		}
+		if err := s.ensureCapacity(ctx, warehouse, additionalVolume); err != nil {
+			return err
+		}
+		updated, err = s.repository.UpdateInventory(ctx, objID, inventory)
+		if err != nil {
+			return err
+		}
+		if updated.Quantity > existing.Quantity || updated.WarehouseID != existing.WarehouseID || updated.Location != existing.Location {
+			if err := s.ensureLocationCapacity(ctx, updated); err != nil {
+				return err
+			}
+		}
+		return s.recordMovement(ctx, updated, existing.Quantity, model.ReasonUpdate)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.repository.UpdateInventory(ctx, objID, inventory)
+	return updated, nil
 }
 
 func (s *inventoryServiceImpl) DeleteInventory(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return errors.New("invalid inventory ID format")
+		return ErrInvalidInventoryID
+	}
+	existing, err := s.repository.GetInventoryByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if existing.Reserved > 0 {
+		return fmt.Errorf("%w: %d units are reserved; commit or release the reservations first", repository.ErrStockReserved, existing.Reserved)
+	}
+	return s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repository.DeleteInventory(ctx, objID); err != nil {
+			return err
+		}
+		removed := *existing
+		removed.Quantity = 0
+		return s.recordMovement(ctx, &removed, existing.Quantity, model.ReasonDelete)
+	})
+}
+
+func (s *inventoryServiceImpl) AdjustInventory(ctx context.Context, id string, adjustment *model.StockAdjustment) (*model.Inventory, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidInventoryID
+	}
+	if adjustment.Delta == 0 {
+		return nil, ErrZeroDelta
+	}
+	if !model.IsValidAdjustmentReason(adjustment.Reason) {
+		return nil, ErrInvalidReason
+	}
+	// Only additions need room; the warehouse and volume are looked up before the transaction
+	// so that it holds no HTTP calls.
+	var warehouse *client.Warehouse
+	additionalVolume := 0
+	if adjustment.Delta > 0 {
+		existing, err := s.repository.GetInventoryByID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.WarehouseID.IsZero() { // Records that predate warehouse links have none to check
+			if warehouse, err = s.verifyWarehouse(ctx, existing.WarehouseID); err != nil {
+				return nil, err
+			}
+			if additionalVolume, err = s.recordVolume(ctx, existing, adjustment.Delta); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var adjusted *model.Inventory
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if warehouse != nil {
+			if err := s.ensureCapacity(ctx, warehouse, additionalVolume); err != nil {
+				return err
+			}
+		}
+		adjusted, err = s.repository.AdjustQuantity(ctx, objID, adjustment.Delta)
+		if err != nil {
+			return err
+		}
+		if adjustment.Delta > 0 {
+			if err := s.ensureLocationCapacity(ctx, adjusted); err != nil {
+				return err
+			}
+		}
+		return s.recordMovement(ctx, adjusted, adjusted.Quantity-adjustment.Delta, adjustment.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjusted, nil
+}
+
+func (s *inventoryServiceImpl) GetMovements(ctx context.Context, id string, from, to time.Time, params *pagination.Params) ([]model.Movement, string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, "", ErrInvalidInventoryID
+	}
+	return s.movements.GetMovementsByInventoryID(ctx, objID, from, to, params)
+}
+
+func (s *inventoryServiceImpl) TransferStock(ctx context.Context, transfer *model.StockTransfer) (*model.TransferResult, error) {
+	sameWarehouse := transfer.ToWarehouseID.IsZero() || transfer.ToWarehouseID == transfer.FromWarehouseID
+	if sameWarehouse && transfer.FromLocation == transfer.ToLocation {
+		return nil, ErrSameLocation
+	}
+	// Stock moving into another warehouse needs room there; moves within one change nothing for
+	// the warehouse, but the destination location must still have room.
+	var warehouse *client.Warehouse
+	additionalVolume := 0
+	if !transfer.ToWarehouseID.IsZero() && transfer.ToWarehouseID != transfer.FromWarehouseID {
+		var err error
+		if warehouse, err = s.verifyWarehouse(ctx, transfer.ToWarehouseID); err != nil {
+			return nil, err
+		}
+		if additionalVolume, err = s.volumeOf(ctx, transfer.ProductID, transfer.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	result := &model.TransferResult{}
+	err := s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if warehouse != nil {
+			if err := s.ensureCapacity(ctx, warehouse, additionalVolume); err != nil {
+				return err
+			}
+		}
+		source, destination, err := s.repository.TransferStock(ctx, transfer)
+		if err != nil {
+			return err
+		}
+		if err := s.ensureLocationCapacity(ctx, destination); err != nil {
+			return err
+		}
+		result.Source, result.Destination = source, destination
+		if err := s.recordMovement(ctx, source, source.Quantity+transfer.Quantity, model.ReasonTransferOut); err != nil {
+			return err
+		}
+		return s.recordMovement(ctx, destination, destination.Quantity-transfer.Quantity, model.ReasonTransferIn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *inventoryServiceImpl) GetWarehouseUtilization(ctx context.Context, warehouseID string) (*model.WarehouseUtilization, error) {
+	objID, err := primitive.ObjectIDFromHex(warehouseID)
+	if err != nil {
+		return nil, ErrInvalidWarehouseID
+	}
+	// The warehouse is the resource being asked about here, so a missing one is not found
+	// rather than an invalid reference.
+	warehouse, err := s.warehouses.GetWarehouse(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.usedVolume(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	utilization := &model.WarehouseUtilization{WarehouseID: objID, Capacity: warehouse.Storage, Used: used}
+	if warehouse.Storage > 0 {
+		utilization.Free = max(warehouse.Storage-used, 0)
+		utilization.FillPercent = math.Round(float64(used)*10000/float64(warehouse.Storage)) / 100
+	}
+	return utilization, nil
+}
+
+// ensureReservationsKept rejects an update that would leave a record holding less than its
+// reserved quantity, or move reserved stock to another product, warehouse or location.
+func ensureReservationsKept(existing, updated *model.Inventory) error {
+	if existing.Reserved == 0 {
+		return nil
+	}
+	if updated.Quantity < existing.Reserved {
+		return fmt.Errorf("%w: quantity cannot drop below the %d reserved units", repository.ErrStockReserved, existing.Reserved)
+	}
+	if updated.ProductID != existing.ProductID || updated.WarehouseID != existing.WarehouseID || updated.Location != existing.Location {
+		return fmt.Errorf("%w: product, warehouse and location cannot change while units are reserved", repository.ErrStockReserved)
+	}
+	return nil
+}
+
+// verifyReferences checks that an inventory record points at an existing commodity and warehouse.
+func (s *inventoryServiceImpl) verifyReferences(ctx context.Context, inventory *model.Inventory) (*client.Commodity, *client.Warehouse, error) {
+	commodity, err := s.verifyProduct(ctx, inventory.ProductID)
+	if err != nil {
+		return nil, nil, err
+	}
+	warehouse, err := s.verifyWarehouse(ctx, inventory.WarehouseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commodity, warehouse, nil
+}
+
+// verifyProduct checks that productID refers to a commodity known to the Commodity service.
+func (s *inventoryServiceImpl) verifyProduct(ctx context.Context, productID primitive.ObjectID) (*client.Commodity, error) {
+	if productID.IsZero() {
+		return nil, fmt.Errorf("%w: product ID is required", ErrUnknownCommodity)
+	}
+	commodity, err := s.commodities.GetCommodity(ctx, productID)
+	if err != nil {
+		if errors.Is(err, client.ErrCommodityNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCommodity, productID.Hex())
+		}
+		return nil, fmt.Errorf("failed to verify commodity %s: %w", productID.Hex(), err)
+	}
+	return commodity, nil
+}
+
+// verifyWarehouse checks that warehouseID refers to a warehouse known to the Warehouse service.
+func (s *inventoryServiceImpl) verifyWarehouse(ctx context.Context, warehouseID primitive.ObjectID) (*client.Warehouse, error) {
+	if warehouseID.IsZero() {
+		return nil, fmt.Errorf("%w: warehouse ID is required", ErrUnknownWarehouse)
+	}
+	warehouse, err := s.warehouses.GetWarehouse(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, client.ErrWarehouseNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWarehouse, warehouseID.Hex())
+		}
+		return nil, fmt.Errorf("failed to verify warehouse %s: %w", warehouseID.Hex(), err)
+	}
+	return warehouse, nil
+}
+
+// ensureCapacity rejects a change that would add volume to a warehouse beyond its Storage capacity.
+// Warehouses without a positive Storage are unlimited. It must run inside the transaction that
+// makes the change: claiming the warehouse first means concurrent changes to it are checked
+// one after another rather than each against the same old total.
+func (s *inventoryServiceImpl) ensureCapacity(ctx context.Context, warehouse *client.Warehouse, additionalVolume int) error {
+	if warehouse.Storage <= 0 || additionalVolume <= 0 {
+		return nil
+	}
+	if err := s.repository.ClaimWarehouse(ctx, warehouse.ID); err != nil {
+		return err
+	}
+	used, err := s.usedVolume(ctx, warehouse.ID)
+	if err != nil {
+		return err
+	}
+	if used+additionalVolume > warehouse.Storage {
+		return fmt.Errorf("%w: warehouse %s would hold %d of %d", ErrCapacityExceeded, warehouse.ID.Hex(), used+additionalVolume, warehouse.Storage)
+	}
+	return nil
+}
+
+// usedVolume totals the storage taken by every product held in a warehouse. Records carry their
+// unit volume; only those written before it was stored need the Commodity service.
+func (s *inventoryServiceImpl) usedVolume(ctx context.Context, warehouseID primitive.ObjectID) (int, error) {
+	volume, err := s.repository.GetStockVolume(ctx, warehouseID)
+	if err != nil {
+		return 0, err
+	}
+	unsized, err := s.volumeOfStock(ctx, volume.Unsized, nil)
+	if err != nil {
+		return 0, err
+	}
+	return volume.Used + unsized, nil
+}
+
+// recordVolume returns the storage taken by quantity units of an inventory record's product.
+func (s *inventoryServiceImpl) recordVolume(ctx context.Context, inventory *model.Inventory, quantity int) (int, error) {
+	if inventory.UnitVolume > 0 {
+		return quantity * inventory.UnitVolume, nil
+	}
+	return s.volumeOf(ctx, inventory.ProductID, quantity)
+}
+
+// volumeOfStock totals the storage taken by the given quantity of each product. A non-nil
+// unitVolumes caches each product's unit volume, so callers totalling several locations look
+// every product up once.
+func (s *inventoryServiceImpl) volumeOfStock(ctx context.Context, quantities map[primitive.ObjectID]int, unitVolumes map[primitive.ObjectID]int) (int, error) {
+	used := 0
+	for productID, quantity := range quantities {
+		unitVolume, cached := unitVolumes[productID]
+		if !cached {
+			var err error
+			if unitVolume, err = s.volumeOf(ctx, productID, 1); err != nil {
+				return 0, err
+			}
+			if unitVolumes != nil {
+				unitVolumes[productID] = unitVolume
+			}
+		}
+		used += quantity * unitVolume
+	}
+	return used, nil
+}
+
+// volumeOf returns the storage taken by quantity units of a product.
+// Stock whose commodity no longer exists still occupies space, counted at one unit per item.
+func (s *inventoryServiceImpl) volumeOf(ctx context.Context, productID primitive.ObjectID, quantity int) (int, error) {
+	commodity, err := s.commodities.GetCommodity(ctx, productID)
+	if err != nil {
+		if errors.Is(err, client.ErrCommodityNotFound) {
+			return quantity, nil
+		}
+		return 0, fmt.Errorf("failed to look up unit volume for commodity %s: %w", productID.Hex(), err)
+	}
+	return commodity.Volume(quantity), nil
+}
+
+// GetStockByWarehouse totals the units on hand in each warehouse.
+func (s *inventoryServiceImpl) GetStockByWarehouse(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	return s.repository.GetQuantitiesByWarehouse(ctx)
+}
+
+// recordMovement appends a ledger entry for a change applied to inventory. Callers make the change
+// and its entries in one transaction, so a failed ledger write undoes the change as well.
+func (s *inventoryServiceImpl) recordMovement(ctx context.Context, after *model.Inventory, quantityBefore int, reason string) error {
+	movement := &model.Movement{
+		InventoryID:    after.ID,
+		ProductID:      after.ProductID,
+		Location:       after.Location,
+		QuantityBefore: quantityBefore,
+		QuantityAfter:  after.Quantity,
+		Delta:          after.Quantity - quantityBefore,
+		Reason:         reason,
+		Actor:          ActorFromContext(ctx),
+		Timestamp:      time.Now(),
+	}
+	if _, err := s.movements.CreateMovement(ctx, movement); err != nil {
+		return fmt.Errorf("failed to record %s movement for inventory %s: %w", reason, after.ID.Hex(), err)
 	}
-	return s.repository.DeleteInventory(ctx, objID)
+	repository.AfterCommit(ctx, func() { stockMovements.WithLabelValues(reason).Inc() })
+	return nil
 }
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"Inventory-Services/client"
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+	"wms-common/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWarehouseCapacity(t *testing.T) {
+	// Each case starts from a warehouse of 20 holding 6 units of volume 2 at A-01, and a second
+	// warehouse of 10 holding nothing.
+	tests := []struct {
+		name    string
+		change  func(s *testStore, existing *model.Inventory, other client.Warehouse) error
+		wantErr error
+	}{
+		{
+			name: "create up to the capacity",
+			change: func(s *testStore, _ *model.Inventory, _ client.Warehouse) error {
+				_, err := s.service.CreateInventory(context.Background(), &model.Inventory{ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "B-01", Quantity: 4})
+				return err
+			},
+		},
+		{
+			name: "create beyond the capacity",
+			change: func(s *testStore, _ *model.Inventory, _ client.Warehouse) error {
+				_, err := s.service.CreateInventory(context.Background(), &model.Inventory{ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "B-01", Quantity: 5})
+				return err
+			},
+			wantErr: ErrCapacityExceeded,
+		},
+		{
+			name: "adjust up to the capacity",
+			change: func(s *testStore, existing *model.Inventory, _ client.Warehouse) error {
+				_, err := s.service.AdjustInventory(context.Background(), existing.ID.Hex(), &model.StockAdjustment{Delta: 4, Reason: model.ReasonReceipt})
+				return err
+			},
+		},
+		{
+			name: "adjust beyond the capacity",
+			change: func(s *testStore, existing *model.Inventory, _ client.Warehouse) error {
+				_, err := s.service.AdjustInventory(context.Background(), existing.ID.Hex(), &model.StockAdjustment{Delta: 5, Reason: model.ReasonReceipt})
+				return err
+			},
+			wantErr: ErrCapacityExceeded,
+		},
+		{
+			name: "update counts the record's own stock once",
+			change: func(s *testStore, existing *model.Inventory, _ client.Warehouse) error {
+				updated := *existing
+				updated.Quantity = 10
+				_, err := s.service.UpdateInventory(context.Background(), existing.ID.Hex(), &updated)
+				return err
+			},
+		},
+		{
+			name: "update beyond the capacity",
+			change: func(s *testStore, existing *model.Inventory, _ client.Warehouse) error {
+				updated := *existing
+				updated.Quantity = 11
+				_, err := s.service.UpdateInventory(context.Background(), existing.ID.Hex(), &updated)
+				return err
+			},
+			wantErr: ErrCapacityExceeded,
+		},
+		{
+			name: "transfer within the warehouse",
+			change: func(s *testStore, _ *model.Inventory, _ client.Warehouse) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "A-01", ToLocation: "B-01", Quantity: 6,
+				})
+				return err
+			},
+		},
+		{
+			name: "transfer up to another warehouse's capacity",
+			change: func(s *testStore, _ *model.Inventory, other client.Warehouse) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "A-01", ToWarehouseID: other.ID, ToLocation: "A-01", Quantity: 5,
+				})
+				return err
+			},
+		},
+		{
+			name: "transfer beyond another warehouse's capacity",
+			change: func(s *testStore, _ *model.Inventory, other client.Warehouse) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "A-01", ToWarehouseID: other.ID, ToLocation: "A-01", Quantity: 6,
+				})
+				return err
+			},
+			wantErr: ErrCapacityExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(20)
+			other := client.Warehouse{ID: primitive.NewObjectID(), Name: "Overflow", Storage: 10}
+			s.warehouses.Add(other)
+			existing := s.stock(t, "A-01", 6)
+
+			err := tt.change(s, existing, other)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			// A refused change leaves both warehouses as they were.
+			for warehouseID, want := range map[primitive.ObjectID]int{s.warehouse.ID: 12, other.ID: 0} {
+				utilization, err := s.service.GetWarehouseUtilization(ctx, warehouseID.Hex())
+				if err != nil {
+					t.Fatalf("GetWarehouseUtilization: %v", err)
+				}
+				if utilization.Used != want {
+					t.Errorf("warehouse %s uses %d, want %d", warehouseID.Hex(), utilization.Used, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnlimitedWarehouse(t *testing.T) {
+	s := newTestStore(0)
+	s.stock(t, "A-01", 1000)
+	if _, err := s.service.CreateInventory(context.Background(), &model.Inventory{
+		ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "B-01", Quantity: 1000,
+	}); err != nil {
+		t.Errorf("CreateInventory in a warehouse without a storage limit: %v", err)
+	}
+}
+
+func TestGetWarehouseUtilization(t *testing.T) {
+	tests := []struct {
+		name        string
+		storage     int
+		warehouseID func(s *testStore) string
+		want        model.WarehouseUtilization
+		wantErr     error
+	}{
+		{name: "limited warehouse", storage: 20, want: model.WarehouseUtilization{Capacity: 20, Used: 12, Free: 8, FillPercent: 60}},
+		{name: "overfilled warehouse", storage: 8, want: model.WarehouseUtilization{Capacity: 8, Used: 12, Free: 0, FillPercent: 150}},
+		{name: "unlimited warehouse", want: model.WarehouseUtilization{Used: 12}},
+		{
+			name:        "unknown warehouse",
+			warehouseID: func(*testStore) string { return primitive.NewObjectID().Hex() },
+			wantErr:     client.ErrWarehouseNotFound,
+		},
+		{
+			name:        "malformed warehouse ID",
+			warehouseID: func(*testStore) string { return "not-an-id" },
+			wantErr:     ErrInvalidWarehouseID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			s.stock(t, "A-01", 6)
+			// Shrinking the warehouse afterwards is how it ends up holding more than it has room for.
+			s.warehouse.Storage = tt.storage
+			s.warehouses.Add(s.warehouse)
+			warehouseID := s.warehouse.ID.Hex()
+			if tt.warehouseID != nil {
+				warehouseID = tt.warehouseID(s)
+			}
+
+			utilization, err := s.service.GetWarehouseUtilization(context.Background(), warehouseID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetWarehouseUtilization error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			tt.want.WarehouseID = s.warehouse.ID
+			if *utilization != tt.want {
+				t.Errorf("utilization = %+v, want %+v", *utilization, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustInventory(t *testing.T) {
+	tests := []struct {
+		name         string
+		adjustment   model.StockAdjustment
+		wantErr      error
+		wantStatus   int
+		wantQuantity int
+	}{
+		{name: "receipt", adjustment: model.StockAdjustment{Delta: 4, Reason: model.ReasonReceipt}, wantQuantity: 10},
+		{name: "pick", adjustment: model.StockAdjustment{Delta: -6, Reason: model.ReasonPick}, wantQuantity: 0},
+		{name: "zero delta", adjustment: model.StockAdjustment{Reason: model.ReasonCorrection}, wantErr: ErrZeroDelta, wantStatus: http.StatusBadRequest},
+		{name: "missing reason", adjustment: model.StockAdjustment{Delta: 1}, wantErr: ErrInvalidReason, wantStatus: http.StatusBadRequest},
+		{name: "unknown reason", adjustment: model.StockAdjustment{Delta: 1, Reason: "found"}, wantErr: ErrInvalidReason, wantStatus: http.StatusBadRequest},
+		{name: "short stock", adjustment: model.StockAdjustment{Delta: -7, Reason: model.ReasonDamage}, wantErr: repository.ErrInsufficientStock, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			existing := s.stock(t, "A-01", 6)
+
+			_, err := s.service.AdjustInventory(context.Background(), existing.ID.Hex(), &tt.adjustment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AdjustInventory error = %v, want %v", err, tt.wantErr)
+			}
+			if status, _ := apperrors.Status(err); tt.wantErr != nil && status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			wantQuantity, wantReasons := tt.wantQuantity, []string{model.ReasonCreate, tt.adjustment.Reason}
+			if tt.wantErr != nil {
+				wantQuantity, wantReasons = 6, []string{model.ReasonCreate}
+			}
+			if quantity, _ := s.quantity(t, existing.ID); quantity != wantQuantity {
+				t.Errorf("quantity = %d, want %d", quantity, wantQuantity)
+			}
+			if reasons := s.reasons(t, existing.ID); !slices.Equal(reasons, wantReasons) {
+				t.Errorf("ledger = %v, want %v", reasons, wantReasons)
+			}
+		})
+	}
+}
+
+func TestLedgerEntries(t *testing.T) {
+	s := newTestStore(0)
+	existing := s.stock(t, "A-01", 6)
+	ctx := WithActor(context.Background(), "ada")
+
+	if _, err := s.service.AdjustInventory(ctx, existing.ID.Hex(), &model.StockAdjustment{Delta: -2, Reason: model.ReasonDamage}); err != nil {
+		t.Fatalf("AdjustInventory: %v", err)
+	}
+	if _, err := s.service.TransferStock(ctx, &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "A-01", ToLocation: "B-01", Quantity: 3}); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+
+	movements, _, err := s.service.GetMovements(ctx, existing.ID.Hex(), time.Time{}, time.Time{}, firstPage())
+	if err != nil {
+		t.Fatalf("GetMovements: %v", err)
+	}
+	type entry struct {
+		reason               string
+		before, after, delta int
+		actor, location      string
+	}
+	want := []entry{
+		{reason: model.ReasonCreate, before: 0, after: 6, delta: 6, actor: "anonymous", location: "A-01"},
+		{reason: model.ReasonDamage, before: 6, after: 4, delta: -2, actor: "ada", location: "A-01"},
+		{reason: model.ReasonTransferOut, before: 4, after: 1, delta: -3, actor: "ada", location: "A-01"},
+	}
+	var got []entry
+	for _, movement := range movements {
+		if movement.InventoryID != existing.ID || movement.ProductID != s.commodity.ID {
+			t.Errorf("movement %+v is not for inventory %s of product %s", movement, existing.ID.Hex(), s.commodity.ID.Hex())
+		}
+		got = append(got, entry{movement.Reason, movement.QuantityBefore, movement.QuantityAfter, movement.Delta, movement.Actor, movement.Location})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ledger = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetMovementsInRange(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	existing := s.stock(t, "A-01", 6)
+	other := s.stock(t, "B-01", 1)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for hour := 0; hour < 4; hour++ {
+		for _, inventoryID := range []primitive.ObjectID{existing.ID, other.ID} {
+			movement := &model.Movement{InventoryID: inventoryID, Reason: model.ReasonCycleCount, Delta: hour, Timestamp: day.Add(time.Duration(hour) * time.Hour)}
+			if _, err := s.movements.CreateMovement(ctx, movement); err != nil {
+				t.Fatalf("CreateMovement: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		from, to   time.Time
+		wantDeltas []int
+	}{
+		{name: "from and to, both inclusive", from: day.Add(time.Hour), to: day.Add(2 * time.Hour), wantDeltas: []int{1, 2}},
+		{name: "only from", from: day.Add(3 * time.Hour), wantDeltas: []int{6, 3}}, // The record's creation happened now
+		{name: "only to", to: day.Add(time.Hour), wantDeltas: []int{0, 1}},
+		{name: "empty range", from: day.Add(90 * time.Minute), to: day.Add(110 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movements, _, err := s.service.GetMovements(ctx, existing.ID.Hex(), tt.from, tt.to, firstPage())
+			if err != nil {
+				t.Fatalf("GetMovements: %v", err)
+			}
+			var deltas []int
+			for _, movement := range movements {
+				if movement.InventoryID != existing.ID {
+					t.Errorf("movement of inventory %s listed for %s", movement.InventoryID.Hex(), existing.ID.Hex())
+				}
+				deltas = append(deltas, movement.Delta)
+			}
+			if !slices.Equal(deltas, tt.wantDeltas) {
+				t.Errorf("deltas = %v, want %v", deltas, tt.wantDeltas)
+			}
+		})
+	}
+
+	if _, _, err := s.service.GetMovements(ctx, "not-an-id", time.Time{}, time.Time{}, firstPage()); !errors.Is(err, ErrInvalidInventoryID) {
+		t.Errorf("malformed ID error = %v, want %v", err, ErrInvalidInventoryID)
+	}
+}
+
+func TestTransferStock(t *testing.T) {
+	tests := []struct {
+		name       string
+		transfer   func(s *testStore) *model.StockTransfer
+		refuse     string // Ledger reason the store fails to record
+		wantErr    error
+		wantStatus int
+	}{
+		{
+			name: "moves the units",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "A-01", ToLocation: "B-01", Quantity: 4}
+			},
+		},
+		{
+			name: "missing source",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "C-01", ToLocation: "B-01", Quantity: 1}
+			},
+			wantErr:    repository.ErrTransferSourceNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "another product at the source",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: primitive.NewObjectID(), FromLocation: "A-01", ToLocation: "B-01", Quantity: 1}
+			},
+			wantErr:    repository.ErrTransferSourceNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "insufficient stock",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "A-01", ToLocation: "B-01", Quantity: 7}
+			},
+			wantErr:    repository.ErrInsufficientStock,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "source is the destination",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "A-01", ToLocation: "A-01", Quantity: 1}
+			},
+			wantErr:    ErrSameLocation,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "source is the destination in a named warehouse",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "A-01", ToWarehouseID: s.warehouse.ID, ToLocation: "A-01", Quantity: 1}
+			},
+			wantErr:    ErrSameLocation,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "destination not recorded",
+			transfer: func(s *testStore) *model.StockTransfer {
+				return &model.StockTransfer{ProductID: s.commodity.ID, FromLocation: "A-01", ToLocation: "B-01", Quantity: 4}
+			},
+			refuse:     model.ReasonTransferIn,
+			wantErr:    errLedgerDown,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			source := s.stock(t, "A-01", 6)
+			s.ledger.refuse = tt.refuse
+
+			result, err := s.service.TransferStock(ctx, tt.transfer(s))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransferStock error = %v, want %v", err, tt.wantErr)
+			}
+			if status, _ := apperrors.Status(err); tt.wantErr != nil && status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			all, _, err := s.service.GetAllInventories(ctx, firstPage())
+			if err != nil {
+				t.Fatalf("GetAllInventories: %v", err)
+			}
+			if tt.wantErr == nil {
+				if result.Source.Quantity != 2 || result.Destination.Quantity != 4 || result.Destination.Location != "B-01" {
+					t.Errorf("result = source %+v, destination %+v; want 2 left at A-01 and 4 at B-01", result.Source, result.Destination)
+				}
+				if reasons := s.reasons(t, result.Destination.ID); !slices.Equal(reasons, []string{model.ReasonTransferIn}) {
+					t.Errorf("destination ledger = %v, want [%s]", reasons, model.ReasonTransferIn)
+				}
+				return
+			}
+			// A refused or failed transfer leaves the source, the ledger and the locations as they were.
+			if quantity, _ := s.quantity(t, source.ID); quantity != 6 {
+				t.Errorf("source quantity = %d, want 6", quantity)
+			}
+			if reasons := s.reasons(t, source.ID); !slices.Equal(reasons, []string{model.ReasonCreate}) {
+				t.Errorf("source ledger = %v, want [%s]", reasons, model.ReasonCreate)
+			}
+			if len(all) != 1 {
+				t.Errorf("%d inventory records, want only the source", len(all))
+			}
+		})
+	}
+}
+
+func TestUnknownCommodityIsInvalidReference(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	existing := s.stock(t, "A-01", 6)
+	unknown := primitive.NewObjectID()
+
+	_, createErr := s.service.CreateInventory(ctx, &model.Inventory{ProductID: unknown, WarehouseID: s.warehouse.ID, Location: "B-01", Quantity: 1})
+	updated := *existing
+	updated.ProductID = unknown
+	_, updateErr := s.service.UpdateInventory(ctx, existing.ID.Hex(), &updated)
+
+	for operation, err := range map[string]error{"create": createErr, "update": updateErr} {
+		if !errors.Is(err, ErrUnknownCommodity) {
+			t.Errorf("%s error = %v, want %v", operation, err, ErrUnknownCommodity)
+		}
+		if status, _ := apperrors.Status(err); status != http.StatusUnprocessableEntity {
+			t.Errorf("%s status = %d, want %d", operation, status, http.StatusUnprocessableEntity)
+		}
+	}
+	if inventory, err := s.service.GetInventoryByID(ctx, existing.ID.Hex()); err != nil || inventory.ProductID != s.commodity.ID {
+		t.Errorf("record after the refused update = %+v, %v; want it to keep its product", inventory, err)
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"errors"
+	"fmt"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidLocationID is returned when a storage location ID is not a valid ObjectID.
+var ErrInvalidLocationID = apperrors.InvalidID("invalid storage location ID format")
+
+// ErrDuplicateLocation is returned when a warehouse already has a storage location with the same code.
+var ErrDuplicateLocation = apperrors.Conflict("warehouse already has a storage location with this code")
+
+// ErrUnknownLocation is returned when a putaway targets a location that is not a storage location of the warehouse.
+var ErrUnknownLocation = apperrors.InvalidReference("location is not a storage location of the warehouse")
+
+// ErrLocationCapacityExceeded is returned when a change would push a location's stored volume above its Capacity.
+var ErrLocationCapacityExceeded = apperrors.Conflict("storage location capacity exceeded")
+
+func (s *inventoryServiceImpl) CreateLocation(ctx context.Context, location *model.StorageLocation) (*model.StorageLocation, error) {
+	if err := s.verifyLocation(ctx, primitive.NilObjectID, location); err != nil {
+		return nil, err
+	}
+	return s.locations.CreateLocation(ctx, location)
+}
+
+// GetAllLocations returns one page of storage locations and the cursor for the next page.
+func (s *inventoryServiceImpl) GetAllLocations(ctx context.Context, params *pagination.Params) ([]model.StorageLocation, string, error) {
+	return s.locations.GetAllLocations(ctx, params)
+}
+
+func (s *inventoryServiceImpl) GetLocationByID(ctx context.Context, id string) (*model.StorageLocation, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidLocationID
+	}
+	return s.locations.GetLocationByID(ctx, objID)
+}
+
+func (s *inventoryServiceImpl) UpdateLocation(ctx context.Context, id string, location *model.StorageLocation) (*model.StorageLocation, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidLocationID
+	}
+	if err := s.verifyLocation(ctx, objID, location); err != nil {
+		return nil, err
+	}
+	return s.locations.UpdateLocation(ctx, objID, location)
+}
+
+// DeleteLocation removes a storage location. Stock recorded at it is left alone; it is simply no
+// longer suggested for putaway.
+func (s *inventoryServiceImpl) DeleteLocation(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidLocationID
+	}
+	return s.locations.DeleteLocation(ctx, objID)
+}
+
+// SuggestPutaway proposes where to put units of a product, trying each strategy in turn until the
+// whole quantity is placed. A location is only offered as much as its free capacity holds, so a
+// quantity may be split across several locations; what fits nowhere is reported as unplaced.
+func (s *inventoryServiceImpl) SuggestPutaway(ctx context.Context, request *model.PutawayRequest) (*model.PutawayPlan, error) {
+	commodity, err := s.verifyProduct(ctx, request.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.verifyWarehouse(ctx, request.WarehouseID); err != nil {
+		return nil, err
+	}
+	fromLocation := request.FromLocation
+	if fromLocation == "" {
+		fromLocation = config.Cfg.ReceivingLocation
+	}
+	strategies := request.Strategies
+	if len(strategies) == 0 {
+		strategies = config.Cfg.PutawayStrategies
+	}
+
+	locations, err := s.locations.GetLocationsByWarehouse(ctx, request.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.repository.GetStockByLocation(ctx, request.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]int, len(locations))
+	unitVolumes := map[primitive.ObjectID]int{request.ProductID: commodity.Volume(1)}
+	for _, location := range locations {
+		volume, err := s.locationVolume(ctx, stock[location.Code], unitVolumes)
+		if err != nil {
+			return nil, err
+		}
+		used[location.Code] = volume
+	}
+
+	plan := &model.PutawayPlan{
+		ProductID:    request.ProductID,
+		WarehouseID:  request.WarehouseID,
+		FromLocation: fromLocation,
+		Quantity:     request.Quantity,
+		Suggestions:  []model.PutawaySuggestion{},
+	}
+	remaining := request.Quantity
+	unitVolume := commodity.Volume(1)
+	for _, strategy := range strategies {
+		for _, location := range putawayCandidates(strategy, locations, stock, request.ProductID) {
+			if remaining == 0 {
+				break
+			}
+			if location.Code == fromLocation || location.Code == config.Cfg.ReceivingLocation {
+				continue
+			}
+			units := remaining
+			var freeCapacity *int
+			if location.Capacity > 0 {
+				free := max(location.Capacity-used[location.Code], 0)
+				freeCapacity = &free
+				units = min(units, free/unitVolume)
+			}
+			if units <= 0 {
+				continue
+			}
+			plan.Suggestions = append(plan.Suggestions, model.PutawaySuggestion{
+				Location:     location.Code,
+				Quantity:     units,
+				Strategy:     strategy,
+				FreeCapacity: freeCapacity,
+			})
+			used[location.Code] += commodity.Volume(units)
+			remaining -= units
+		}
+	}
+	plan.Unplaced = remaining
+	return plan, nil
+}
+
+// ConfirmPutaway moves put-away units from where they were received to their storage location
+// and records both sides in the ledger with the "putaway" reason. The target must be a storage
+// location of the warehouse with room for the units; the room is checked in the transaction that
+// moves them, so concurrent putaways cannot overfill a location between them.
+func (s *inventoryServiceImpl) ConfirmPutaway(ctx context.Context, confirmation *model.PutawayConfirmation) (*model.TransferResult, error) {
+	fromLocation := confirmation.FromLocation
+	if fromLocation == "" {
+		fromLocation = config.Cfg.ReceivingLocation
+	}
+	if fromLocation == confirmation.ToLocation {
+		return nil, apperrors.Validation("Source and destination must differ")
+	}
+	if _, err := s.verifyProduct(ctx, confirmation.ProductID); err != nil {
+		return nil, err
+	}
+	location, err := s.locations.GetLocationByCode(ctx, confirmation.WarehouseID, confirmation.ToLocation)
+	if err != nil {
+		if errors.Is(err, repository.ErrLocationNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownLocation, confirmation.ToLocation)
+		}
+		return nil, err
+	}
+
+	result := &model.TransferResult{}
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		source, destination, err := s.repository.TransferStock(ctx, &model.StockTransfer{
+			ProductID:       confirmation.ProductID,
+			FromWarehouseID: confirmation.WarehouseID,
+			FromLocation:    fromLocation,
+			ToWarehouseID:   confirmation.WarehouseID,
+			ToLocation:      location.Code,
+			Quantity:        confirmation.Quantity,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.ensureLocationCapacity(ctx, destination); err != nil {
+			return err
+		}
+		result.Source, result.Destination = source, destination
+		if err := s.recordMovement(ctx, source, source.Quantity+confirmation.Quantity, model.ReasonPutaway); err != nil {
+			return err
+		}
+		return s.recordMovement(ctx, destination, destination.Quantity-confirmation.Quantity, model.ReasonPutaway)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ensureLocationCapacity checks that the location an inventory record was just written to still
+// holds no more than its Capacity. Every change that adds stock to a location calls it after the
+// write, in the transaction that makes the change, so that a refusal undoes the write; the
+// warehouse claim serialises it with other transactions adding stock to the same warehouse.
+// Locations that are not storage locations of the warehouse, such as the receiving location, and
+// storage locations without a positive Capacity are unlimited.
+func (s *inventoryServiceImpl) ensureLocationCapacity(ctx context.Context, written *model.Inventory) error {
+	if written.WarehouseID.IsZero() {
+		return nil
+	}
+	location, err := s.locations.GetLocationByCode(ctx, written.WarehouseID, written.Location)
+	if err != nil {
+		if errors.Is(err, repository.ErrLocationNotFound) {
+			return nil
+		}
+		return err
+	}
+	if location.Capacity <= 0 {
+		return nil
+	}
+	if err := s.repository.ClaimWarehouse(ctx, location.WarehouseID); err != nil {
+		return err
+	}
+	stock, err := s.repository.GetStockByLocation(ctx, location.WarehouseID)
+	if err != nil {
+		return err
+	}
+	used, err := s.locationVolume(ctx, stock[location.Code], nil)
+	if err != nil {
+		return err
+	}
+	if used > location.Capacity {
+		return fmt.Errorf("%w: location %s would hold %d of %d", ErrLocationCapacityExceeded,
+			location.Code, used, location.Capacity)
+	}
+	return nil
+}
+
+// locationVolume returns the storage a location's stock takes, looking up the unit volume of
+// records stored without one through unitVolumes.
+func (s *inventoryServiceImpl) locationVolume(ctx context.Context, stock *repository.LocationStock, unitVolumes map[primitive.ObjectID]int) (int, error) {
+	if stock == nil {
+		return 0, nil
+	}
+	unsized, err := s.volumeOfStock(ctx, stock.Volume.Unsized, unitVolumes)
+	if err != nil {
+		return 0, err
+	}
+	return stock.Volume.Used + unsized, nil
+}
+
+// putawayCandidates returns the locations a strategy would put productID into, keeping the
+// nearest-first order of locations. Locations that are another commodity's home are never offered.
+func putawayCandidates(strategy string, locations []model.StorageLocation, stock map[string]*repository.LocationStock, productID primitive.ObjectID) []model.StorageLocation {
+	candidates := []model.StorageLocation{}
+	for _, location := range locations {
+		var quantities map[primitive.ObjectID]int
+		if held := stock[location.Code]; held != nil {
+			quantities = held.Quantities
+		}
+		ownHome := location.HomeProductID != nil && *location.HomeProductID == productID
+		otherHome := location.HomeProductID != nil && !ownHome
+		var match bool
+		switch strategy {
+		case model.PutawayHome:
+			match = ownHome
+		case model.PutawayConsolidate:
+			match = !otherHome && quantities[productID] > 0
+		case model.PutawayNearestEmpty:
+			match = !otherHome && isEmpty(quantities)
+		}
+		if match {
+			candidates = append(candidates, location)
+		}
+	}
+	return candidates
+}
+
+// isEmpty reports whether a location's stock holds no units at all.
+func isEmpty(quantities map[primitive.ObjectID]int) bool {
+	for _, quantity := range quantities {
+		if quantity > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyLocation checks that a storage location points at an existing warehouse and, if it has
+// one, home commodity, and that no other location of the warehouse uses its code.
+func (s *inventoryServiceImpl) verifyLocation(ctx context.Context, id primitive.ObjectID, location *model.StorageLocation) error {
+	if _, err := s.verifyWarehouse(ctx, location.WarehouseID); err != nil {
+		return err
+	}
+	if location.HomeProductID != nil {
+		if _, err := s.verifyProduct(ctx, *location.HomeProductID); err != nil {
+			return err
+		}
+	}
+	existing, err := s.locations.GetLocationByCode(ctx, location.WarehouseID, location.Code)
+	if err != nil {
+		if errors.Is(err, repository.ErrLocationNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != id {
+		return fmt.Errorf("%w: %s", ErrDuplicateLocation, location.Code)
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"wms-common/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// putawayLayout gives the store's warehouse these storage locations, nearest first:
+//
+//	C-01    distance 0, another commodity's home
+//	A-01    distance 1, capacity 20, already holding 4 units (volume 8)
+//	E-01    distance 1, capacity 6, empty
+//	B-01    distance 2, unlimited, empty
+//	HOME-1  distance 5, capacity 10, the commodity's home
+//
+// and puts 10 received units at the receiving location.
+func putawayLayout(t *testing.T, s *testStore) {
+	t.Helper()
+	home, other := s.commodity.ID, primitive.NewObjectID()
+	for _, location := range []model.StorageLocation{
+		{Code: "C-01", Distance: 0, HomeProductID: &other},
+		{Code: "A-01", Distance: 1, Capacity: 20},
+		{Code: "E-01", Distance: 1, Capacity: 6},
+		{Code: "B-01", Distance: 2},
+		{Code: "HOME-1", Distance: 5, Capacity: 10, HomeProductID: &home},
+	} {
+		location.WarehouseID = s.warehouse.ID
+		if _, err := s.locations.CreateLocation(context.Background(), &location); err != nil {
+			t.Fatalf("CreateLocation %s: %v", location.Code, err)
+		}
+	}
+	s.stock(t, "A-01", 4)
+	s.stock(t, "RECEIVING", 10)
+}
+
+func TestSuggestPutaway(t *testing.T) {
+	tests := []struct {
+		name            string
+		quantity        int
+		fromLocation    string
+		strategies      []string
+		wantSuggestions []string // location:quantity:strategy
+		wantUnplaced    int
+	}{
+		{
+			name:            "home location first",
+			quantity:        5,
+			wantSuggestions: []string{"HOME-1:5:home"},
+		},
+		{
+			name:     "split by free capacity",
+			quantity: 20,
+			wantSuggestions: []string{
+				"HOME-1:5:home",
+				"A-01:6:consolidate", // 12 of its 20 free after the 4 units already there
+				"E-01:3:nearest_empty",
+				"B-01:6:nearest_empty",
+			},
+		},
+		{
+			name:            "requested strategies only",
+			quantity:        10,
+			strategies:      []string{model.PutawayConsolidate},
+			wantSuggestions: []string{"A-01:6:consolidate"},
+			wantUnplaced:    4,
+		},
+		{
+			name:            "source location never suggested",
+			quantity:        3,
+			fromLocation:    "A-01",
+			strategies:      []string{model.PutawayConsolidate},
+			wantSuggestions: []string{},
+			wantUnplaced:    3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			putawayLayout(t, s)
+
+			plan, err := s.service.SuggestPutaway(context.Background(), &model.PutawayRequest{
+				ProductID:    s.commodity.ID,
+				WarehouseID:  s.warehouse.ID,
+				FromLocation: tt.fromLocation,
+				Quantity:     tt.quantity,
+				Strategies:   tt.strategies,
+			})
+			if err != nil {
+				t.Fatalf("SuggestPutaway: %v", err)
+			}
+			suggestions := []string{}
+			for _, suggestion := range plan.Suggestions {
+				suggestions = append(suggestions, fmt.Sprintf("%s:%d:%s", suggestion.Location, suggestion.Quantity, suggestion.Strategy))
+			}
+			if !slices.Equal(suggestions, tt.wantSuggestions) {
+				t.Errorf("suggestions = %v, want %v", suggestions, tt.wantSuggestions)
+			}
+			if plan.Unplaced != tt.wantUnplaced {
+				t.Errorf("Unplaced = %d, want %d", plan.Unplaced, tt.wantUnplaced)
+			}
+		})
+	}
+}
+
+func TestConfirmPutaway(t *testing.T) {
+	tests := []struct {
+		name       string
+		toLocation string
+		quantity   int
+		wantErr    error
+	}{
+		{name: "fits an empty location", toLocation: "HOME-1", quantity: 5},
+		{name: "fills a location exactly", toLocation: "A-01", quantity: 6},
+		{name: "fills an unlimited location", toLocation: "B-01", quantity: 10},
+		{name: "location too small", toLocation: "HOME-1", quantity: 6, wantErr: ErrLocationCapacityExceeded},
+		{name: "no room beside existing stock", toLocation: "A-01", quantity: 7, wantErr: ErrLocationCapacityExceeded},
+		{name: "unknown location", toLocation: "Z-99", quantity: 1, wantErr: ErrUnknownLocation},
+		{name: "back to the receiving location", toLocation: "RECEIVING", quantity: 1, wantErr: apperrors.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			putawayLayout(t, s)
+
+			result, err := s.service.ConfirmPutaway(ctx, &model.PutawayConfirmation{
+				ProductID:   s.commodity.ID,
+				WarehouseID: s.warehouse.ID,
+				ToLocation:  tt.toLocation,
+				Quantity:    tt.quantity,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConfirmPutaway error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				inventories, _, err := s.service.GetAllInventories(ctx, firstPage())
+				if err != nil {
+					t.Fatalf("GetAllInventories: %v", err)
+				}
+				for _, inventory := range inventories {
+					if inventory.Location == "RECEIVING" && inventory.Quantity != 10 {
+						t.Errorf("receiving location holds %d, want the 10 units left there", inventory.Quantity)
+					}
+				}
+				return
+			}
+
+			if result.Source.Location != "RECEIVING" || result.Source.Quantity != 10-tt.quantity {
+				t.Errorf("source = %+v, want %d units left at RECEIVING", result.Source, 10-tt.quantity)
+			}
+			if result.Destination.Location != tt.toLocation {
+				t.Errorf("destination = %+v, want %s", result.Destination, tt.toLocation)
+			}
+			for _, inventory := range []*model.Inventory{result.Source, result.Destination} {
+				if reasons := s.reasons(t, inventory.ID); reasons[len(reasons)-1] != model.ReasonPutaway {
+					t.Errorf("ledger reasons of %s = %v, want a putaway last", inventory.Location, reasons)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmPutawayCountsEarlierPutaways(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	putawayLayout(t, s)
+	confirm := func(quantity int) error {
+		_, err := s.service.ConfirmPutaway(ctx, &model.PutawayConfirmation{
+			ProductID:   s.commodity.ID,
+			WarehouseID: s.warehouse.ID,
+			ToLocation:  "E-01",
+			Quantity:    quantity,
+		})
+		return err
+	}
+
+	if err := confirm(2); err != nil {
+		t.Fatalf("first ConfirmPutaway: %v", err)
+	}
+	if err := confirm(2); !errors.Is(err, ErrLocationCapacityExceeded) {
+		t.Errorf("second ConfirmPutaway error = %v, want %v", err, ErrLocationCapacityExceeded)
+	}
+	if err := confirm(1); err != nil {
+		t.Errorf("ConfirmPutaway of what still fits: %v", err)
+	}
+}
+
+// held returns the units held at each location of the store's warehouse.
+func (s *testStore) held(t *testing.T) map[string]int {
+	t.Helper()
+	inventories, _, err := s.service.GetAllInventories(context.Background(), firstPage())
+	if err != nil {
+		t.Fatalf("GetAllInventories: %v", err)
+	}
+	held := map[string]int{}
+	for _, inventory := range inventories {
+		held[inventory.Location] += inventory.Quantity
+	}
+	return held
+}
+
+// at returns the inventory record of the store's commodity at location.
+func (s *testStore) at(t *testing.T, location string) *model.Inventory {
+	t.Helper()
+	inventories, _, err := s.service.GetAllInventories(context.Background(), firstPage())
+	if err != nil {
+		t.Fatalf("GetAllInventories: %v", err)
+	}
+	for i := range inventories {
+		if inventories[i].Location == location {
+			return &inventories[i]
+		}
+	}
+	t.Fatalf("no inventory at %s", location)
+	return nil
+}
+
+func TestLocationCapacity(t *testing.T) {
+	// Each case starts from putawayLayout, where E-01 has room for 3 units and A-01 for 6 more.
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, s *testStore) error
+		wantErr error
+	}{
+		{
+			name: "create up to the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.CreateInventory(context.Background(), &model.Inventory{ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "E-01", Quantity: 3})
+				return err
+			},
+		},
+		{
+			name: "create beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.CreateInventory(context.Background(), &model.Inventory{ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "E-01", Quantity: 4})
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "create outside the storage locations",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.CreateInventory(context.Background(), &model.Inventory{ProductID: s.commodity.ID, WarehouseID: s.warehouse.ID, Location: "Z-99", Quantity: 100})
+				return err
+			},
+		},
+		{
+			name: "adjust up to the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.AdjustInventory(context.Background(), s.at(t, "A-01").ID.Hex(), &model.StockAdjustment{Delta: 6, Reason: model.ReasonReceipt})
+				return err
+			},
+		},
+		{
+			name: "adjust beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.AdjustInventory(context.Background(), s.at(t, "A-01").ID.Hex(), &model.StockAdjustment{Delta: 7, Reason: model.ReasonReceipt})
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "update beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				updated := *s.at(t, "A-01")
+				updated.Quantity = 11
+				_, err := s.service.UpdateInventory(context.Background(), updated.ID.Hex(), &updated)
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "update moving stock into a full location",
+			change: func(t *testing.T, s *testStore) error {
+				updated := *s.at(t, "RECEIVING")
+				updated.Location = "E-01"
+				_, err := s.service.UpdateInventory(context.Background(), updated.ID.Hex(), &updated)
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "transfer up to the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "RECEIVING", ToLocation: "E-01", Quantity: 3,
+				})
+				return err
+			},
+		},
+		{
+			name: "transfer beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromWarehouseID: s.warehouse.ID, FromLocation: "RECEIVING", ToLocation: "E-01", Quantity: 4,
+				})
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "transfer without warehouses beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				_, err := s.service.TransferStock(context.Background(), &model.StockTransfer{
+					ProductID: s.commodity.ID, FromLocation: "RECEIVING", ToLocation: "E-01", Quantity: 4,
+				})
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+		{
+			name: "receive beyond the capacity",
+			change: func(t *testing.T, s *testStore) error {
+				ctx := context.Background()
+				asn := openTestASN(t, s, 4, "E-01")
+				if _, err := s.service.RecordASNReceipt(ctx, asn.ID.Hex(), &model.ASNReceipt{
+					Lines: []model.ASNCount{{ProductID: s.commodity.ID, ReceivedQuantity: 4}},
+				}); err != nil {
+					t.Fatalf("RecordASNReceipt: %v", err)
+				}
+				_, err := s.service.CloseASN(ctx, asn.ID.Hex())
+				return err
+			},
+			wantErr: ErrLocationCapacityExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			putawayLayout(t, s)
+
+			err := tt.change(t, s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			// A refused change leaves every location as it was.
+			if held, want := s.held(t), map[string]int{"A-01": 4, "RECEIVING": 10}; !maps.Equal(held, want) {
+				t.Errorf("held = %v, want %v", held, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/model"
+	"context"
+	"fmt"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidASNID is returned when an ASN ID is not a valid ObjectID.
+var ErrInvalidASNID = apperrors.InvalidID("invalid ASN ID format")
+
+// ErrASNFinal is returned when counts are recorded against, or a status change is requested
+// for, an ASN that is already closed or cancelled.
+var ErrASNFinal = apperrors.Conflict("ASN can no longer be received against")
+
+// CreateASN records an advance shipping notice for a delivery to a warehouse. Every line starts
+// with nothing received.
+func (s *inventoryServiceImpl) CreateASN(ctx context.Context, asn *model.ASN) (*model.ASN, error) {
+	if _, err := s.verifyWarehouse(ctx, asn.WarehouseID); err != nil {
+		return nil, err
+	}
+	for i := range asn.Lines {
+		if _, err := s.verifyProduct(ctx, asn.Lines[i].ProductID); err != nil {
+			return nil, err
+		}
+		asn.Lines[i].ReceivedQuantity = 0
+		asn.Lines[i].Reconcile()
+	}
+
+	now := time.Now()
+	asn.ID = primitive.NilObjectID
+	asn.Status = model.ASNOpen
+	asn.Version = 0
+	asn.CreatedAt = now
+	asn.UpdatedAt = now
+	asn.ClosedAt = nil
+	return s.asns.CreateASN(ctx, asn)
+}
+
+// GetAllASNs returns one page of ASNs and the cursor for the next page.
+func (s *inventoryServiceImpl) GetAllASNs(ctx context.Context, params *pagination.Params) ([]model.ASN, string, error) {
+	return s.asns.GetAllASNs(ctx, params)
+}
+
+func (s *inventoryServiceImpl) GetASNByID(ctx context.Context, id string) (*model.ASN, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidASNID
+	}
+	return s.asns.GetASNByID(ctx, objID)
+}
+
+// RecordASNReceipt sets the counted totals of an ASN's lines. Counting a line again replaces its
+// earlier count, so miscounts can be corrected until the ASN is closed. Commodities that arrived
+// without being announced are added as lines expecting nothing, which flags them as over-received.
+func (s *inventoryServiceImpl) RecordASNReceipt(ctx context.Context, id string, receipt *model.ASNReceipt) (*model.ASN, error) {
+	asn, err := s.openASN(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, count := range receipt.Lines {
+		line := findASNLine(asn, count.ProductID)
+		if line == nil {
+			if _, err := s.verifyProduct(ctx, count.ProductID); err != nil {
+				return nil, err
+			}
+			asn.Lines = append(asn.Lines, model.ASNLine{ProductID: count.ProductID})
+			line = &asn.Lines[len(asn.Lines)-1]
+		}
+		line.ReceivedQuantity = count.ReceivedQuantity
+		if count.Location != "" {
+			line.Location = count.Location
+		}
+		line.Reconcile()
+	}
+	asn.Status = model.ASNReceiving
+	return s.asns.SaveASN(ctx, asn)
+}
+
+// CloseASN finishes receiving an ASN and posts every counted line into inventory with the
+// "receipt" ledger reason. Lines without a location are posted to the configured receiving
+// location. The warehouse must have room for everything received, and each storage location for
+// what is posted to it. Closing the ASN and posting its lines happen in one transaction: if any
+// line cannot be posted the ASN stays open and can be closed again, and since saving the ASN
+// checks its version, two clients closing it together cannot both post its stock.
+func (s *inventoryServiceImpl) CloseASN(ctx context.Context, id string) (*model.ASNCloseResult, error) {
+	asn, err := s.openASN(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	warehouse, err := s.verifyWarehouse(ctx, asn.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	unitVolumes := make(map[primitive.ObjectID]int, len(asn.Lines))
+	receivedVolume := 0
+	for _, line := range asn.Lines {
+		unitVolume, err := s.volumeOf(ctx, line.ProductID, 1)
+		if err != nil {
+			return nil, err
+		}
+		unitVolumes[line.ProductID] = unitVolume
+		receivedVolume += line.ReceivedQuantity * unitVolume
+	}
+
+	var result *model.ASNCloseResult
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.ensureCapacity(ctx, warehouse, receivedVolume); err != nil {
+			return err
+		}
+		now := time.Now()
+		asn.Status = model.ASNClosed
+		asn.ClosedAt = &now
+		closed, err := s.asns.SaveASN(ctx, asn)
+		if err != nil {
+			return err
+		}
+
+		posted := []model.Inventory{}
+		for _, line := range closed.Lines {
+			if line.ReceivedQuantity == 0 {
+				continue
+			}
+			location := line.Location
+			if location == "" {
+				location = config.Cfg.ReceivingLocation
+			}
+			inventory, err := s.repository.AddStock(ctx, line.ProductID, closed.WarehouseID, location, line.ReceivedQuantity, unitVolumes[line.ProductID])
+			if err != nil {
+				return fmt.Errorf("failed to post received product %s: %w", line.ProductID.Hex(), err)
+			}
+			if err := s.ensureLocationCapacity(ctx, inventory); err != nil {
+				return err
+			}
+			if err := s.recordMovement(ctx, inventory, inventory.Quantity-line.ReceivedQuantity, model.ReasonReceipt); err != nil {
+				return err
+			}
+			posted = append(posted, *inventory)
+		}
+		result = &model.ASNCloseResult{ASN: closed, Inventory: posted}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CancelASN marks an ASN as not going to be received. Nothing is posted to inventory.
+func (s *inventoryServiceImpl) CancelASN(ctx context.Context, id string) (*model.ASN, error) {
+	asn, err := s.openASN(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	asn.Status = model.ASNCancelled
+	asn.ClosedAt = &now
+	return s.asns.SaveASN(ctx, asn)
+}
+
+// openASN loads an ASN that can still be received against.
+func (s *inventoryServiceImpl) openASN(ctx context.Context, id string) (*model.ASN, error) {
+	asn, err := s.GetASNByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if asn.Status == model.ASNClosed || asn.Status == model.ASNCancelled {
+		return nil, fmt.Errorf("%w: ASN is %s", ErrASNFinal, asn.Status)
+	}
+	return asn, nil
+}
+
+// findASNLine returns the line of asn for productID, or nil if the ASN has none.
+func findASNLine(asn *model.ASN, productID primitive.ObjectID) *model.ASNLine {
+	for i := range asn.Lines {
+		if asn.Lines[i].ProductID == productID {
+			return &asn.Lines[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"Inventory-Services/model"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// openTestASN creates an ASN for the store's warehouse expecting units of its commodity.
+func openTestASN(t *testing.T, s *testStore, expected int, location string) *model.ASN {
+	t.Helper()
+	created, err := s.service.CreateASN(context.Background(), &model.ASN{
+		Supplier:    "Acme",
+		Reference:   "DN-1",
+		WarehouseID: s.warehouse.ID,
+		Lines:       []model.ASNLine{{ProductID: s.commodity.ID, ExpectedQuantity: expected, Location: location}},
+	})
+	if err != nil {
+		t.Fatalf("CreateASN: %v", err)
+	}
+	return created
+}
+
+func TestCreateASN(t *testing.T) {
+	tests := []struct {
+		name      string
+		warehouse func(s *testStore) primitive.ObjectID
+		product   func(s *testStore) primitive.ObjectID
+		wantErr   error
+	}{
+		{name: "known warehouse and commodity"},
+		{
+			name:      "unknown warehouse",
+			warehouse: func(*testStore) primitive.ObjectID { return primitive.NewObjectID() },
+			wantErr:   ErrUnknownWarehouse,
+		},
+		{
+			name:    "unknown commodity",
+			product: func(*testStore) primitive.ObjectID { return primitive.NewObjectID() },
+			wantErr: ErrUnknownCommodity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			asn := &model.ASN{
+				WarehouseID: s.warehouse.ID,
+				Status:      model.ASNClosed,
+				Lines:       []model.ASNLine{{ProductID: s.commodity.ID, ExpectedQuantity: 5, ReceivedQuantity: 5}},
+			}
+			if tt.warehouse != nil {
+				asn.WarehouseID = tt.warehouse(s)
+			}
+			if tt.product != nil {
+				asn.Lines[0].ProductID = tt.product(s)
+			}
+
+			created, err := s.service.CreateASN(context.Background(), asn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateASN error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if created.Status != model.ASNOpen {
+				t.Errorf("Status = %q, want %q", created.Status, model.ASNOpen)
+			}
+			// Nothing has been counted yet, whatever the request claimed.
+			if line := created.Lines[0]; line.ReceivedQuantity != 0 || line.Variance != -5 || line.Discrepancy != model.DiscrepancyUnder {
+				t.Errorf("line = %+v, want nothing received and 5 under", line)
+			}
+		})
+	}
+}
+
+func TestRecordASNReceipt(t *testing.T) {
+	tests := []struct {
+		name            string
+		expected        int // Zero leaves the commodity unannounced
+		received        int
+		wantVariance    int
+		wantDiscrepancy string
+	}{
+		{name: "short delivery", expected: 10, received: 7, wantVariance: -3, wantDiscrepancy: model.DiscrepancyUnder},
+		{name: "exact delivery", expected: 10, received: 10, wantDiscrepancy: model.DiscrepancyNone},
+		{name: "over delivery", expected: 10, received: 12, wantVariance: 2, wantDiscrepancy: model.DiscrepancyOver},
+		{name: "unannounced commodity", received: 4, wantVariance: 4, wantDiscrepancy: model.DiscrepancyOver},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			asn := &model.ASN{WarehouseID: s.warehouse.ID}
+			if tt.expected > 0 {
+				asn.Lines = []model.ASNLine{{ProductID: s.commodity.ID, ExpectedQuantity: tt.expected}}
+			}
+			created, err := s.service.CreateASN(ctx, asn)
+			if err != nil {
+				t.Fatalf("CreateASN: %v", err)
+			}
+
+			receiving, err := s.service.RecordASNReceipt(ctx, created.ID.Hex(), &model.ASNReceipt{
+				Lines: []model.ASNCount{{ProductID: s.commodity.ID, ReceivedQuantity: tt.received, Location: "DOCK-2"}},
+			})
+			if err != nil {
+				t.Fatalf("RecordASNReceipt: %v", err)
+			}
+			if receiving.Status != model.ASNReceiving || len(receiving.Lines) != 1 {
+				t.Fatalf("ASN = %+v, want one line being received", receiving)
+			}
+			line := receiving.Lines[0]
+			if line.ReceivedQuantity != tt.received || line.Variance != tt.wantVariance || line.Discrepancy != tt.wantDiscrepancy || line.Location != "DOCK-2" {
+				t.Errorf("line = %+v, want %d received at DOCK-2, variance %d, discrepancy %q",
+					line, tt.received, tt.wantVariance, tt.wantDiscrepancy)
+			}
+		})
+	}
+}
+
+func TestCloseASN(t *testing.T) {
+	tests := []struct {
+		name         string
+		storage      int
+		location     string
+		received     int
+		wantErr      error
+		wantLocation string
+	}{
+		{name: "posts to the line's location", received: 8, location: "A-01", wantLocation: "A-01"},
+		{name: "posts to the receiving location", received: 8, wantLocation: "RECEIVING"},
+		{name: "posts nothing for uncounted lines"},
+		{name: "warehouse without room", storage: 10, received: 8, wantErr: ErrCapacityExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(tt.storage)
+			asn := openTestASN(t, s, 8, tt.location)
+			if tt.received > 0 {
+				if _, err := s.service.RecordASNReceipt(ctx, asn.ID.Hex(), &model.ASNReceipt{
+					Lines: []model.ASNCount{{ProductID: s.commodity.ID, ReceivedQuantity: tt.received}},
+				}); err != nil {
+					t.Fatalf("RecordASNReceipt: %v", err)
+				}
+			}
+
+			result, err := s.service.CloseASN(ctx, asn.ID.Hex())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CloseASN error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// Nothing was posted and the ASN can still be received against.
+				if got, err := s.service.GetASNByID(ctx, asn.ID.Hex()); err != nil || got.Status != model.ASNReceiving {
+					t.Errorf("ASN = %+v, %v, want it still receiving", got, err)
+				}
+				if inventories, _, _ := s.service.GetAllInventories(ctx, firstPage()); len(inventories) != 0 {
+					t.Errorf("inventory = %+v, want none posted", inventories)
+				}
+				return
+			}
+
+			if result.ASN.Status != model.ASNClosed || result.ASN.ClosedAt == nil {
+				t.Errorf("ASN = %+v, want it closed", result.ASN)
+			}
+			if tt.received == 0 {
+				if len(result.Inventory) != 0 {
+					t.Errorf("posted %+v, want nothing", result.Inventory)
+				}
+			} else {
+				if len(result.Inventory) != 1 {
+					t.Fatalf("posted %d records, want 1", len(result.Inventory))
+				}
+				posted := result.Inventory[0]
+				if posted.Location != tt.wantLocation || posted.Quantity != tt.received || posted.UnitVolume != s.commodity.UnitVolume {
+					t.Errorf("posted %+v, want %d units at %s", posted, tt.received, tt.wantLocation)
+				}
+				if reasons := s.reasons(t, posted.ID); !slices.Equal(reasons, []string{model.ReasonReceipt}) {
+					t.Errorf("ledger reasons = %v, want one receipt", reasons)
+				}
+			}
+
+			// Closing is final: the stock cannot be posted twice.
+			if _, err := s.service.CloseASN(ctx, asn.ID.Hex()); !errors.Is(err, ErrASNFinal) {
+				t.Errorf("closing again error = %v, want %v", err, ErrASNFinal)
+			}
+		})
+	}
+}
+
+func TestCloseASNAddsToExistingStock(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	existing := s.stock(t, "A-01", 5)
+	asn := openTestASN(t, s, 3, "A-01")
+	if _, err := s.service.RecordASNReceipt(ctx, asn.ID.Hex(), &model.ASNReceipt{
+		Lines: []model.ASNCount{{ProductID: s.commodity.ID, ReceivedQuantity: 3}},
+	}); err != nil {
+		t.Fatalf("RecordASNReceipt: %v", err)
+	}
+
+	result, err := s.service.CloseASN(ctx, asn.ID.Hex())
+	if err != nil {
+		t.Fatalf("CloseASN: %v", err)
+	}
+	if len(result.Inventory) != 1 || result.Inventory[0].ID != existing.ID || result.Inventory[0].Quantity != 8 {
+		t.Errorf("posted %+v, want 8 units on record %s", result.Inventory, existing.ID.Hex())
+	}
+	if reasons := s.reasons(t, existing.ID); !slices.Equal(reasons, []string{model.ReasonCreate, model.ReasonReceipt}) {
+		t.Errorf("ledger reasons = %v, want create then receipt", reasons)
+	}
+}
+
+func TestFinalASNs(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(s InventoryService, ctx context.Context, id string) error
+	}{
+		{
+			name: "cancelled",
+			finish: func(s InventoryService, ctx context.Context, id string) error {
+				_, err := s.CancelASN(ctx, id)
+				return err
+			},
+		},
+		{
+			name: "closed",
+			finish: func(s InventoryService, ctx context.Context, id string) error {
+				_, err := s.CloseASN(ctx, id)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			asn := openTestASN(t, s, 8, "")
+			if err := tt.finish(s.service, ctx, asn.ID.Hex()); err != nil {
+				t.Fatalf("finishing the ASN: %v", err)
+			}
+
+			if _, err := s.service.RecordASNReceipt(ctx, asn.ID.Hex(), &model.ASNReceipt{
+				Lines: []model.ASNCount{{ProductID: s.commodity.ID, ReceivedQuantity: 8}},
+			}); !errors.Is(err, ErrASNFinal) {
+				t.Errorf("RecordASNReceipt error = %v, want %v", err, ErrASNFinal)
+			}
+			if _, err := s.service.CloseASN(ctx, asn.ID.Hex()); !errors.Is(err, ErrASNFinal) {
+				t.Errorf("CloseASN error = %v, want %v", err, ErrASNFinal)
+			}
+			if _, err := s.service.CancelASN(ctx, asn.ID.Hex()); !errors.Is(err, ErrASNFinal) {
+				t.Errorf("CancelASN error = %v, want %v", err, ErrASNFinal)
+			}
+			if inventories, _, _ := s.service.GetAllInventories(ctx, firstPage()); len(inventories) != 0 {
+				t.Errorf("inventory = %+v, want none posted", inventories)
+			}
+		})
+	}
+}
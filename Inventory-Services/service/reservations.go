@@ -0,0 +1,189 @@
+package service
+
+import (
+	"Inventory-Services/config"
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidReservationID is returned when a reservation ID is not a valid ObjectID.
+var ErrInvalidReservationID = apperrors.InvalidID("invalid reservation ID format")
+
+// expireBatchSize bounds how many expired reservations are loaded at once by ExpireReservations.
+const expireBatchSize = 100
+
+// ReserveStock holds units of a product in one warehouse and records the reservation. The units
+// are taken from a single inventory record when one has enough available, and otherwise split
+// across the warehouse's records. They stay on hand but stop counting as available until the
+// reservation is committed, released or expires.
+func (s *inventoryServiceImpl) ReserveStock(ctx context.Context, request *model.ReservationRequest) (*model.Reservation, error) {
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+	if request.TTLSeconds == 0 {
+		ttl = time.Duration(config.Cfg.ReservationDefaultTTLSeconds) * time.Second
+	}
+
+	// Held units and their reservation document are written together: without the document
+	// nothing would ever release the units.
+	var created *model.Reservation
+	err := s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		reservation, err := s.repository.ReserveStock(ctx, request)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		reservation.ReferenceID = request.ReferenceID
+		reservation.Status = model.ReservationActive
+		reservation.Actor = ActorFromContext(ctx)
+		reservation.CreatedAt = now
+		reservation.ExpiresAt = now.Add(ttl)
+		created, err = s.reservations.CreateReservation(ctx, reservation)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetAllReservations returns one page of reservations and the cursor for the next page.
+func (s *inventoryServiceImpl) GetAllReservations(ctx context.Context, params *pagination.Params) ([]model.Reservation, string, error) {
+	return s.reservations.GetAllReservations(ctx, params)
+}
+
+func (s *inventoryServiceImpl) GetReservationByID(ctx context.Context, id string) (*model.Reservation, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidReservationID
+	}
+	return s.reservations.GetReservationByID(ctx, objID)
+}
+
+// CommitReservation takes the reserved units off the shelf: the quantity and reserved count of
+// each record they are held on drop, and every change is recorded in the ledger.
+func (s *inventoryServiceImpl) CommitReservation(ctx context.Context, id string) (*model.Reservation, error) {
+	return s.closeReservation(ctx, id, model.ReservationCommitted)
+}
+
+// ReleaseReservation gives the reserved units back to the available stock of their records.
+func (s *inventoryServiceImpl) ReleaseReservation(ctx context.Context, id string) (*model.Reservation, error) {
+	return s.closeReservation(ctx, id, model.ReservationReleased)
+}
+
+// ExpireReservations releases every active reservation whose TTL has passed and returns how many it expired.
+func (s *inventoryServiceImpl) ExpireReservations(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		batch, err := s.reservations.GetExpiredReservations(ctx, time.Now(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, reservation := range batch {
+			if _, err := s.settleReservation(ctx, reservation.ID, model.ReservationExpired); err != nil {
+				if errors.Is(err, repository.ErrReservationClosed) {
+					continue // Committed or released since the batch was read
+				}
+				return expired, err
+			}
+			expired++
+		}
+		if len(batch) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// closeReservation commits or releases a reservation on request. One whose TTL has passed but
+// that the sweeper has not reached yet is expired instead, and the request fails.
+func (s *inventoryServiceImpl) closeReservation(ctx context.Context, id string, status model.ReservationStatus) (*model.Reservation, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidReservationID
+	}
+	reservation, err := s.reservations.GetReservationByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status == model.ReservationActive && !reservation.ExpiresAt.After(time.Now()) {
+		if _, err := s.settleReservation(ctx, objID, model.ReservationExpired); err != nil && !errors.Is(err, repository.ErrReservationClosed) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: reservation expired at %s", repository.ErrReservationClosed, reservation.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return s.settleReservation(ctx, objID, status)
+}
+
+// settleReservation closes an active reservation with status and applies each of its allocations
+// to its inventory record in one transaction. Closing only matches an active reservation, so a
+// reservation is applied at most once even when a client, another replica and the sweeper race
+// to close it, and if an inventory write fails the reservation stays active to be closed again.
+func (s *inventoryServiceImpl) settleReservation(ctx context.Context, id primitive.ObjectID, status model.ReservationStatus) (*model.Reservation, error) {
+	var reservation *model.Reservation
+	err := s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		closed, err := s.reservations.CloseReservation(ctx, id, status, time.Now())
+		if err != nil {
+			return err
+		}
+		reservation = closed
+
+		for _, allocation := range closed.Holdings() {
+			if status == model.ReservationCommitted {
+				committed, err := s.repository.CommitReserved(ctx, allocation.InventoryID, allocation.Quantity)
+				if err != nil {
+					return fmt.Errorf("failed to commit reserved stock: %w", err)
+				}
+				if err := s.recordMovement(ctx, committed, committed.Quantity+allocation.Quantity, model.ReasonReservationCommit); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := s.repository.ReleaseReserved(ctx, allocation.InventoryID, allocation.Quantity); err != nil {
+				if errors.Is(err, repository.ErrInventoryNotFound) || errors.Is(err, repository.ErrReservedStockMismatch) {
+					// There is nothing left to give back; keeping the reservation open would only
+					// make every sweep fail on it again.
+					logging.Logger(ctx).Error("reservation closed without releasing stock", "reservation_id", id.Hex(),
+						"status", status, "inventory_id", allocation.InventoryID.Hex(), "error", err)
+					continue
+				}
+				return fmt.Errorf("failed to release reserved stock: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	reservationsClosed.WithLabelValues(string(status)).Inc()
+	return reservation, nil
+}
+
+// RunReservationSweeper expires overdue reservations every interval until ctx is cancelled.
+func RunReservationSweeper(ctx context.Context, service InventoryService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, interval)
+			expired, err := service.ExpireReservations(sweepCtx)
+			cancel()
+			if err != nil {
+				slog.Error("failed to expire reservations", "expired", expired, "error", err)
+			} else if expired > 0 {
+				slog.Info("expired reservations", "count", expired)
+			}
+		}
+	}
+}
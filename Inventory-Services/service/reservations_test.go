@@ -0,0 +1,345 @@
+package service
+
+import (
+	"Inventory-Services/client"
+	"Inventory-Services/model"
+	"Inventory-Services/repository"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"wms-common/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReserveStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantity     int
+		location     string
+		wantErr      error
+		wantReserved int
+	}{
+		{name: "holds available units", quantity: 4, wantReserved: 4},
+		{name: "holds every unit", quantity: 10, wantReserved: 10},
+		{name: "more than available", quantity: 11, wantErr: repository.ErrInsufficientAvailable},
+		{name: "nothing at the location", quantity: 1, location: "B-01", wantErr: repository.ErrReservationSourceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			inventory := s.stock(t, "A-01", 10)
+
+			reservation, err := s.service.ReserveStock(context.Background(), &model.ReservationRequest{
+				ProductID:   s.commodity.ID,
+				Location:    tt.location,
+				Quantity:    tt.quantity,
+				ReferenceID: "order-1",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReserveStock error = %v, want %v", err, tt.wantErr)
+			}
+			if _, reserved := s.quantity(t, inventory.ID); reserved != tt.wantReserved {
+				t.Errorf("Reserved = %d, want %d", reserved, tt.wantReserved)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want := []model.Allocation{{InventoryID: inventory.ID, Location: "A-01", Quantity: tt.quantity}}
+			if reservation.Status != model.ReservationActive || !slices.Equal(reservation.Allocations, want) {
+				t.Errorf("reservation = %+v, want an active reservation of %+v", reservation, want)
+			}
+		})
+	}
+}
+
+func TestReserveStockSplitsAcrossRecords(t *testing.T) {
+	tests := []struct {
+		name         string
+		close        func(s InventoryService, ctx context.Context, id string) (*model.Reservation, error)
+		wantQuantity [2]int
+		wantReasons  []string
+	}{
+		{
+			name:         "commit",
+			close:        InventoryService.CommitReservation,
+			wantQuantity: [2]int{0, 2},
+			wantReasons:  []string{model.ReasonCreate, model.ReasonReservationCommit},
+		},
+		{name: "release", close: InventoryService.ReleaseReservation, wantQuantity: [2]int{6, 6}, wantReasons: []string{model.ReasonCreate}},
+		{name: "expiry", wantQuantity: [2]int{6, 6}, wantReasons: []string{model.ReasonCreate}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			records := []*model.Inventory{s.stock(t, "A-01", 6), s.stock(t, "A-02", 6)}
+			ttl := 0
+			if tt.close == nil {
+				ttl = -1
+			}
+
+			reservation, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 10, TTLSeconds: ttl})
+			if err != nil {
+				t.Fatalf("ReserveStock: %v", err)
+			}
+			want := []model.Allocation{
+				{InventoryID: records[0].ID, Location: "A-01", Quantity: 6},
+				{InventoryID: records[1].ID, Location: "A-02", Quantity: 4},
+			}
+			if reservation.Quantity != 10 || reservation.WarehouseID != s.warehouse.ID || !slices.Equal(reservation.Allocations, want) {
+				t.Fatalf("reservation = %+v, want 10 units in %s allocated as %+v", reservation, s.warehouse.ID.Hex(), want)
+			}
+			for i, wantReserved := range []int{6, 4} {
+				if _, reserved := s.quantity(t, records[i].ID); reserved != wantReserved {
+					t.Errorf("record %d Reserved = %d, want %d", i, reserved, wantReserved)
+				}
+			}
+
+			if tt.close != nil {
+				_, err = tt.close(s.service, ctx, reservation.ID.Hex())
+			} else {
+				_, err = s.service.ExpireReservations(ctx)
+			}
+			if err != nil {
+				t.Fatalf("closing the reservation: %v", err)
+			}
+			for i, record := range records {
+				if quantity, reserved := s.quantity(t, record.ID); quantity != tt.wantQuantity[i] || reserved != 0 {
+					t.Errorf("record %d Quantity, Reserved = %d, %d, want %d, 0", i, quantity, reserved, tt.wantQuantity[i])
+				}
+				if reasons := s.reasons(t, record.ID); !slices.Equal(reasons, tt.wantReasons) {
+					t.Errorf("record %d ledger reasons = %v, want %v", i, reasons, tt.wantReasons)
+				}
+			}
+		})
+	}
+}
+
+func TestReserveStockStaysInOneWarehouse(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	s.stock(t, "A-01", 6)
+	other := client.Warehouse{ID: primitive.NewObjectID(), Name: "Overflow"}
+	s.warehouses.Add(other)
+	if _, err := s.service.CreateInventory(ctx, &model.Inventory{ProductID: s.commodity.ID, WarehouseID: other.ID, Location: "A-01", Quantity: 6}); err != nil {
+		t.Fatalf("CreateInventory: %v", err)
+	}
+
+	if _, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 10}); !errors.Is(err, repository.ErrInsufficientAvailable) {
+		t.Fatalf("ReserveStock error = %v, want %v", err, repository.ErrInsufficientAvailable)
+	}
+	reservation, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, WarehouseID: other.ID, Quantity: 5})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if reservation.WarehouseID != other.ID || len(reservation.Allocations) != 1 {
+		t.Errorf("reservation = %+v, want one allocation in %s", reservation, other.ID.Hex())
+	}
+}
+
+func TestCloseReservation(t *testing.T) {
+	tests := []struct {
+		name         string
+		close        func(s InventoryService, ctx context.Context, id string) (*model.Reservation, error)
+		wantStatus   model.ReservationStatus
+		wantQuantity int
+		wantReasons  []string
+	}{
+		{
+			name:         "commit takes the units off the shelf",
+			close:        InventoryService.CommitReservation,
+			wantStatus:   model.ReservationCommitted,
+			wantQuantity: 6,
+			wantReasons:  []string{model.ReasonCreate, model.ReasonReservationCommit},
+		},
+		{
+			name:         "release gives the units back",
+			close:        InventoryService.ReleaseReservation,
+			wantStatus:   model.ReservationReleased,
+			wantQuantity: 10,
+			wantReasons:  []string{model.ReasonCreate},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStore(0)
+			inventory := s.stock(t, "A-01", 10)
+			reservation, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 4})
+			if err != nil {
+				t.Fatalf("ReserveStock: %v", err)
+			}
+
+			closed, err := tt.close(s.service, ctx, reservation.ID.Hex())
+			if err != nil {
+				t.Fatalf("closing the reservation: %v", err)
+			}
+			if closed.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", closed.Status, tt.wantStatus)
+			}
+			quantity, reserved := s.quantity(t, inventory.ID)
+			if quantity != tt.wantQuantity || reserved != 0 {
+				t.Errorf("Quantity, Reserved = %d, %d, want %d, 0", quantity, reserved, tt.wantQuantity)
+			}
+			if reasons := s.reasons(t, inventory.ID); !slices.Equal(reasons, tt.wantReasons) {
+				t.Errorf("ledger reasons = %v, want %v", reasons, tt.wantReasons)
+			}
+
+			// A reservation is applied once: closing it again in either way is a conflict.
+			for _, again := range []func(InventoryService, context.Context, string) (*model.Reservation, error){
+				InventoryService.CommitReservation, InventoryService.ReleaseReservation,
+			} {
+				if _, err := again(s.service, ctx, reservation.ID.Hex()); !errors.Is(err, repository.ErrReservationClosed) {
+					t.Errorf("closing again error = %v, want %v", err, repository.ErrReservationClosed)
+				}
+			}
+			if quantity, _ := s.quantity(t, inventory.ID); quantity != tt.wantQuantity {
+				t.Errorf("Quantity after closing again = %d, want %d", quantity, tt.wantQuantity)
+			}
+		})
+	}
+}
+
+func TestCommitAgainstMissingReservedUnitsIsAConflict(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	inventory := s.stock(t, "A-01", 10)
+	reservation, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 4})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	// The record loses its reserved units behind the reservation's back.
+	if _, err := s.inventories.ReleaseReserved(ctx, inventory.ID, 4); err != nil {
+		t.Fatalf("ReleaseReserved: %v", err)
+	}
+
+	_, err = s.service.CommitReservation(ctx, reservation.ID.Hex())
+	if !errors.Is(err, apperrors.ErrConflict) {
+		t.Fatalf("CommitReservation error = %v, want a conflict", err)
+	}
+	if quantity, reserved := s.quantity(t, inventory.ID); quantity != 10 || reserved != 0 {
+		t.Errorf("Quantity, Reserved = %d, %d, want 10, 0", quantity, reserved)
+	}
+}
+
+func TestExpiredReservations(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	inventory := s.stock(t, "A-01", 10)
+	overdue, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 3, TTLSeconds: -1})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if _, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 2, TTLSeconds: -1}); err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	current, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 1})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+
+	// An overdue reservation cannot be committed; the attempt expires it instead.
+	if _, err := s.service.CommitReservation(ctx, overdue.ID.Hex()); !errors.Is(err, repository.ErrReservationClosed) {
+		t.Fatalf("CommitReservation error = %v, want %v", err, repository.ErrReservationClosed)
+	}
+	if got, err := s.service.GetReservationByID(ctx, overdue.ID.Hex()); err != nil || got.Status != model.ReservationExpired {
+		t.Fatalf("GetReservationByID = %+v, %v, want an expired reservation", got, err)
+	}
+
+	expired, err := s.service.ExpireReservations(ctx)
+	if err != nil {
+		t.Fatalf("ExpireReservations: %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("ExpireReservations = %d, want 1", expired)
+	}
+	if quantity, reserved := s.quantity(t, inventory.ID); quantity != 10 || reserved != 1 {
+		t.Errorf("Quantity, Reserved = %d, %d, want 10, 1", quantity, reserved)
+	}
+	if got, err := s.service.GetReservationByID(ctx, current.ID.Hex()); err != nil || got.Status != model.ReservationActive {
+		t.Errorf("unexpired reservation = %+v, %v, want it still active", got, err)
+	}
+}
+
+func TestReservedStockIsGuarded(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(s *testStore, inventory *model.Inventory) error
+		wantErr error
+	}{
+		{
+			name: "update below the reserved units",
+			change: func(s *testStore, inventory *model.Inventory) error {
+				updated := *inventory
+				updated.Quantity = 3
+				_, err := s.service.UpdateInventory(context.Background(), inventory.ID.Hex(), &updated)
+				return err
+			},
+			wantErr: repository.ErrStockReserved,
+		},
+		{
+			name: "update moving reserved units",
+			change: func(s *testStore, inventory *model.Inventory) error {
+				updated := *inventory
+				updated.Location = "B-01"
+				_, err := s.service.UpdateInventory(context.Background(), inventory.ID.Hex(), &updated)
+				return err
+			},
+			wantErr: repository.ErrStockReserved,
+		},
+		{
+			name: "delete",
+			change: func(s *testStore, inventory *model.Inventory) error {
+				return s.service.DeleteInventory(context.Background(), inventory.ID.Hex())
+			},
+			wantErr: repository.ErrStockReserved,
+		},
+		{
+			name: "adjust below the reserved units",
+			change: func(s *testStore, inventory *model.Inventory) error {
+				_, err := s.service.AdjustInventory(context.Background(), inventory.ID.Hex(), &model.StockAdjustment{Delta: -7, Reason: model.ReasonDamage})
+				return err
+			},
+			wantErr: repository.ErrInsufficientStock,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(0)
+			inventory := s.stock(t, "A-01", 10)
+			if _, err := s.service.ReserveStock(context.Background(), &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 4}); err != nil {
+				t.Fatalf("ReserveStock: %v", err)
+			}
+
+			if err := tt.change(s, inventory); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if quantity, reserved := s.quantity(t, inventory.ID); quantity != 10 || reserved != 4 {
+				t.Errorf("Quantity, Reserved = %d, %d, want 10, 4", quantity, reserved)
+			}
+		})
+	}
+}
+
+func TestReservedStockCanStillBeUpdated(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	inventory := s.stock(t, "A-01", 10)
+	if _, err := s.service.ReserveStock(ctx, &model.ReservationRequest{ProductID: s.commodity.ID, Quantity: 4}); err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+
+	// The reserved count is not the client's to set, so an update leaves it alone.
+	updated := *inventory
+	updated.Quantity = 6
+	updated.Reserved = 0
+	got, err := s.service.UpdateInventory(ctx, inventory.ID.Hex(), &updated)
+	if err != nil {
+		t.Fatalf("UpdateInventory: %v", err)
+	}
+	if got.Quantity != 6 || got.Reserved != 4 {
+		t.Errorf("Quantity, Reserved = %d, %d, want 6, 4", got.Quantity, got.Reserved)
+	}
+}
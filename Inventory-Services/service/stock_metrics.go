@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// stockMovements counts the ledger entries written, by reason.
+var stockMovements = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "wms_stock_movements_total",
+	Help: "Stock movements recorded, by reason.",
+}, []string{"reason"})
+
+// reservationsClosed counts reservations by how they ended: committed, released or expired.
+var reservationsClosed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "wms_reservations_closed_total",
+	Help: "Stock reservations closed, by outcome.",
+}, []string{"status"})
+
+var unitsOnHandDesc = prometheus.NewDesc(
+	"wms_units_on_hand",
+	"Units of stock on hand, by warehouse.",
+	[]string{"warehouse_id"}, nil,
+)
+
+// stockCollectTimeout bounds the query run on each scrape.
+const stockCollectTimeout = 5 * time.Second
+
+// StockCollector reports the units on hand per warehouse. It asks the service on every
+// scrape, so the figures are always current and survive restarts.
+type StockCollector struct {
+	service InventoryService
+}
+
+// NewStockCollector creates a StockCollector reading from service.
+func NewStockCollector(service InventoryService) *StockCollector {
+	return &StockCollector{service: service}
+}
+
+func (c *StockCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- unitsOnHandDesc
+}
+
+func (c *StockCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), stockCollectTimeout)
+	defer cancel()
+
+	stock, err := c.service.GetStockByWarehouse(ctx)
+	if err != nil {
+		slog.Error("failed to collect stock metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(unitsOnHandDesc, err)
+		return
+	}
+	for warehouseID, units := range stock {
+		ch <- prometheus.MustNewConstMetric(unitsOnHandDesc, prometheus.GaugeValue, float64(units), warehouseID.Hex())
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"Order-Services/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/tracing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrCommodityNotFound is returned when the Commodity service has no record for the requested ID.
+var ErrCommodityNotFound = apperrors.NotFound("commodity not found")
+
+// Commodity is the subset of a Commodity service record that orders rely on.
+type Commodity struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+// CommodityClient looks up commodities owned by the Commodity service.
+type CommodityClient interface {
+	GetCommodity(ctx context.Context, id primitive.ObjectID) (*Commodity, error)
+}
+
+// httpCommodityClient implements CommodityClient over the Commodity service's REST API.
+type httpCommodityClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCommodityClient creates a CommodityClient that calls the Commodity service at config.Cfg.CommodityServiceURL.
+func NewCommodityClient() CommodityClient {
+	return &httpCommodityClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.CommodityServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpCommodityClient) GetCommodity(ctx context.Context, id primitive.ObjectID) (*Commodity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/commodities/%s", c.baseURL, id.Hex()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build commodity request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach commodity service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var commodity Commodity
+		if err := json.NewDecoder(resp.Body).Decode(&commodity); err != nil {
+			return nil, fmt.Errorf("failed to decode commodity response: %w", err)
+		}
+		return &commodity, nil
+	case http.StatusNotFound:
+		return nil, ErrCommodityNotFound
+	default:
+		return nil, fmt.Errorf("commodity service returned status %d", resp.StatusCode)
+	}
+}
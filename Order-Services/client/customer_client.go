@@ -0,0 +1,71 @@
+package client
+
+import (
+	"Order-Services/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/tracing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrCustomerNotFound is returned when the Customer service has no record for the requested ID.
+var ErrCustomerNotFound = apperrors.NotFound("customer not found")
+
+// Customer is the subset of a Customer service record that orders rely on.
+type Customer struct {
+	ID        primitive.ObjectID `json:"id"`
+	FirstName string             `json:"firstName"`
+	LastName  string             `json:"lastName"`
+}
+
+// CustomerClient looks up customers owned by the Customer service.
+type CustomerClient interface {
+	GetCustomer(ctx context.Context, id primitive.ObjectID) (*Customer, error)
+}
+
+// httpCustomerClient implements CustomerClient over the Customer service's REST API.
+type httpCustomerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCustomerClient creates a CustomerClient that calls the Customer service at config.Cfg.CustomerServiceURL.
+func NewCustomerClient() CustomerClient {
+	return &httpCustomerClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.CustomerServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpCustomerClient) GetCustomer(ctx context.Context, id primitive.ObjectID) (*Customer, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/customers/%s", c.baseURL, id.Hex()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build customer request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach customer service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var customer Customer
+		if err := json.NewDecoder(resp.Body).Decode(&customer); err != nil {
+			return nil, fmt.Errorf("failed to decode customer response: %w", err)
+		}
+		return &customer, nil
+	case http.StatusNotFound:
+		return nil, ErrCustomerNotFound
+	default:
+		return nil, fmt.Errorf("customer service returned status %d", resp.StatusCode)
+	}
+}
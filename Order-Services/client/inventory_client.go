@@ -0,0 +1,184 @@
+package client
+
+import (
+	"Order-Services/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/pagination"
+	"wms-common/tracing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInsufficientStock is returned when the Inventory service has no warehouse with enough
+// available units of a commodity to reserve.
+var ErrInsufficientStock = apperrors.Conflict("insufficient available stock")
+
+// actorHeader carries the acting user to the Inventory service, which records it on reservations.
+const actorHeader = "X-User-ID"
+
+// Reservation statuses reported by the Inventory service. Only active reservations hold stock.
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation is the subset of an Inventory service reservation that orders rely on.
+type Reservation struct {
+	ID          primitive.ObjectID `json:"id"`
+	ProductID   primitive.ObjectID `json:"productId"`
+	WarehouseID primitive.ObjectID `json:"warehouseId"` // Where the units are held
+	Quantity    int                `json:"quantity"`
+	ReferenceID string             `json:"referenceId"`
+	Status      string             `json:"status"`
+}
+
+// ReservationRequest asks the Inventory service to hold Quantity units of a commodity for
+// ReferenceID, in WarehouseID or, when it is zero, in whichever warehouse has them.
+type ReservationRequest struct {
+	ProductID   primitive.ObjectID `json:"productId"`
+	WarehouseID primitive.ObjectID `json:"warehouseId"`
+	Quantity    int                `json:"quantity"`
+	ReferenceID string             `json:"referenceId"`
+	TTLSeconds  int                `json:"ttlSeconds"`
+}
+
+// InventoryClient holds and settles stock through the Inventory service's reservations.
+type InventoryClient interface {
+	ReserveStock(ctx context.Context, request ReservationRequest, actor string) (*Reservation, error)
+	// Reservations returns every reservation made for referenceID, whatever its status.
+	Reservations(ctx context.Context, referenceID string) ([]Reservation, error)
+	CommitReservation(ctx context.Context, id primitive.ObjectID, actor string) error
+	ReleaseReservation(ctx context.Context, id primitive.ObjectID, actor string) error
+}
+
+// httpInventoryClient implements InventoryClient over the Inventory service's REST API.
+type httpInventoryClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewInventoryClient creates an InventoryClient that calls the Inventory service at config.Cfg.InventoryServiceURL.
+func NewInventoryClient() InventoryClient {
+	return &httpInventoryClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.InventoryServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpInventoryClient) ReserveStock(ctx context.Context, request ReservationRequest, actor string) (*Reservation, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reservation request: %w", err)
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/inventory/reservations", body, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var reservation Reservation
+		if err := json.NewDecoder(resp.Body).Decode(&reservation); err != nil {
+			return nil, fmt.Errorf("failed to decode reservation response: %w", err)
+		}
+		return &reservation, nil
+	case http.StatusConflict, http.StatusNotFound:
+		// Not found means the commodity is stocked nowhere, which for an order is the same shortage.
+		return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, errorMessage(resp))
+	default:
+		return nil, fmt.Errorf("inventory service returned status %d: %s", resp.StatusCode, errorMessage(resp))
+	}
+}
+
+func (c *httpInventoryClient) Reservations(ctx context.Context, referenceID string) ([]Reservation, error) {
+	query := url.Values{}
+	query.Set("referenceId", referenceID)
+	query.Set("limit", fmt.Sprint(pagination.MaxLimit))
+
+	reservations := []Reservation{}
+	for {
+		resp, err := c.do(ctx, http.MethodGet, "/inventory/reservations?"+query.Encode(), nil, "")
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("inventory service returned status %d", resp.StatusCode)
+		}
+		var page []Reservation
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode reservations response: %w", err)
+		}
+		reservations = append(reservations, page...)
+
+		next := resp.Header.Get(pagination.NextCursorHeader)
+		if next == "" {
+			return reservations, nil
+		}
+		query.Set("after", next)
+	}
+}
+
+func (c *httpInventoryClient) CommitReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	return c.settle(ctx, id, "commit", actor)
+}
+
+func (c *httpInventoryClient) ReleaseReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	return c.settle(ctx, id, "release", actor)
+}
+
+// settle commits or releases a reservation.
+func (c *httpInventoryClient) settle(ctx context.Context, id primitive.ObjectID, action, actor string) error {
+	resp, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/inventory/reservations/%s/%s", id.Hex(), action), nil, actor)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("inventory service could not %s reservation %s: status %d: %s", action, id.Hex(), resp.StatusCode, errorMessage(resp))
+	}
+	return nil
+}
+
+func (c *httpInventoryClient) do(ctx context.Context, method, path string, body []byte, actor string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inventory request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if actor != "" {
+		req.Header.Set(actorHeader, actor)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach inventory service: %w", err)
+	}
+	return resp, nil
+}
+
+// errorMessage returns the message of an error envelope, or the status text if the body is not one.
+func errorMessage(resp *http.Response) string {
+	var body apperrors.Body
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		return http.StatusText(resp.StatusCode)
+	}
+	return body.Error
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryCustomerClient is a CustomerClient backed by a map instead of the Customer service.
+type InMemoryCustomerClient struct {
+	mu        sync.RWMutex
+	customers map[primitive.ObjectID]Customer
+}
+
+// NewInMemoryCustomerClient creates an InMemoryCustomerClient seeded with the given customers.
+func NewInMemoryCustomerClient(customers ...Customer) *InMemoryCustomerClient {
+	c := &InMemoryCustomerClient{customers: make(map[primitive.ObjectID]Customer)}
+	for _, customer := range customers {
+		c.Add(customer)
+	}
+	return c
+}
+
+// Add registers a customer, replacing any existing entry with the same ID.
+func (c *InMemoryCustomerClient) Add(customer Customer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.customers[customer.ID] = customer
+}
+
+func (c *InMemoryCustomerClient) GetCustomer(ctx context.Context, id primitive.ObjectID) (*Customer, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	customer, ok := c.customers[id]
+	if !ok {
+		return nil, ErrCustomerNotFound
+	}
+	return &customer, nil
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryInventoryClient is an InventoryClient that keeps available stock per warehouse and
+// commodity and the reservations against it in maps instead of calling the Inventory service.
+type InMemoryInventoryClient struct {
+	mu           sync.Mutex
+	warehouses   []primitive.ObjectID                              // In the order stock was first put in them
+	available    map[primitive.ObjectID]map[primitive.ObjectID]int // By warehouse, then commodity
+	reservations map[primitive.ObjectID]Reservation
+}
+
+// NewInMemoryInventoryClient creates an InMemoryInventoryClient with no stock.
+func NewInMemoryInventoryClient() *InMemoryInventoryClient {
+	return &InMemoryInventoryClient{
+		available:    make(map[primitive.ObjectID]map[primitive.ObjectID]int),
+		reservations: make(map[primitive.ObjectID]Reservation),
+	}
+}
+
+// SetAvailable sets how many units of a commodity can still be reserved in a warehouse with a zero ID.
+func (c *InMemoryInventoryClient) SetAvailable(productID primitive.ObjectID, quantity int) {
+	c.SetAvailableIn(primitive.NilObjectID, productID, quantity)
+}
+
+// SetAvailableIn sets how many units of a commodity can still be reserved in a warehouse.
+func (c *InMemoryInventoryClient) SetAvailableIn(warehouseID, productID primitive.ObjectID, quantity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stock(warehouseID)[productID] = quantity
+}
+
+// Available returns how many units of a commodity can still be reserved, over all warehouses.
+func (c *InMemoryInventoryClient) Available(productID primitive.ObjectID) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for _, stock := range c.available {
+		total += stock[productID]
+	}
+	return total
+}
+
+// AvailableIn returns how many units of a commodity can still be reserved in a warehouse.
+func (c *InMemoryInventoryClient) AvailableIn(warehouseID, productID primitive.ObjectID) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.available[warehouseID][productID]
+}
+
+// stock returns the available units of a warehouse by commodity, adding the warehouse if it is
+// new. Callers hold c.mu.
+func (c *InMemoryInventoryClient) stock(warehouseID primitive.ObjectID) map[primitive.ObjectID]int {
+	stock, ok := c.available[warehouseID]
+	if !ok {
+		stock = make(map[primitive.ObjectID]int)
+		c.available[warehouseID] = stock
+		c.warehouses = append(c.warehouses, warehouseID)
+	}
+	return stock
+}
+
+// Reservation returns a reservation by ID, whatever its status.
+func (c *InMemoryInventoryClient) Reservation(id primitive.ObjectID) (Reservation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reservation, ok := c.reservations[id]
+	return reservation, ok
+}
+
+// ReserveStock holds the units in the requested warehouse or, when none is requested, in the
+// first one that has enough available, as the Inventory service does.
+func (c *InMemoryInventoryClient) ReserveStock(ctx context.Context, request ReservationRequest, actor string) (*Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	candidates := c.warehouses
+	if !request.WarehouseID.IsZero() {
+		candidates = []primitive.ObjectID{request.WarehouseID}
+	}
+	for _, warehouseID := range candidates {
+		stock := c.available[warehouseID]
+		if stock[request.ProductID] < request.Quantity {
+			continue
+		}
+		stock[request.ProductID] -= request.Quantity
+		reservation := Reservation{
+			ID:          primitive.NewObjectID(),
+			ProductID:   request.ProductID,
+			WarehouseID: warehouseID,
+			Quantity:    request.Quantity,
+			ReferenceID: request.ReferenceID,
+			Status:      ReservationActive,
+		}
+		c.reservations[reservation.ID] = reservation
+		return &reservation, nil
+	}
+	return nil, fmt.Errorf("%w: commodity %s", ErrInsufficientStock, request.ProductID.Hex())
+}
+
+func (c *InMemoryInventoryClient) Reservations(ctx context.Context, referenceID string) ([]Reservation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reservations := []Reservation{}
+	for _, reservation := range c.reservations {
+		if reservation.ReferenceID == referenceID {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations, nil
+}
+
+func (c *InMemoryInventoryClient) CommitReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	return c.settle(id, ReservationCommitted)
+}
+
+func (c *InMemoryInventoryClient) ReleaseReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	return c.settle(id, ReservationReleased)
+}
+
+// Expire closes an active reservation as the Inventory service does once its TTL has passed,
+// returning its units to the available stock.
+func (c *InMemoryInventoryClient) Expire(id primitive.ObjectID) error {
+	return c.settle(id, ReservationExpired)
+}
+
+// settle closes an active reservation, returning its units to the available stock unless it was committed.
+func (c *InMemoryInventoryClient) settle(id primitive.ObjectID, status string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reservation, ok := c.reservations[id]
+	if !ok || reservation.Status != ReservationActive {
+		return fmt.Errorf("reservation %s is not active", id.Hex())
+	}
+	if status != ReservationCommitted {
+		c.stock(reservation.WarehouseID)[reservation.ProductID] += reservation.Quantity
+	}
+	reservation.Status = status
+	c.reservations[id] = reservation
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Config holds the application configuration for this microservice.
+type Config struct {
+	Port         int    `json:"port"`
+	GinMode      string `json:"gin_mode"`
+	MongoDBURI   string `json:"mongodb_uri"`
+	DatabaseName string `json:"database_name"`
+
+	// Where repositories keep their data: StorageBackendMongo (default) or StorageBackendMemory
+	StorageBackend string `json:"storage_backend"`
+
+	// Base URL of the Customer service, used to validate the customer when an order is confirmed
+	CustomerServiceURL string `json:"customer_service_url"`
+	// Base URL of the Commodity service, used to validate order lines when an order is confirmed
+	CommodityServiceURL string `json:"commodity_service_url"`
+	// Base URL of the Inventory service, where allocating an order reserves stock for its lines
+	InventoryServiceURL string `json:"inventory_service_url"`
+	// How long an allocated order's reservations hold stock before Inventory gives it back
+	AllocationTTLSeconds int `json:"allocation_ttl_seconds"`
+}
+
+// Storage backends selectable through STORAGE_BACKEND.
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory" // Data lives in process memory and is lost on restart
+)
+
+// Cfg is the global configuration instance.
+var Cfg *Config
+
+// LoadConfig loads configuration from environment variables or defaults.
+func LoadConfig() error {
+	Cfg = &Config{
+		Port:         8089, // Default port for order service
+		GinMode:      "debug",
+		MongoDBURI:   "mongodb://mongodb-wms:27017", // Default for Docker Compose local
+		DatabaseName: "wms_order_db",
+
+		StorageBackend: StorageBackendMongo,
+
+		CustomerServiceURL:  "http://customer-service:8087",
+		CommodityServiceURL: "http://commodity-service:8086",
+		InventoryServiceURL: "http://inventory-service:8088",
+
+		AllocationTTLSeconds: 7 * 24 * 60 * 60,
+	}
+
+	// Override with environment variables if set (Render will set these)
+	if portStr := os.Getenv("PORT"); portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			Cfg.Port = port
+		}
+	}
+	if ginMode := os.Getenv("GIN_MODE"); ginMode != "" {
+		Cfg.GinMode = ginMode
+	}
+	if mongoURI := os.Getenv("MONGODB_URI"); mongoURI != "" {
+		Cfg.MongoDBURI = mongoURI
+	}
+	if dbName := os.Getenv("DATABASE_NAME"); dbName != "" {
+		Cfg.DatabaseName = dbName
+	}
+
+	if customerURL := os.Getenv("CUSTOMER_SERVICE_URL"); customerURL != "" {
+		Cfg.CustomerServiceURL = customerURL
+	}
+	if commodityURL := os.Getenv("COMMODITY_SERVICE_URL"); commodityURL != "" {
+		Cfg.CommodityServiceURL = commodityURL
+	}
+
+	if inventoryURL := os.Getenv("INVENTORY_SERVICE_URL"); inventoryURL != "" {
+		Cfg.InventoryServiceURL = inventoryURL
+	}
+	if ttlStr := os.Getenv("ALLOCATION_TTL_SECONDS"); ttlStr != "" {
+		ttl, err := strconv.Atoi(ttlStr)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ALLOCATION_TTL_SECONDS %q, expected a positive number of seconds", ttlStr)
+		}
+		Cfg.AllocationTTLSeconds = ttl
+	}
+
+	if storage := os.Getenv("STORAGE_BACKEND"); storage != "" {
+		Cfg.StorageBackend = storage
+	}
+	if Cfg.StorageBackend != StorageBackendMongo && Cfg.StorageBackend != StorageBackendMemory {
+		return fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", Cfg.StorageBackend, StorageBackendMongo, StorageBackendMemory)
+	}
+
+	fmt.Printf("Order Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s, CustomerServiceURL=%s, CommodityServiceURL=%s, InventoryServiceURL=%s, AllocationTTLSeconds=%d, StorageBackend=%s\n",
+		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName, Cfg.CustomerServiceURL, Cfg.CommodityServiceURL, Cfg.InventoryServiceURL, Cfg.AllocationTTLSeconds, Cfg.StorageBackend)
+
+	return nil
+}
+
+// UseMemoryStorage reports whether repositories keep their data in process memory instead of MongoDB.
+func (c *Config) UseMemoryStorage() bool {
+	return c.StorageBackend == StorageBackendMemory
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"Order-Services/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorHeader is the request header that identifies who is making a change.
+const ActorHeader = "X-User-ID"
+
+// ActorMiddleware copies the ActorHeader onto the request context so status changes can record it.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if actor := ctx.GetHeader(ActorHeader); actor != "" {
+			ctx.Request = ctx.Request.WithContext(service.WithActor(ctx.Request.Context(), actor))
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"Order-Services/model"
+	"Order-Services/repository"
+	"Order-Services/service"
+	"context"
+	"net/http"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderController handles HTTP requests related to orders.
+type OrderController struct {
+	orderService service.OrderService
+}
+
+// NewOrderController creates a new instance of OrderController.
+func NewOrderController(s service.OrderService) *OrderController {
+	return &OrderController{orderService: s}
+}
+
+// CreateOrder handles POST /orders requests. The order starts as a draft.
+func (c *OrderController) CreateOrder(ctx *gin.Context) {
+	var order model.Order
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if order.CustomerID.IsZero() {
+		apperrors.Respond(ctx, apperrors.Validation("Customer ID is required"))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	createdOrder, err := c.orderService.CreateOrder(timeoutCtx, &order)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, createdOrder)
+}
+
+// GetAllOrders handles GET /orders requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.OrderListSpec,
+// such as ?customerId= and ?status=.
+func (c *OrderController) GetAllOrders(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.OrderListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	orders, nextCursor, err := c.orderService.GetAllOrders(timeoutCtx, params)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, orders)
+}
+
+// GetOrderByID handles GET /orders/:id requests.
+func (c *OrderController) GetOrderByID(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	order, err := c.orderService.GetOrderByID(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+// UpdateOrder handles PUT /orders/:id requests. Only draft orders can be updated.
+func (c *OrderController) UpdateOrder(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var order model.Order
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
+		return
+	}
+
+	if order.CustomerID.IsZero() {
+		apperrors.Respond(ctx, apperrors.Validation("Customer ID is required"))
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	updatedOrder, err := c.orderService.UpdateOrder(timeoutCtx, id, &order)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, updatedOrder)
+}
+
+// DeleteOrder handles DELETE /orders/:id requests. Only draft orders can be deleted.
+func (c *OrderController) DeleteOrder(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := c.orderService.DeleteOrder(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil) // 204 No Content for successful deletion
+}
+
+// ConfirmOrder handles POST /orders/:id/confirm requests.
+func (c *OrderController) ConfirmOrder(ctx *gin.Context) {
+	c.changeStatus(ctx, c.orderService.ConfirmOrder)
+}
+
+// AllocateOrder handles POST /orders/:id/allocate requests.
+func (c *OrderController) AllocateOrder(ctx *gin.Context) {
+	c.changeStatus(ctx, c.orderService.AllocateOrder)
+}
+
+// PickOrder handles POST /orders/:id/pick requests.
+func (c *OrderController) PickOrder(ctx *gin.Context) {
+	c.changeStatus(ctx, c.orderService.PickOrder)
+}
+
+// ShipOrder handles POST /orders/:id/ship requests.
+func (c *OrderController) ShipOrder(ctx *gin.Context) {
+	c.changeStatus(ctx, c.orderService.ShipOrder)
+}
+
+// CancelOrder handles POST /orders/:id/cancel requests.
+func (c *OrderController) CancelOrder(ctx *gin.Context) {
+	c.changeStatus(ctx, c.orderService.CancelOrder)
+}
+
+// changeStatus runs one lifecycle step on the order named in the path and responds with the updated order.
+func (c *OrderController) changeStatus(ctx *gin.Context, step func(context.Context, string) (*model.Order, error)) {
+	id := ctx.Param("id")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	order, err := step(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
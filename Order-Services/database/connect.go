@@ -0,0 +1,43 @@
+package database
+
+import (
+	"Order-Services/config" // Updated import path
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"wms-common/tracing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Client holds the MongoDB client instance.
+var Client *mongo.Client
+
+// ConnectDB establishes a connection to MongoDB.
+func ConnectDB() (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The monitor records a trace span for every MongoDB command.
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.Cfg.MongoDBURI).SetMonitor(tracing.MongoMonitor()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	// Ping the primary to verify connection
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	log.Println("Successfully connected to MongoDB!")
+	Client = client
+	return client, nil
+}
+
+// GetCollection returns a handle to a MongoDB collection.
+func GetCollection(client *mongo.Client, collectionName string) *mongo.Collection {
+	return client.Database(config.Cfg.DatabaseName).Collection(collectionName)
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrderStatus is the stage an order has reached in its lifecycle.
+type OrderStatus string
+
+const (
+	StatusDraft     OrderStatus = "draft"     // Being put together; the customer and lines can still change
+	StatusConfirmed OrderStatus = "confirmed" // Customer and lines checked against the services that own them
+	StatusAllocated OrderStatus = "allocated" // Stock has been set aside for every line
+	StatusPicked    OrderStatus = "picked"    // Taken off the shelves and ready to leave
+	StatusShipped   OrderStatus = "shipped"   // Left the warehouse; final
+	StatusCancelled OrderStatus = "cancelled" // Abandoned before it was picked; final
+)
+
+// transitions lists the statuses each status may move to. Shipped and cancelled orders are final.
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusDraft:     {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusAllocated, StatusCancelled},
+	StatusAllocated: {StatusPicked, StatusCancelled},
+	StatusPicked:    {StatusShipped},
+}
+
+// CanBecome reports whether an order in status s may move to next.
+func (s OrderStatus) CanBecome(next OrderStatus) bool {
+	return slices.Contains(transitions[s], next)
+}
+
+// OrderLine asks for a quantity of one commodity.
+type OrderLine struct {
+	CommodityID primitive.ObjectID `bson:"commodity_id" json:"commodityId"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+}
+
+// StatusChange records one step of an order's lifecycle.
+type StatusChange struct {
+	Status    OrderStatus `bson:"status" json:"status"`
+	Actor     string      `bson:"actor" json:"actor"`
+	Timestamp time.Time   `bson:"timestamp" json:"timestamp"`
+}
+
+// Order is a customer's request for commodities: a header with its status, and its lines.
+type Order struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	CustomerID primitive.ObjectID `bson:"customer_id" json:"customerId"`
+	Status     OrderStatus        `bson:"status" json:"status"` // Set by the service; changed only through the lifecycle endpoints
+	Lines      []OrderLine        `bson:"lines" json:"lines"`
+	History    []StatusChange     `bson:"history" json:"history"` // Every status the order has had, oldest first
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updatedAt"`
+	Version    int                `bson:"version" json:"version"` // Incremented by every change, so a change based on a stale read can be refused
+	// SettlementPending is set while the reservations of a picked or cancelled order are still
+	// being committed or released in the Inventory service. It stays set if settling fails
+	// part-way, and repeating the pick or cancel finishes the job.
+	SettlementPending bool `bson:"settlement_pending" json:"settlementPending"`
+}
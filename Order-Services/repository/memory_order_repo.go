@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"Order-Services/model"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryOrderRepository is an OrderRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[primitive.ObjectID]model.Order
+}
+
+// NewInMemoryOrderRepository creates an empty InMemoryOrderRepository.
+func NewInMemoryOrderRepository() *InMemoryOrderRepository {
+	return &InMemoryOrderRepository{orders: map[primitive.ObjectID]model.Order{}}
+}
+
+func (r *InMemoryOrderRepository) CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
+	r.orders[order.ID] = cloneOrder(*order)
+	return order, nil
+}
+
+func (r *InMemoryOrderRepository) GetAllOrders(ctx context.Context, params *pagination.Params) ([]model.Order, string, error) {
+	r.mu.RLock()
+	orders := make([]model.Order, 0, len(r.orders))
+	for _, order := range r.orders {
+		orders = append(orders, cloneOrder(order))
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(orders, params)
+}
+
+func (r *InMemoryOrderRepository) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	order = cloneOrder(order)
+	return &order, nil
+}
+
+func (r *InMemoryOrderRepository) UpdateDraft(ctx context.Context, id primitive.ObjectID, order *model.Order) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	if stored.Status != model.StatusDraft {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotDraft, stored.Status)
+	}
+	stored.CustomerID = order.CustomerID
+	stored.Lines = slices.Clone(order.Lines)
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	r.orders[id] = stored
+
+	updated := cloneOrder(stored)
+	return &updated, nil
+}
+
+func (r *InMemoryOrderRepository) DeleteDraft(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if stored.Status != model.StatusDraft {
+		return fmt.Errorf("%w: order is %s", ErrOrderNotDraft, stored.Status)
+	}
+	delete(r.orders, id)
+	return nil
+}
+
+func (r *InMemoryOrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, from model.OrderStatus, version int, change model.StatusChange, settlementPending bool) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	if stored.Status != from || stored.Version != version {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderChanged, stored.Status)
+	}
+	stored.Status = change.Status
+	stored.SettlementPending = settlementPending
+	stored.UpdatedAt = change.Timestamp
+	stored.Version++
+	stored.History = append(slices.Clone(stored.History), change)
+	r.orders[id] = stored
+
+	updated := cloneOrder(stored)
+	return &updated, nil
+}
+
+func (r *InMemoryOrderRepository) FinishSettlement(ctx context.Context, id primitive.ObjectID, status model.OrderStatus) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	if stored.Status != status {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderChanged, stored.Status)
+	}
+	stored.SettlementPending = false
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	r.orders[id] = stored
+
+	updated := cloneOrder(stored)
+	return &updated, nil
+}
+
+// cloneOrder copies an order so callers cannot mutate stored lines or history.
+func cloneOrder(order model.Order) model.Order {
+	order.Lines = slices.Clone(order.Lines)
+	order.History = slices.Clone(order.History)
+	return order
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"Order-Services/config"
+	"Order-Services/database"
+	"Order-Services/model"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrOrderNotFound is returned when no order matches the given ID.
+	ErrOrderNotFound = apperrors.NotFound("order not found")
+	// ErrOrderNotDraft is returned when the customer or lines of an order are changed, or the
+	// order deleted, after it has left the draft status.
+	ErrOrderNotDraft = apperrors.Conflict("only draft orders can be changed or deleted")
+	// ErrOrderChanged is returned when an order was changed while a transition was in progress.
+	ErrOrderChanged = apperrors.Conflict("order was changed by another request")
+)
+
+// OrderListSpec lists the fields clients may sort and filter orders on.
+var OrderListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"status":    "status",
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+	},
+	FilterFields: map[string]pagination.Field{
+		"customerId": {BSON: "customer_id", Kind: pagination.ObjectID},
+		"status":     {BSON: "status", Kind: pagination.String},
+	},
+}
+
+// OrderRepository defines the interface for order data operations.
+type OrderRepository interface {
+	CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error)
+	GetAllOrders(ctx context.Context, params *pagination.Params) ([]model.Order, string, error)
+	GetOrderByID(ctx context.Context, id primitive.ObjectID) (*model.Order, error)
+	// UpdateDraft replaces the customer and lines of a draft order.
+	UpdateDraft(ctx context.Context, id primitive.ObjectID, order *model.Order) (*model.Order, error)
+	// DeleteDraft deletes a draft order.
+	DeleteDraft(ctx context.Context, id primitive.ObjectID) error
+	// UpdateStatus moves an order from status from to change.Status, appends change to its
+	// history and sets its SettlementPending flag, failing with ErrOrderChanged if the order is
+	// no longer in status from or has changed in any way since it was read at version.
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, from model.OrderStatus, version int, change model.StatusChange, settlementPending bool) (*model.Order, error)
+	// FinishSettlement clears the SettlementPending flag of an order in status.
+	FinishSettlement(ctx context.Context, id primitive.ObjectID, status model.OrderStatus) (*model.Order, error)
+}
+
+// orderRepositoryImpl implements OrderRepository for MongoDB.
+type orderRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewOrderRepository creates a new instance of OrderRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
+func NewOrderRepository() OrderRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryOrderRepository()
+	}
+	if database.Client == nil {
+		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
+	}
+	collection := database.GetCollection(database.Client, "orders")
+	return &orderRepositoryImpl{collection: collection}
+}
+
+func (r *orderRepositoryImpl) CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	ctx, done := instrument.Repository(ctx, "order", "CreateOrder")
+	defer done()
+
+	result, err := r.collection.InsertOne(ctx, order)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order in repository: %w", err)
+	}
+	order.ID = result.InsertedID.(primitive.ObjectID)
+	return order, nil
+}
+
+// GetAllOrders returns one page of orders and the cursor for the next page.
+func (r *orderRepositoryImpl) GetAllOrders(ctx context.Context, params *pagination.Params) ([]model.Order, string, error) {
+	ctx, done := instrument.Repository(ctx, "order", "GetAllOrders")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to retrieve orders from repository: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	orders := []model.Order{}
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, "", fmt.Errorf("failed to decode orders from cursor: %w", err)
+	}
+	return pagination.Page(orders, params)
+}
+
+func (r *orderRepositoryImpl) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*model.Order, error) {
+	ctx, done := instrument.Repository(ctx, "order", "GetOrderByID")
+	defer done()
+
+	var order model.Order
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve order by ID from repository: %w", err)
+	}
+	return &order, nil
+}
+
+// UpdateDraft replaces the customer and lines in one conditional update, so an order
+// confirmed concurrently is never edited.
+func (r *orderRepositoryImpl) UpdateDraft(ctx context.Context, id primitive.ObjectID, order *model.Order) (*model.Order, error) {
+	ctx, done := instrument.Repository(ctx, "order", "UpdateDraft")
+	defer done()
+
+	filter := bson.M{"_id": id, "status": model.StatusDraft}
+	update := bson.M{
+		"$set": bson.M{
+			"customer_id": order.CustomerID,
+			"lines":       order.Lines,
+			"updated_at":  time.Now(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	var updated model.Order
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, r.missedMatch(ctx, id, ErrOrderNotDraft)
+		}
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *orderRepositoryImpl) DeleteDraft(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "order", "DeleteDraft")
+	defer done()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "status": model.StatusDraft})
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return r.missedMatch(ctx, id, ErrOrderNotDraft)
+	}
+	return nil
+}
+
+// UpdateStatus matches on the expected current status and version, so of two concurrent
+// transitions from the same status only one succeeds, and a transition checked against an
+// order read before its draft was edited fails.
+func (r *orderRepositoryImpl) UpdateStatus(ctx context.Context, id primitive.ObjectID, from model.OrderStatus, version int, change model.StatusChange, settlementPending bool) (*model.Order, error) {
+	ctx, done := instrument.Repository(ctx, "order", "UpdateStatus")
+	defer done()
+
+	filter := bson.M{"_id": id, "status": from, "version": version}
+	update := bson.M{
+		"$set":  bson.M{"status": change.Status, "settlement_pending": settlementPending, "updated_at": change.Timestamp},
+		"$push": bson.M{"history": change},
+		"$inc":  bson.M{"version": 1},
+	}
+
+	var updated model.Order
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, r.missedMatch(ctx, id, ErrOrderChanged)
+		}
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *orderRepositoryImpl) FinishSettlement(ctx context.Context, id primitive.ObjectID, status model.OrderStatus) (*model.Order, error) {
+	ctx, done := instrument.Repository(ctx, "order", "FinishSettlement")
+	defer done()
+
+	filter := bson.M{"_id": id, "status": status}
+	update := bson.M{
+		"$set": bson.M{"settlement_pending": false, "updated_at": time.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+
+	var updated model.Order
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, r.missedMatch(ctx, id, ErrOrderChanged)
+		}
+		return nil, fmt.Errorf("failed to finish order settlement: %w", err)
+	}
+	return &updated, nil
+}
+
+// missedMatch explains why a conditional write matched nothing: the order is gone, or it
+// exists but is not in the expected status, in which case conflict is returned.
+func (r *orderRepositoryImpl) missedMatch(ctx context.Context, id primitive.ObjectID, conflict error) error {
+	order, err := r.GetOrderByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: order is %s", conflict, order.Status)
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"Order-Services/controller"
+	"Order-Services/service"
+	"fmt"      // Import fmt for string formatting
+	"net/http" // Import http for redirects
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderRoutes sets up the API routes for order operations.
+func OrderRoutes(router *gin.Engine) {
+	orderController := controller.NewOrderController(service.NewOrderService())
+
+	// Primary routes: define WITHOUT a trailing slash for collection endpoints
+	orderGroup := router.Group("/orders", controller.ActorMiddleware())
+	{
+		// Explicitly handle all HTTP methods for the base /orders path (no trailing slash)
+		orderGroup.POST("", orderController.CreateOrder) // Matches /orders
+		orderGroup.GET("", orderController.GetAllOrders) // Matches /orders
+
+		// Routes for specific IDs
+		orderGroup.GET("/:id", orderController.GetOrderByID) // Matches /orders/:id
+		orderGroup.PUT("/:id", orderController.UpdateOrder)
+		orderGroup.DELETE("/:id", orderController.DeleteOrder)
+
+		// Lifecycle: draft -> confirmed -> allocated -> picked -> shipped, or cancelled before picking
+		orderGroup.POST("/:id/confirm", orderController.ConfirmOrder)
+		orderGroup.POST("/:id/allocate", orderController.AllocateOrder)
+		orderGroup.POST("/:id/pick", orderController.PickOrder)
+		orderGroup.POST("/:id/ship", orderController.ShipOrder)
+		orderGroup.POST("/:id/cancel", orderController.CancelOrder)
+	}
+
+	// Add explicit 301 redirects for paths that might come in WITH trailing slashes.
+	// This ensures consistency and guides clients (and proxies) to the non-trailing-slash URL.
+	router.GET("/orders/", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/orders")
+	})
+	router.POST("/orders/", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/orders")
+	})
+	router.PUT("/orders/:id/", func(c *gin.Context) { // Handle /orders/:id/
+		id := c.Param("id")
+		c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/orders/%s", id))
+	})
+	router.DELETE("/orders/:id/", func(c *gin.Context) { // Handle /orders/:id/
+		id := c.Param("id")
+		c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/orders/%s", id))
+	})
+	router.GET("/orders/:id/", func(c *gin.Context) { // Explicitly redirect GET /orders/:id/
+		id := c.Param("id")
+		c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/orders/%s", id))
+	})
+	// Add OPTIONS redirect for CORS preflight if a trailing slash is sent
+	router.OPTIONS("/orders/", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/orders")
+	})
+	router.OPTIONS("/orders/:id/", func(c *gin.Context) {
+		id := c.Param("id")
+		c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/orders/%s", id))
+	})
+}
@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+// actorKey is the context key under which the acting user is stored.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries the identity responsible for an order status change.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or "anonymous" when none was set.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"Order-Services/client"
+	"Order-Services/config"
+	"Order-Services/model"
+	"Order-Services/repository"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrInvalidOrderID is returned when an order ID is not a valid ObjectID.
+	ErrInvalidOrderID = apperrors.InvalidID("invalid order ID format")
+	// ErrUnknownCustomer is returned when an order is confirmed for a customer that does not exist in the Customer service.
+	ErrUnknownCustomer = apperrors.InvalidReference("customer ID does not reference an existing customer")
+	// ErrUnknownCommodity is returned when an order is confirmed with a line for a commodity that does not exist in the Commodity service.
+	ErrUnknownCommodity = apperrors.InvalidReference("commodity ID does not reference an existing commodity")
+	// ErrInvalidTransition is returned when an order is moved to a status its lifecycle does not allow from its current one.
+	ErrInvalidTransition = apperrors.Conflict("order cannot move to the requested status")
+	// ErrEmptyOrder is returned when an order without lines is confirmed.
+	ErrEmptyOrder = apperrors.Validation("an order needs at least one line to be confirmed")
+	// ErrInsufficientStock is returned when an order is allocated but Inventory cannot reserve one of its lines.
+	ErrInsufficientStock = apperrors.Conflict("not enough available stock to allocate the order")
+	// ErrSettlementPending is returned when an order whose reservations are still being committed or released is moved on.
+	ErrSettlementPending = apperrors.Conflict("order's stock reservations are still being settled")
+)
+
+// OrderService defines the interface for order business logic.
+type OrderService interface {
+	CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error)
+	GetAllOrders(ctx context.Context, params *pagination.Params) ([]model.Order, string, error)
+	GetOrderByID(ctx context.Context, id string) (*model.Order, error)
+	UpdateOrder(ctx context.Context, id string, order *model.Order) (*model.Order, error)
+	DeleteOrder(ctx context.Context, id string) error
+	ConfirmOrder(ctx context.Context, id string) (*model.Order, error)
+	AllocateOrder(ctx context.Context, id string) (*model.Order, error)
+	PickOrder(ctx context.Context, id string) (*model.Order, error)
+	ShipOrder(ctx context.Context, id string) (*model.Order, error)
+	CancelOrder(ctx context.Context, id string) (*model.Order, error)
+}
+
+// Dependencies groups the collaborators an OrderService is built from.
+type Dependencies struct {
+	Orders      repository.OrderRepository
+	Customers   client.CustomerClient
+	Commodities client.CommodityClient
+	Inventory   client.InventoryClient
+}
+
+// orderServiceImpl implements OrderService.
+type orderServiceImpl struct {
+	repository  repository.OrderRepository
+	customers   client.CustomerClient
+	commodities client.CommodityClient
+	inventory   client.InventoryClient
+}
+
+// NewOrderService creates a new instance of OrderService.
+func NewOrderService() OrderService {
+	return NewOrderServiceWithDependencies(Dependencies{
+		Orders:      repository.NewOrderRepository(),
+		Customers:   client.NewCustomerClient(),
+		Commodities: client.NewCommodityClient(),
+		Inventory:   client.NewInventoryClient(),
+	})
+}
+
+// NewOrderServiceWithDependencies creates an OrderService from explicit collaborators,
+// so tests can substitute in-memory repositories and clients.
+func NewOrderServiceWithDependencies(deps Dependencies) OrderService {
+	return &orderServiceImpl{
+		repository:  deps.Orders,
+		customers:   deps.Customers,
+		commodities: deps.Commodities,
+		inventory:   deps.Inventory,
+	}
+}
+
+// CreateOrder stores a new draft order. The customer and commodities are not looked up until
+// the order is confirmed, so a draft can be put together while they are still being set up.
+func (s *orderServiceImpl) CreateOrder(ctx context.Context, order *model.Order) (*model.Order, error) {
+	if err := validateLines(order.Lines); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	order.Status = model.StatusDraft
+	order.History = []model.StatusChange{{Status: model.StatusDraft, Actor: ActorFromContext(ctx), Timestamp: now}}
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	if order.Lines == nil {
+		order.Lines = []model.OrderLine{}
+	}
+	return s.repository.CreateOrder(ctx, order)
+}
+
+// GetAllOrders returns one page of orders and the cursor for the next page.
+func (s *orderServiceImpl) GetAllOrders(ctx context.Context, params *pagination.Params) ([]model.Order, string, error) {
+	return s.repository.GetAllOrders(ctx, params)
+}
+
+func (s *orderServiceImpl) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidOrderID
+	}
+	return s.repository.GetOrderByID(ctx, objID)
+}
+
+// UpdateOrder replaces the customer and lines of a draft order. Orders past draft are fixed.
+func (s *orderServiceImpl) UpdateOrder(ctx context.Context, id string, order *model.Order) (*model.Order, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidOrderID
+	}
+	if err := validateLines(order.Lines); err != nil {
+		return nil, err
+	}
+	if order.Lines == nil {
+		order.Lines = []model.OrderLine{}
+	}
+	return s.repository.UpdateDraft(ctx, objID, order)
+}
+
+// DeleteOrder deletes a draft order. Orders past draft are cancelled instead, so their history is kept.
+func (s *orderServiceImpl) DeleteOrder(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrInvalidOrderID
+	}
+	return s.repository.DeleteDraft(ctx, objID)
+}
+
+// ConfirmOrder checks that the order has lines, that its customer exists in the Customer
+// service and that every commodity exists in the Commodity service, then confirms it.
+func (s *orderServiceImpl) ConfirmOrder(ctx context.Context, id string) (*model.Order, error) {
+	return s.transition(ctx, id, model.StatusConfirmed, s.validateReferences)
+}
+
+// AllocateOrder reserves stock in the Inventory service for every line, with the order ID as
+// the reservation reference, then marks the order allocated. If a line cannot be reserved, or
+// the order changes in the meantime, the reservations already made are released again.
+func (s *orderServiceImpl) AllocateOrder(ctx context.Context, id string) (*model.Order, error) {
+	order, err := s.load(ctx, id, model.StatusAllocated)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := s.reserve(ctx, order, order.Lines, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	allocated, err := s.move(ctx, order, model.StatusAllocated, false)
+	if err != nil {
+		s.releaseReservations(ctx, reservations)
+		return nil, err
+	}
+	return allocated, nil
+}
+
+// PickOrder marks an allocated order picked and commits its reservations, taking the units off
+// the Inventory service's shelves. Reservations that lapsed since the order was allocated are
+// made again first, so a pick always takes every line off the shelves or fails without moving
+// the order. The status changes before the reservations are committed, so an order cancelled
+// at the same time never has its stock committed; if committing fails part-way, the order stays
+// picked with SettlementPending set and picking it again commits the rest. Only what the lines
+// ask for is committed: reservations beyond that, such as one left behind by an allocation
+// that lost a race, are released.
+func (s *orderServiceImpl) PickOrder(ctx context.Context, id string) (*model.Order, error) {
+	order, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	retry := order.Status == model.StatusPicked && order.SettlementPending
+	if !retry {
+		if err := checkTransition(order, model.StatusPicked); err != nil {
+			return nil, err
+		}
+	}
+	cover, surplus, reserved, err := s.coverLines(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if !retry {
+		if order, err = s.move(ctx, order, model.StatusPicked, true); err != nil {
+			s.releaseReservations(ctx, reserved)
+			return nil, err
+		}
+	}
+	return s.settle(ctx, order, cover, surplus)
+}
+
+func (s *orderServiceImpl) ShipOrder(ctx context.Context, id string) (*model.Order, error) {
+	return s.transition(ctx, id, model.StatusShipped, requireSettled)
+}
+
+// CancelOrder cancels an order that has not been picked yet. An allocated order's reservations
+// are released once it is cancelled; if releasing fails part-way, the order stays cancelled with
+// SettlementPending set and cancelling it again releases the rest.
+func (s *orderServiceImpl) CancelOrder(ctx context.Context, id string) (*model.Order, error) {
+	order, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.StatusCancelled || !order.SettlementPending {
+		if err := checkTransition(order, model.StatusCancelled); err != nil {
+			return nil, err
+		}
+		allocated := order.Status == model.StatusAllocated
+		if order, err = s.move(ctx, order, model.StatusCancelled, allocated); err != nil {
+			return nil, err
+		}
+		if !allocated {
+			return order, nil
+		}
+	}
+	reservations, err := s.inventory.Reservations(ctx, order.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("order %s is %s but its reservations could not be listed: %w", order.ID.Hex(), order.Status, err)
+	}
+	return s.settle(ctx, order, nil, activeReservations(reservations))
+}
+
+// transition moves an order to status to if its lifecycle allows it, after check (if any)
+// accepts the order.
+func (s *orderServiceImpl) transition(ctx context.Context, id string, to model.OrderStatus, check func(context.Context, *model.Order) error) (*model.Order, error) {
+	order, err := s.load(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		if err := check(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+	return s.move(ctx, order, to, false)
+}
+
+// load reads an order that is about to move to status to, failing if its lifecycle does not allow it.
+func (s *orderServiceImpl) load(ctx context.Context, id string, to model.OrderStatus) (*model.Order, error) {
+	order, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(order, to); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *orderServiceImpl) get(ctx context.Context, id string) (*model.Order, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidOrderID
+	}
+	return s.repository.GetOrderByID(ctx, objID)
+}
+
+// checkTransition fails if an order's lifecycle does not allow it to move to status to.
+func checkTransition(order *model.Order, to model.OrderStatus) error {
+	if !order.Status.CanBecome(to) {
+		return fmt.Errorf("%w: a %s order cannot become %s", ErrInvalidTransition, order.Status, to)
+	}
+	return nil
+}
+
+// requireSettled refuses to move an order on while its reservations are still being settled.
+func requireSettled(ctx context.Context, order *model.Order) error {
+	if order.SettlementPending {
+		return fmt.Errorf("%w: pick order %s again to finish", ErrSettlementPending, order.ID.Hex())
+	}
+	return nil
+}
+
+// move records the transition of a loaded order to status to, setting its SettlementPending
+// flag. The repository rejects it if another request moved the order on or edited it since it
+// was loaded, so whatever was checked or reserved for the loaded order holds for the one that
+// is moved.
+func (s *orderServiceImpl) move(ctx context.Context, order *model.Order, to model.OrderStatus, settlementPending bool) (*model.Order, error) {
+	change := model.StatusChange{Status: to, Actor: ActorFromContext(ctx), Timestamp: time.Now()}
+	return s.repository.UpdateStatus(ctx, order.ID, order.Status, order.Version, change, settlementPending)
+}
+
+// reserve reserves stock for lines of an order, all in one warehouse so the order is picked in
+// one place. A non-zero warehouseID is that warehouse; otherwise the Inventory service picks it
+// for the first line, and if a later line does not fit there, the lines are reserved again in
+// the warehouse that line would come from, unless that was tried already. If a line cannot be
+// reserved, the reservations already made for the others are released again.
+func (s *orderServiceImpl) reserve(ctx context.Context, order *model.Order, lines []model.OrderLine, warehouseID primitive.ObjectID) ([]client.Reservation, error) {
+	pinned := !warehouseID.IsZero()
+	tried := map[primitive.ObjectID]bool{}
+	for {
+		reservations, short, err := s.reserveIn(ctx, order, lines, warehouseID)
+		if err == nil {
+			return reservations, nil
+		}
+		s.releaseReservations(ctx, reservations)
+		if !pinned && len(reservations) > 0 && errors.Is(err, client.ErrInsufficientStock) {
+			tried[reservations[0].WarehouseID] = true
+			if probe, _, probeErr := s.reserveIn(ctx, order, []model.OrderLine{short}, primitive.NilObjectID); probeErr == nil {
+				s.releaseReservations(ctx, probe)
+				if next := probe[0].WarehouseID; !tried[next] {
+					warehouseID = next
+					continue
+				}
+			}
+		}
+		if errors.Is(err, client.ErrInsufficientStock) {
+			return nil, fmt.Errorf("%w: commodity %s", ErrInsufficientStock, short.CommodityID.Hex())
+		}
+		return nil, fmt.Errorf("failed to reserve commodity %s: %w", short.CommodityID.Hex(), err)
+	}
+}
+
+// reserveIn reserves stock for lines in turn, in warehouseID or, when it is zero, in the
+// warehouse the first reservation is made in. It stops at the first line it cannot reserve and
+// returns the reservations made so far together with that line.
+func (s *orderServiceImpl) reserveIn(ctx context.Context, order *model.Order, lines []model.OrderLine, warehouseID primitive.ObjectID) ([]client.Reservation, model.OrderLine, error) {
+	reservations := make([]client.Reservation, 0, len(lines))
+	for _, line := range lines {
+		reservation, err := s.inventory.ReserveStock(ctx, client.ReservationRequest{
+			ProductID:   line.CommodityID,
+			WarehouseID: warehouseID,
+			Quantity:    line.Quantity,
+			ReferenceID: order.ID.Hex(),
+			TTLSeconds:  config.Cfg.AllocationTTLSeconds,
+		}, ActorFromContext(ctx))
+		if err != nil {
+			return reservations, line, err
+		}
+		reservations = append(reservations, *reservation)
+		warehouseID = reservation.WarehouseID
+	}
+	return reservations, model.OrderLine{}, nil
+}
+
+// coverLines makes sure an allocated order's reservations still hold every line in full.
+// Reservations lapse when they expire after AllocationTTLSeconds or are released in the
+// Inventory service; whatever lapsed is reserved again. It returns the active reservations that
+// cover the lines, those beyond them, and the ones it made, so those can be given back if the
+// order does not move on. What is reserved again comes from the warehouse the order's stock is
+// already reserved in.
+func (s *orderServiceImpl) coverLines(ctx context.Context, order *model.Order) (cover, surplus, reserved []client.Reservation, err error) {
+	reservations, err := s.inventory.Reservations(ctx, order.ID.Hex())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list the reservations of order %s: %w", order.ID.Hex(), err)
+	}
+	cover, surplus, short := allot(order.Lines, reservations)
+	if reserved, err = s.reserve(ctx, order, short, warehouseOf(reservations)); err != nil {
+		return nil, nil, nil, err
+	}
+	return append(cover, reserved...), surplus, reserved, nil
+}
+
+// allot splits the active reservations among the lines they were made for. Units already
+// committed count towards their line first; the active reservations then fill each line,
+// largest first, as far as they fit whole. It returns the reservations that fit, those that do
+// not, and what each line still lacks.
+func allot(lines []model.OrderLine, reservations []client.Reservation) (cover, surplus []client.Reservation, short []model.OrderLine) {
+	need := make(map[primitive.ObjectID]int, len(lines))
+	for _, line := range lines {
+		need[line.CommodityID] = line.Quantity
+	}
+	for _, reservation := range reservations {
+		if reservation.Status == client.ReservationCommitted {
+			need[reservation.ProductID] -= reservation.Quantity
+		}
+	}
+	active := activeReservations(reservations)
+	slices.SortFunc(active, func(a, b client.Reservation) int {
+		if a.Quantity != b.Quantity {
+			return b.Quantity - a.Quantity
+		}
+		return strings.Compare(a.ID.Hex(), b.ID.Hex())
+	})
+	for _, reservation := range active {
+		if reservation.Quantity <= need[reservation.ProductID] {
+			need[reservation.ProductID] -= reservation.Quantity
+			cover = append(cover, reservation)
+		} else {
+			surplus = append(surplus, reservation)
+		}
+	}
+	for _, line := range lines {
+		if missing := need[line.CommodityID]; missing > 0 {
+			short = append(short, model.OrderLine{CommodityID: line.CommodityID, Quantity: missing})
+		}
+	}
+	return cover, surplus, short
+}
+
+// warehouseOf returns the warehouse an order's stock is reserved in: that of its first active or
+// committed reservation, or a zero ID if it has none.
+func warehouseOf(reservations []client.Reservation) primitive.ObjectID {
+	for _, reservation := range reservations {
+		if reservation.Status == client.ReservationActive || reservation.Status == client.ReservationCommitted {
+			return reservation.WarehouseID
+		}
+	}
+	return primitive.NilObjectID
+}
+
+// activeReservations returns the reservations that still hold stock.
+func activeReservations(reservations []client.Reservation) []client.Reservation {
+	active := []client.Reservation{}
+	for _, reservation := range reservations {
+		if reservation.Status == client.ReservationActive {
+			active = append(active, reservation)
+		}
+	}
+	return active
+}
+
+// settle commits the reservations in commit and releases those in release, then clears the
+// order's SettlementPending flag. Settling stops at the first failure, leaving the flag set so
+// the step can be repeated.
+func (s *orderServiceImpl) settle(ctx context.Context, order *model.Order, commit, release []client.Reservation) (*model.Order, error) {
+	actor := ActorFromContext(ctx)
+	for _, reservation := range commit {
+		if err := s.inventory.CommitReservation(ctx, reservation.ID, actor); err != nil {
+			return nil, fmt.Errorf("order %s is %s but its reservations could not be committed: %w", order.ID.Hex(), order.Status, err)
+		}
+	}
+	for _, reservation := range release {
+		if err := s.inventory.ReleaseReservation(ctx, reservation.ID, actor); err != nil {
+			return nil, fmt.Errorf("order %s is %s but its reservations could not be released: %w", order.ID.Hex(), order.Status, err)
+		}
+	}
+	return s.repository.FinishSettlement(ctx, order.ID, order.Status)
+}
+
+// releaseReservations gives back reservations made for an allocation that did not go through.
+// Failures are logged rather than returned: the allocation error matters more to the caller,
+// and picking the order releases whatever it does not need, while the rest expires after
+// AllocationTTLSeconds.
+func (s *orderServiceImpl) releaseReservations(ctx context.Context, reservations []client.Reservation) {
+	for _, reservation := range reservations {
+		if err := s.inventory.ReleaseReservation(ctx, reservation.ID, ActorFromContext(ctx)); err != nil {
+			logging.Logger(ctx).Error("failed to release reservation of an abandoned allocation",
+				"reservation_id", reservation.ID.Hex(), "reference_id", reservation.ReferenceID, "error", err)
+		}
+	}
+}
+
+// validateReferences confirms that an order's customer and commodities exist.
+func (s *orderServiceImpl) validateReferences(ctx context.Context, order *model.Order) error {
+	if len(order.Lines) == 0 {
+		return ErrEmptyOrder
+	}
+	if _, err := s.customers.GetCustomer(ctx, order.CustomerID); err != nil {
+		if errors.Is(err, client.ErrCustomerNotFound) {
+			return fmt.Errorf("%w: %s", ErrUnknownCustomer, order.CustomerID.Hex())
+		}
+		return fmt.Errorf("failed to verify customer %s: %w", order.CustomerID.Hex(), err)
+	}
+	for _, line := range order.Lines {
+		if _, err := s.commodities.GetCommodity(ctx, line.CommodityID); err != nil {
+			if errors.Is(err, client.ErrCommodityNotFound) {
+				return fmt.Errorf("%w: %s", ErrUnknownCommodity, line.CommodityID.Hex())
+			}
+			return fmt.Errorf("failed to verify commodity %s: %w", line.CommodityID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// validateLines checks that every line names a commodity, asks for a positive quantity,
+// and that no commodity appears on two lines.
+func validateLines(lines []model.OrderLine) error {
+	seen := make(map[primitive.ObjectID]bool, len(lines))
+	for i, line := range lines {
+		if line.CommodityID.IsZero() {
+			return apperrors.Validation(fmt.Sprintf("line %d: commodityId is required", i+1))
+		}
+		if line.Quantity <= 0 {
+			return apperrors.Validation(fmt.Sprintf("line %d: quantity must be positive", i+1))
+		}
+		if seen[line.CommodityID] {
+			return apperrors.Validation(fmt.Sprintf("line %d: commodity %s is already on another line", i+1, line.CommodityID.Hex()))
+		}
+		seen[line.CommodityID] = true
+	}
+	return nil
+}
@@ -0,0 +1,733 @@
+package service
+
+import (
+	"Order-Services/client"
+	"Order-Services/config"
+	"Order-Services/model"
+	"Order-Services/repository"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	config.Cfg = &config.Config{AllocationTTLSeconds: 60}
+	os.Exit(m.Run())
+}
+
+// testShop is an OrderService on memory storage whose neighbours know one customer and two
+// commodities, with 10 units of each available.
+type testShop struct {
+	service     OrderService
+	orders      repository.OrderRepository
+	inventory   *client.InMemoryInventoryClient
+	customer    client.Customer
+	commodities []client.Commodity
+}
+
+func newTestShop() *testShop {
+	shop := &testShop{
+		orders:    repository.NewInMemoryOrderRepository(),
+		inventory: client.NewInMemoryInventoryClient(),
+		customer:  client.Customer{ID: primitive.NewObjectID(), FirstName: "Ada"},
+		commodities: []client.Commodity{
+			{ID: primitive.NewObjectID(), Name: "Crate"},
+			{ID: primitive.NewObjectID(), Name: "Pallet"},
+		},
+	}
+	for _, commodity := range shop.commodities {
+		shop.inventory.SetAvailable(commodity.ID, 10)
+	}
+	shop.service = shop.serviceOver(shop.orders)
+	return shop
+}
+
+// serviceOver builds an OrderService over orders that shares the shop's neighbours.
+func (shop *testShop) serviceOver(orders repository.OrderRepository) OrderService {
+	return NewOrderServiceWithDependencies(Dependencies{
+		Orders:      orders,
+		Customers:   client.NewInMemoryCustomerClient(shop.customer),
+		Commodities: client.NewInMemoryCommodityClient(shop.commodities...),
+		Inventory:   shop.inventory,
+	})
+}
+
+// draft creates a draft order for the shop's customer asking for quantities of its commodities in turn.
+func (shop *testShop) draft(t *testing.T, quantities ...int) *model.Order {
+	t.Helper()
+	order := &model.Order{CustomerID: shop.customer.ID}
+	for i, quantity := range quantities {
+		order.Lines = append(order.Lines, model.OrderLine{CommodityID: shop.commodities[i].ID, Quantity: quantity})
+	}
+	created, err := shop.service.CreateOrder(context.Background(), order)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return created
+}
+
+// available returns the units of each of the shop's commodities that can still be reserved.
+func (shop *testShop) available() []int {
+	available := make([]int, len(shop.commodities))
+	for i, commodity := range shop.commodities {
+		available[i] = shop.inventory.Available(commodity.ID)
+	}
+	return available
+}
+
+// reservations returns the reservations made for an order that have status.
+func (shop *testShop) reservations(t *testing.T, order *model.Order, status string) []client.Reservation {
+	t.Helper()
+	all, err := shop.inventory.Reservations(context.Background(), order.ID.Hex())
+	if err != nil {
+		t.Fatalf("Reservations: %v", err)
+	}
+	matching := []client.Reservation{}
+	for _, reservation := range all {
+		if reservation.Status == status {
+			matching = append(matching, reservation)
+		}
+	}
+	return matching
+}
+
+// step is one lifecycle endpoint of an OrderService.
+type step func(OrderService, context.Context, string) (*model.Order, error)
+
+var (
+	confirm  step = OrderService.ConfirmOrder
+	allocate step = OrderService.AllocateOrder
+	pick     step = OrderService.PickOrder
+	ship     step = OrderService.ShipOrder
+	cancel   step = OrderService.CancelOrder
+)
+
+func TestOrderLifecycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []step
+		wantErr     error // Returned by the last step
+		wantHistory []model.OrderStatus
+	}{
+		{
+			name:        "draft to shipped",
+			steps:       []step{confirm, allocate, pick, ship},
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed, model.StatusAllocated, model.StatusPicked, model.StatusShipped},
+		},
+		{
+			name:        "cancel a draft",
+			steps:       []step{cancel},
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusCancelled},
+		},
+		{
+			name:        "cancel a confirmed order",
+			steps:       []step{confirm, cancel},
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed, model.StatusCancelled},
+		},
+		{
+			name:        "cancel an allocated order",
+			steps:       []step{confirm, allocate, cancel},
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed, model.StatusAllocated, model.StatusCancelled},
+		},
+		{
+			name:        "allocate a draft",
+			steps:       []step{allocate},
+			wantErr:     ErrInvalidTransition,
+			wantHistory: []model.OrderStatus{model.StatusDraft},
+		},
+		{
+			name:        "pick a confirmed order",
+			steps:       []step{confirm, pick},
+			wantErr:     ErrInvalidTransition,
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed},
+		},
+		{
+			name:        "confirm twice",
+			steps:       []step{confirm, confirm},
+			wantErr:     ErrInvalidTransition,
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed},
+		},
+		{
+			name:        "cancel a picked order",
+			steps:       []step{confirm, allocate, pick, cancel},
+			wantErr:     ErrInvalidTransition,
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed, model.StatusAllocated, model.StatusPicked},
+		},
+		{
+			name:        "cancel a shipped order",
+			steps:       []step{confirm, allocate, pick, ship, cancel},
+			wantErr:     ErrInvalidTransition,
+			wantHistory: []model.OrderStatus{model.StatusDraft, model.StatusConfirmed, model.StatusAllocated, model.StatusPicked, model.StatusShipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithActor(context.Background(), "alice")
+			shop := newTestShop()
+			order := shop.draft(t, 2, 3)
+
+			var err error
+			for i, next := range tt.steps {
+				_, err = next(shop.service, ctx, order.ID.Hex())
+				if err != nil && i < len(tt.steps)-1 {
+					t.Fatalf("step %d: %v", i+1, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("last step error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := shop.service.GetOrderByID(ctx, order.ID.Hex())
+			if err != nil {
+				t.Fatalf("GetOrderByID: %v", err)
+			}
+			history := []model.OrderStatus{}
+			for _, change := range got.History {
+				history = append(history, change.Status)
+			}
+			if !slices.Equal(history, tt.wantHistory) {
+				t.Errorf("history = %v, want %v", history, tt.wantHistory)
+			}
+			if want := tt.wantHistory[len(tt.wantHistory)-1]; got.Status != want {
+				t.Errorf("Status = %q, want %q", got.Status, want)
+			}
+			if last := got.History[len(got.History)-1]; len(got.History) > 1 && last.Actor != "alice" {
+				t.Errorf("last change made by %q, want alice", last.Actor)
+			}
+		})
+	}
+}
+
+func TestConfirmOrderChecksReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   func(shop *testShop) *model.Order
+		wantErr error
+	}{
+		{
+			name:    "no lines",
+			order:   func(shop *testShop) *model.Order { return &model.Order{CustomerID: shop.customer.ID} },
+			wantErr: ErrEmptyOrder,
+		},
+		{
+			name: "unknown customer",
+			order: func(shop *testShop) *model.Order {
+				return &model.Order{CustomerID: primitive.NewObjectID(), Lines: []model.OrderLine{{CommodityID: shop.commodities[0].ID, Quantity: 1}}}
+			},
+			wantErr: ErrUnknownCustomer,
+		},
+		{
+			name: "unknown commodity",
+			order: func(shop *testShop) *model.Order {
+				return &model.Order{CustomerID: shop.customer.ID, Lines: []model.OrderLine{{CommodityID: primitive.NewObjectID(), Quantity: 1}}}
+			},
+			wantErr: ErrUnknownCommodity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			order, err := shop.service.CreateOrder(ctx, tt.order(shop))
+			if err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+
+			if _, err := shop.service.ConfirmOrder(ctx, order.ID.Hex()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConfirmOrder error = %v, want %v", err, tt.wantErr)
+			}
+			if got, err := shop.service.GetOrderByID(ctx, order.ID.Hex()); err != nil || got.Status != model.StatusDraft {
+				t.Errorf("order = %+v, %v, want it still a draft", got, err)
+			}
+		})
+	}
+}
+
+func TestAllocateOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		quantities    []int
+		wantErr       error
+		wantStatus    model.OrderStatus
+		wantAvailable []int
+		wantActive    int
+	}{
+		{name: "reserves every line", quantities: []int{4, 10}, wantStatus: model.StatusAllocated, wantAvailable: []int{6, 0}, wantActive: 2},
+		{name: "releases earlier lines when one is short", quantities: []int{4, 11}, wantErr: ErrInsufficientStock, wantStatus: model.StatusConfirmed, wantAvailable: []int{10, 10}},
+		{name: "reserves nothing when the first line is short", quantities: []int{11, 4}, wantErr: ErrInsufficientStock, wantStatus: model.StatusConfirmed, wantAvailable: []int{10, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			order := shop.draft(t, tt.quantities...)
+			if _, err := shop.service.ConfirmOrder(ctx, order.ID.Hex()); err != nil {
+				t.Fatalf("ConfirmOrder: %v", err)
+			}
+
+			_, err := shop.service.AllocateOrder(ctx, order.ID.Hex())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AllocateOrder error = %v, want %v", err, tt.wantErr)
+			}
+			if got, err := shop.service.GetOrderByID(ctx, order.ID.Hex()); err != nil || got.Status != tt.wantStatus {
+				t.Errorf("order = %+v, %v, want status %q", got, err, tt.wantStatus)
+			}
+			if available := shop.available(); !slices.Equal(available, tt.wantAvailable) {
+				t.Errorf("available = %v, want %v", available, tt.wantAvailable)
+			}
+			if active := shop.reservations(t, order, client.ReservationActive); len(active) != tt.wantActive {
+				t.Errorf("%d active reservations, want %d", len(active), tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestAllocateOrderInOneWarehouse(t *testing.T) {
+	north, south := primitive.NewObjectID(), primitive.NewObjectID()
+	tests := []struct {
+		name       string
+		north      []int // Units of each commodity available in the north warehouse
+		south      []int
+		quantities []int
+		wantErr    error
+		wantNorth  []int
+		wantSouth  []int
+	}{
+		{name: "first warehouse holds every line", north: []int{10, 10}, south: []int{10, 10}, quantities: []int{4, 3}, wantNorth: []int{6, 7}, wantSouth: []int{10, 10}},
+		{name: "moves to the warehouse holding a later line", north: []int{10, 2}, south: []int{5, 10}, quantities: []int{4, 3}, wantNorth: []int{10, 2}, wantSouth: []int{1, 7}},
+		{name: "no warehouse holds every line", north: []int{10, 0}, south: []int{0, 10}, quantities: []int{4, 3}, wantErr: ErrInsufficientStock, wantNorth: []int{10, 0}, wantSouth: []int{0, 10}},
+		{name: "a line split between warehouses", north: []int{6, 0}, south: []int{6, 0}, quantities: []int{10}, wantErr: ErrInsufficientStock, wantNorth: []int{6, 0}, wantSouth: []int{6, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			for i, commodity := range shop.commodities {
+				shop.inventory.SetAvailable(commodity.ID, 0)
+				shop.inventory.SetAvailableIn(north, commodity.ID, tt.north[i])
+				shop.inventory.SetAvailableIn(south, commodity.ID, tt.south[i])
+			}
+			order := shop.draft(t, tt.quantities...)
+			if _, err := shop.service.ConfirmOrder(ctx, order.ID.Hex()); err != nil {
+				t.Fatalf("ConfirmOrder: %v", err)
+			}
+
+			if _, err := shop.service.AllocateOrder(ctx, order.ID.Hex()); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AllocateOrder error = %v, want %v", err, tt.wantErr)
+			}
+			for _, warehouse := range []struct {
+				id   primitive.ObjectID
+				want []int
+			}{{north, tt.wantNorth}, {south, tt.wantSouth}} {
+				var available []int
+				for _, commodity := range shop.commodities {
+					available = append(available, shop.inventory.AvailableIn(warehouse.id, commodity.ID))
+				}
+				if !slices.Equal(available, warehouse.want) {
+					t.Errorf("available in %s = %v, want %v", warehouse.id.Hex(), available, warehouse.want)
+				}
+			}
+			if active := shop.reservations(t, order, client.ReservationActive); tt.wantErr != nil && len(active) != 0 {
+				t.Errorf("%d reservations left active, want none", len(active))
+			}
+		})
+	}
+}
+
+func TestPickReservesLapsedLinesInTheOrdersWarehouse(t *testing.T) {
+	ctx := context.Background()
+	north, south := primitive.NewObjectID(), primitive.NewObjectID()
+	shop := newTestShop()
+	for _, commodity := range shop.commodities {
+		shop.inventory.SetAvailable(commodity.ID, 0)
+		shop.inventory.SetAvailableIn(north, commodity.ID, 5)
+	}
+	order := shop.draft(t, 4, 3)
+	for _, next := range []step{confirm, allocate} {
+		if _, err := next(shop.service, ctx, order.ID.Hex()); err != nil {
+			t.Fatalf("preparing the order: %v", err)
+		}
+	}
+	// The first line's reservation lapses and its units go elsewhere, leaving stock only in the south.
+	for _, reservation := range shop.reservations(t, order, client.ReservationActive) {
+		if reservation.ProductID == shop.commodities[0].ID {
+			if err := shop.inventory.Expire(reservation.ID); err != nil {
+				t.Fatalf("Expire: %v", err)
+			}
+		}
+	}
+	shop.inventory.SetAvailableIn(north, shop.commodities[0].ID, 0)
+	shop.inventory.SetAvailableIn(south, shop.commodities[0].ID, 10)
+
+	if _, err := shop.service.PickOrder(ctx, order.ID.Hex()); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("PickOrder error = %v, want %v", err, ErrInsufficientStock)
+	}
+	if available := shop.inventory.AvailableIn(south, shop.commodities[0].ID); available != 10 {
+		t.Errorf("available in the south = %d, want 10", available)
+	}
+
+	shop.inventory.SetAvailableIn(north, shop.commodities[0].ID, 4)
+	if _, err := shop.service.PickOrder(ctx, order.ID.Hex()); err != nil {
+		t.Fatalf("PickOrder: %v", err)
+	}
+	committed := shop.reservations(t, order, client.ReservationCommitted)
+	if len(committed) != 2 {
+		t.Errorf("%d reservations committed, want 2", len(committed))
+	}
+	for _, reservation := range committed {
+		if reservation.WarehouseID != north {
+			t.Errorf("reservation %+v committed outside the north warehouse", reservation)
+		}
+	}
+}
+
+func TestSettleAllocatedOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		settle        step
+		wantStatus    string
+		wantAvailable []int
+	}{
+		{name: "pick commits the reservations", settle: pick, wantStatus: "committed", wantAvailable: []int{6, 7}},
+		{name: "cancel releases the reservations", settle: cancel, wantStatus: "released", wantAvailable: []int{10, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			order := shop.draft(t, 4, 3)
+			for _, next := range []step{confirm, allocate} {
+				if _, err := next(shop.service, ctx, order.ID.Hex()); err != nil {
+					t.Fatalf("preparing the order: %v", err)
+				}
+			}
+			reservations := shop.reservations(t, order, client.ReservationActive)
+
+			if _, err := tt.settle(shop.service, ctx, order.ID.Hex()); err != nil {
+				t.Fatalf("settling the order: %v", err)
+			}
+			for _, reservation := range reservations {
+				if settled, _ := shop.inventory.Reservation(reservation.ID); settled.Status != tt.wantStatus {
+					t.Errorf("reservation %s is %q, want %q", reservation.ID.Hex(), settled.Status, tt.wantStatus)
+				}
+			}
+			if available := shop.available(); !slices.Equal(available, tt.wantAvailable) {
+				t.Errorf("available = %v, want %v", available, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+// racingOrders is an OrderRepository that runs meanwhile once, right after the first order is
+// read, as if another request changed the order while this one was working on it.
+type racingOrders struct {
+	repository.OrderRepository
+	meanwhile func()
+}
+
+func (r *racingOrders) GetOrderByID(ctx context.Context, id primitive.ObjectID) (*model.Order, error) {
+	order, err := r.OrderRepository.GetOrderByID(ctx, id)
+	if r.meanwhile != nil {
+		meanwhile := r.meanwhile
+		r.meanwhile = nil
+		meanwhile()
+	}
+	return order, err
+}
+
+func TestOrderChangedMeanwhile(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       []step
+		race          step
+		meanwhile     step
+		wantStatus    model.OrderStatus
+		wantAvailable []int
+	}{
+		{
+			name:          "allocation of a cancelled order is given back",
+			prepare:       []step{confirm},
+			race:          allocate,
+			meanwhile:     cancel,
+			wantStatus:    model.StatusCancelled,
+			wantAvailable: []int{10, 10},
+		},
+		{
+			name:          "allocation runs once",
+			prepare:       []step{confirm},
+			race:          allocate,
+			meanwhile:     allocate,
+			wantStatus:    model.StatusAllocated,
+			wantAvailable: []int{6, 7},
+		},
+		{
+			name:          "a cancelled order is not picked",
+			prepare:       []step{confirm, allocate},
+			race:          pick,
+			meanwhile:     cancel,
+			wantStatus:    model.StatusCancelled,
+			wantAvailable: []int{10, 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			order := shop.draft(t, 4, 3)
+			for _, next := range tt.prepare {
+				if _, err := next(shop.service, ctx, order.ID.Hex()); err != nil {
+					t.Fatalf("preparing the order: %v", err)
+				}
+			}
+			racing := &racingOrders{OrderRepository: shop.orders}
+			racing.meanwhile = func() {
+				if _, err := tt.meanwhile(shop.service, ctx, order.ID.Hex()); err != nil {
+					t.Errorf("concurrent change: %v", err)
+				}
+			}
+
+			if _, err := tt.race(shop.serviceOver(racing), ctx, order.ID.Hex()); !errors.Is(err, repository.ErrOrderChanged) {
+				t.Fatalf("error = %v, want %v", err, repository.ErrOrderChanged)
+			}
+			if got, err := shop.service.GetOrderByID(ctx, order.ID.Hex()); err != nil || got.Status != tt.wantStatus {
+				t.Errorf("order = %+v, %v, want status %q", got, err, tt.wantStatus)
+			}
+			if available := shop.available(); !slices.Equal(available, tt.wantAvailable) {
+				t.Errorf("available = %v, want %v", available, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestPickAfterReservationsLapse(t *testing.T) {
+	tests := []struct {
+		name          string
+		lapse         func(shop *testShop, reservation client.Reservation) error
+		leftInStock   int // Units of the first commodity available to others once its reservation lapsed
+		wantErr       error
+		wantStatus    model.OrderStatus
+		wantAvailable []int
+	}{
+		{
+			name: "expired reservation is made again",
+			lapse: func(shop *testShop, reservation client.Reservation) error {
+				return shop.inventory.Expire(reservation.ID)
+			},
+			leftInStock:   10,
+			wantStatus:    model.StatusPicked,
+			wantAvailable: []int{6, 7},
+		},
+		{
+			name: "released reservation is made again",
+			lapse: func(shop *testShop, reservation client.Reservation) error {
+				return shop.inventory.ReleaseReservation(context.Background(), reservation.ID, "someone")
+			},
+			leftInStock:   10,
+			wantStatus:    model.StatusPicked,
+			wantAvailable: []int{6, 7},
+		},
+		{
+			name: "stock taken meanwhile",
+			lapse: func(shop *testShop, reservation client.Reservation) error {
+				return shop.inventory.Expire(reservation.ID)
+			},
+			leftInStock:   3,
+			wantErr:       ErrInsufficientStock,
+			wantStatus:    model.StatusAllocated,
+			wantAvailable: []int{3, 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			order := shop.draft(t, 4, 3)
+			for _, next := range []step{confirm, allocate} {
+				if _, err := next(shop.service, ctx, order.ID.Hex()); err != nil {
+					t.Fatalf("preparing the order: %v", err)
+				}
+			}
+			for _, reservation := range shop.reservations(t, order, client.ReservationActive) {
+				if reservation.ProductID == shop.commodities[0].ID {
+					if err := tt.lapse(shop, reservation); err != nil {
+						t.Fatalf("lapsing the reservation: %v", err)
+					}
+				}
+			}
+			shop.inventory.SetAvailable(shop.commodities[0].ID, tt.leftInStock)
+
+			_, err := shop.service.PickOrder(ctx, order.ID.Hex())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PickOrder error = %v, want %v", err, tt.wantErr)
+			}
+			got, err := shop.service.GetOrderByID(ctx, order.ID.Hex())
+			if err != nil {
+				t.Fatalf("GetOrderByID: %v", err)
+			}
+			if got.Status != tt.wantStatus || got.SettlementPending {
+				t.Errorf("order is %q with SettlementPending %v, want %q and settled", got.Status, got.SettlementPending, tt.wantStatus)
+			}
+			if available := shop.available(); !slices.Equal(available, tt.wantAvailable) {
+				t.Errorf("available = %v, want %v", available, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+// flakyInventory is an InventoryClient whose commits and releases fail once the first
+// succeeded, until healed.
+type flakyInventory struct {
+	*client.InMemoryInventoryClient
+	settled int
+	healed  bool
+}
+
+func (f *flakyInventory) CommitReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.InMemoryInventoryClient.CommitReservation(ctx, id, actor)
+}
+
+func (f *flakyInventory) ReleaseReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	if err := f.fail(); err != nil {
+		return err
+	}
+	return f.InMemoryInventoryClient.ReleaseReservation(ctx, id, actor)
+}
+
+func (f *flakyInventory) fail() error {
+	f.settled++
+	if !f.healed && f.settled > 1 {
+		return errors.New("inventory service unavailable")
+	}
+	return nil
+}
+
+func TestSettlementFailingPartWay(t *testing.T) {
+	tests := []struct {
+		name          string
+		settle        step
+		wantStatus    model.OrderStatus
+		wantAvailable []int
+	}{
+		{name: "pick", settle: pick, wantStatus: model.StatusPicked, wantAvailable: []int{6, 7}},
+		{name: "cancel", settle: cancel, wantStatus: model.StatusCancelled, wantAvailable: []int{10, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			shop := newTestShop()
+			flaky := &flakyInventory{InMemoryInventoryClient: shop.inventory}
+			service := NewOrderServiceWithDependencies(Dependencies{
+				Orders:      shop.orders,
+				Customers:   client.NewInMemoryCustomerClient(shop.customer),
+				Commodities: client.NewInMemoryCommodityClient(shop.commodities...),
+				Inventory:   flaky,
+			})
+			order := shop.draft(t, 4, 3)
+			for _, next := range []step{confirm, allocate} {
+				if _, err := next(service, ctx, order.ID.Hex()); err != nil {
+					t.Fatalf("preparing the order: %v", err)
+				}
+			}
+
+			if _, err := tt.settle(service, ctx, order.ID.Hex()); err == nil {
+				t.Fatal("settling succeeded, want the second reservation to fail")
+			}
+			got, err := service.GetOrderByID(ctx, order.ID.Hex())
+			if err != nil {
+				t.Fatalf("GetOrderByID: %v", err)
+			}
+			if got.Status != tt.wantStatus || !got.SettlementPending {
+				t.Fatalf("order is %q with SettlementPending %v, want %q and pending", got.Status, got.SettlementPending, tt.wantStatus)
+			}
+			if tt.wantStatus == model.StatusPicked {
+				if _, err := service.ShipOrder(ctx, order.ID.Hex()); !errors.Is(err, ErrSettlementPending) {
+					t.Errorf("ShipOrder error = %v, want %v", err, ErrSettlementPending)
+				}
+			}
+
+			flaky.healed = true
+			settled, err := tt.settle(service, ctx, order.ID.Hex())
+			if err != nil {
+				t.Fatalf("repeating the step: %v", err)
+			}
+			if settled.Status != tt.wantStatus || settled.SettlementPending {
+				t.Errorf("order is %q with SettlementPending %v, want %q and settled", settled.Status, settled.SettlementPending, tt.wantStatus)
+			}
+			if available := shop.available(); !slices.Equal(available, tt.wantAvailable) {
+				t.Errorf("available = %v, want %v", available, tt.wantAvailable)
+			}
+			if active := shop.reservations(t, order, client.ReservationActive); len(active) != 0 {
+				t.Errorf("%d reservations still active, want none", len(active))
+			}
+			if _, err := tt.settle(service, ctx, order.ID.Hex()); !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("repeating a settled step error = %v, want %v", err, ErrInvalidTransition)
+			}
+		})
+	}
+}
+
+// unreleasingInventory is an InventoryClient whose releases always fail.
+type unreleasingInventory struct {
+	*client.InMemoryInventoryClient
+}
+
+func (unreleasingInventory) ReleaseReservation(ctx context.Context, id primitive.ObjectID, actor string) error {
+	return errors.New("inventory service unavailable")
+}
+
+func TestPickLeavesOrphanedReservationsBehind(t *testing.T) {
+	ctx := context.Background()
+	shop := newTestShop()
+	order := shop.draft(t, 4, 3)
+	if _, err := shop.service.ConfirmOrder(ctx, order.ID.Hex()); err != nil {
+		t.Fatalf("ConfirmOrder: %v", err)
+	}
+
+	// The allocation that loses the race cannot give its reservations back, so they stay active.
+	racing := &racingOrders{OrderRepository: shop.orders}
+	racing.meanwhile = func() {
+		if _, err := shop.service.AllocateOrder(ctx, order.ID.Hex()); err != nil {
+			t.Errorf("concurrent allocation: %v", err)
+		}
+	}
+	loser := NewOrderServiceWithDependencies(Dependencies{
+		Orders:      racing,
+		Customers:   client.NewInMemoryCustomerClient(shop.customer),
+		Commodities: client.NewInMemoryCommodityClient(shop.commodities...),
+		Inventory:   unreleasingInventory{shop.inventory},
+	})
+	if _, err := loser.AllocateOrder(ctx, order.ID.Hex()); !errors.Is(err, repository.ErrOrderChanged) {
+		t.Fatalf("AllocateOrder error = %v, want %v", err, repository.ErrOrderChanged)
+	}
+	if active := shop.reservations(t, order, client.ReservationActive); len(active) != 4 {
+		t.Fatalf("%d reservations active, want both allocations' 4", len(active))
+	}
+
+	if _, err := shop.service.PickOrder(ctx, order.ID.Hex()); err != nil {
+		t.Fatalf("PickOrder: %v", err)
+	}
+	if available := shop.available(); !slices.Equal(available, []int{6, 7}) {
+		t.Errorf("available = %v, want [6 7]", available)
+	}
+	committed := make([]int, len(shop.commodities))
+	for _, reservation := range shop.reservations(t, order, client.ReservationCommitted) {
+		for i, commodity := range shop.commodities {
+			if reservation.ProductID == commodity.ID {
+				committed[i] += reservation.Quantity
+			}
+		}
+	}
+	if !slices.Equal(committed, []int{4, 3}) {
+		t.Errorf("committed = %v, want [4 3]", committed)
+	}
+	if active := shop.reservations(t, order, client.ReservationActive); len(active) != 0 {
+		t.Errorf("%d reservations still active, want none", len(active))
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"Warehouse-Services/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/pagination"
+	"wms-common/tracing"
+)
+
+// ErrCustomersUnavailable is returned when the Customer service cannot be reached or fails.
+var ErrCustomersUnavailable = apperrors.Unavailable("customer service is unavailable")
+
+// CustomerRecord is a customer as returned by the Customer service.
+type CustomerRecord struct {
+	ID           string   `json:"id"`
+	FirstName    string   `json:"firstName"`
+	LastName     string   `json:"lastName"`
+	Email        string   `json:"email"`
+	Phone        string   `json:"phone"`
+	Address      string   `json:"address"`
+	WarehouseIDs []string `json:"warehouseIds"`
+}
+
+// CustomerClient queries customers held by the Customer service.
+type CustomerClient interface {
+	// GetCustomersByWarehouse returns one page of the customers linked to a warehouse. page carries the
+	// limit, after and sort parameters to forward; the next-page cursor is returned alongside.
+	GetCustomersByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]CustomerRecord, string, error)
+}
+
+// httpCustomerClient implements CustomerClient over the Customer service's REST API.
+type httpCustomerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCustomerClient creates a CustomerClient that calls the Customer service at config.Cfg.CustomerServiceURL.
+func NewCustomerClient() CustomerClient {
+	return &httpCustomerClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.CustomerServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpCustomerClient) GetCustomersByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]CustomerRecord, string, error) {
+	query := url.Values{}
+	for key, values := range page {
+		query[key] = values
+	}
+	query.Set("warehouseId", warehouseID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/customers?%s", c.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build customer request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		logging.Logger(ctx).Warn("failed to reach customer service", "error", err)
+		return nil, "", ErrCustomersUnavailable
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return nil, "", fmt.Errorf("%w: rejected by customer service", pagination.ErrInvalidQuery)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, "", fmt.Errorf("%w: it returned status %d", ErrCustomersUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("customer service returned status %d", resp.StatusCode)
+	}
+	customers := []CustomerRecord{}
+	if err := json.NewDecoder(resp.Body).Decode(&customers); err != nil {
+		return nil, "", fmt.Errorf("failed to decode customer response: %w", err)
+	}
+	return customers, resp.Header.Get(pagination.NextCursorHeader), nil
+}
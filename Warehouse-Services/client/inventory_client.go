@@ -0,0 +1,135 @@
+package client
+
+import (
+	"Warehouse-Services/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"wms-common/apperrors"
+	"wms-common/logging"
+	"wms-common/pagination"
+	"wms-common/tracing"
+)
+
+var (
+	// ErrInventoryUnavailable is returned when the Inventory service cannot be reached or fails.
+	ErrInventoryUnavailable = apperrors.Unavailable("inventory service is unavailable")
+	// ErrUnknownToInventory is returned when the Inventory service does not know the warehouse.
+	ErrUnknownToInventory = apperrors.NotFound("warehouse not found in the inventory service")
+)
+
+// InventoryRecord is a stock record as returned by the Inventory service.
+type InventoryRecord struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"productId"`
+	WarehouseID string    `json:"warehouseId"`
+	Quantity    int       `json:"quantity"`
+	Location    string    `json:"location"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// Utilization reports how much of a warehouse's storage capacity is in use, as computed by the Inventory service.
+type Utilization struct {
+	WarehouseID string  `json:"warehouseId"`
+	Capacity    int     `json:"capacity"`
+	Used        int     `json:"used"`
+	Free        int     `json:"free"`
+	FillPercent float64 `json:"fillPercent"`
+}
+
+// InventoryClient queries stock held by the Inventory service.
+type InventoryClient interface {
+	// GetInventoriesByWarehouse returns one page of a warehouse's stock. page carries the
+	// limit, after and sort parameters to forward; the next-page cursor is returned alongside.
+	GetInventoriesByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]InventoryRecord, string, error)
+	GetUtilization(ctx context.Context, warehouseID string) (*Utilization, error)
+}
+
+// httpInventoryClient implements InventoryClient over the Inventory service's REST API.
+type httpInventoryClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewInventoryClient creates an InventoryClient that calls the Inventory service at config.Cfg.InventoryServiceURL.
+func NewInventoryClient() InventoryClient {
+	return &httpInventoryClient{
+		baseURL:    strings.TrimSuffix(config.Cfg.InventoryServiceURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second, Transport: tracing.Transport(logging.Transport(http.DefaultTransport))},
+	}
+}
+
+func (c *httpInventoryClient) GetInventoriesByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]InventoryRecord, string, error) {
+	query := url.Values{}
+	for key, values := range page {
+		query[key] = values
+	}
+	query.Set("warehouseId", warehouseID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/inventory?%s", c.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build inventory request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", unreachable(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, "", fmt.Errorf("%w: rejected by inventory service", pagination.ErrInvalidQuery)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", statusError(resp.StatusCode)
+	}
+	records := []InventoryRecord{}
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, "", fmt.Errorf("failed to decode inventory response: %w", err)
+	}
+	return records, resp.Header.Get(pagination.NextCursorHeader), nil
+}
+
+func (c *httpInventoryClient) GetUtilization(ctx context.Context, warehouseID string) (*Utilization, error) {
+	endpoint := fmt.Sprintf("%s/inventory/warehouses/%s/utilization", c.baseURL, url.PathEscape(warehouseID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build utilization request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, unreachable(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp.StatusCode)
+	}
+	var utilization Utilization
+	if err := json.NewDecoder(resp.Body).Decode(&utilization); err != nil {
+		return nil, fmt.Errorf("failed to decode utilization response: %w", err)
+	}
+	return &utilization, nil
+}
+
+// unreachable logs why the Inventory service could not be reached and returns
+// ErrInventoryUnavailable, whose message names no hosts.
+func unreachable(ctx context.Context, err error) error {
+	logging.Logger(ctx).Warn("failed to reach inventory service", "error", err)
+	return ErrInventoryUnavailable
+}
+
+// statusError maps a status the Inventory service answered with to an error.
+func statusError(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrUnknownToInventory
+	case status >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: it returned status %d", ErrInventoryUnavailable, status)
+	}
+	return fmt.Errorf("inventory service returned status %d", status)
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// InMemoryCustomerClient is a CustomerClient serving customers from a slice instead of the
+// Customer service. Pages honour limit.
+type InMemoryCustomerClient struct {
+	mu        sync.Mutex
+	customers []CustomerRecord
+}
+
+// NewInMemoryCustomerClient creates an InMemoryCustomerClient holding customers.
+func NewInMemoryCustomerClient(customers ...CustomerRecord) *InMemoryCustomerClient {
+	return &InMemoryCustomerClient{customers: customers}
+}
+
+func (c *InMemoryCustomerClient) GetCustomersByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]CustomerRecord, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	customers := []CustomerRecord{}
+	for _, customer := range c.customers {
+		if slices.Contains(customer.WarehouseIDs, warehouseID) {
+			customers = append(customers, customer)
+		}
+	}
+	if limit, err := strconv.Atoi(page.Get("limit")); err == nil && limit < len(customers) {
+		customers = customers[:limit]
+	}
+	return customers, "", nil
+}
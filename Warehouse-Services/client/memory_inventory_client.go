@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// InMemoryInventoryClient is an InventoryClient serving stock records and utilization from maps
+// instead of the Inventory service. Pages honour limit and sort by quantity; every page query
+// is kept so callers can see what was asked for.
+type InMemoryInventoryClient struct {
+	mu          sync.Mutex
+	records     map[string][]InventoryRecord // By warehouse ID
+	utilization map[string]Utilization       // By warehouse ID
+	queries     []url.Values
+}
+
+// NewInMemoryInventoryClient creates an InMemoryInventoryClient holding records.
+func NewInMemoryInventoryClient(records ...InventoryRecord) *InMemoryInventoryClient {
+	c := &InMemoryInventoryClient{records: make(map[string][]InventoryRecord), utilization: make(map[string]Utilization)}
+	for _, record := range records {
+		c.records[record.WarehouseID] = append(c.records[record.WarehouseID], record)
+	}
+	return c
+}
+
+// SetUtilization sets what GetUtilization reports for a warehouse.
+func (c *InMemoryInventoryClient) SetUtilization(utilization Utilization) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.utilization[utilization.WarehouseID] = utilization
+}
+
+// Queries returns the page queries GetInventoriesByWarehouse was sent, oldest first.
+func (c *InMemoryInventoryClient) Queries() []url.Values {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.queries)
+}
+
+func (c *InMemoryInventoryClient) GetInventoriesByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]InventoryRecord, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, page)
+
+	records := slices.Clone(c.records[warehouseID])
+	switch page.Get("sort") {
+	case "quantity":
+		slices.SortStableFunc(records, func(a, b InventoryRecord) int { return a.Quantity - b.Quantity })
+	case "-quantity":
+		slices.SortStableFunc(records, func(a, b InventoryRecord) int { return b.Quantity - a.Quantity })
+	}
+	if limit, err := strconv.Atoi(page.Get("limit")); err == nil && limit < len(records) {
+		records = records[:limit]
+	}
+	if records == nil {
+		records = []InventoryRecord{}
+	}
+	return records, "", nil
+}
+
+func (c *InMemoryInventoryClient) GetUtilization(ctx context.Context, warehouseID string) (*Utilization, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	utilization, ok := c.utilization[warehouseID]
+	if !ok {
+		return nil, ErrUnknownToInventory
+	}
+	return &utilization, nil
+}
@@ -12,8 +12,22 @@ type Config struct { // Ensure struct is named Config
 	GinMode      string `json:"gin_mode"` // This field must exist
 	MongoDBURI   string `json:"mongodb_uri"`
 	DatabaseName string `json:"database_name"`
+
+	// Where repositories keep their data: StorageBackendMongo (default) or StorageBackendMemory
+	StorageBackend string `json:"storage_backend"`
+
+	// Base URL of the Inventory service, queried for stock held in a warehouse
+	InventoryServiceURL string `json:"inventory_service_url"`
+	// Base URL of the Customer service, queried for customers linked to a warehouse
+	CustomerServiceURL string `json:"customer_service_url"`
 }
 
+// Storage backends selectable through STORAGE_BACKEND.
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory" // Data lives in process memory and is lost on restart
+)
+
 // Cfg is the global configuration instance.
 var Cfg *Config
 
@@ -24,6 +38,11 @@ func LoadConfig() error {
 		GinMode:      "debug",                     // Default value
 		MongoDBURI:   "mongodb://localhost:27017", // For individual testing outside Docker
 		DatabaseName: "wms_warehouse_db",
+
+		StorageBackend: StorageBackendMongo,
+
+		InventoryServiceURL: "http://inventory-service:8088",
+		CustomerServiceURL:  "http://customer-service:8087",
 	}
 
 	if portStr := os.Getenv("PORT"); portStr != "" {
@@ -41,8 +60,27 @@ func LoadConfig() error {
 		Cfg.DatabaseName = dbName
 	}
 
-	fmt.Printf("Warehouse Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s\n",
-		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName)
+	if inventoryURL := os.Getenv("INVENTORY_SERVICE_URL"); inventoryURL != "" {
+		Cfg.InventoryServiceURL = inventoryURL
+	}
+	if customerURL := os.Getenv("CUSTOMER_SERVICE_URL"); customerURL != "" {
+		Cfg.CustomerServiceURL = customerURL
+	}
+
+	if storage := os.Getenv("STORAGE_BACKEND"); storage != "" {
+		Cfg.StorageBackend = storage
+	}
+	if Cfg.StorageBackend != StorageBackendMongo && Cfg.StorageBackend != StorageBackendMemory {
+		return fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", Cfg.StorageBackend, StorageBackendMongo, StorageBackendMemory)
+	}
+
+	fmt.Printf("Warehouse Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s, InventoryServiceURL=%s, CustomerServiceURL=%s, StorageBackend=%s\n",
+		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName, Cfg.InventoryServiceURL, Cfg.CustomerServiceURL, Cfg.StorageBackend)
 
 	return nil
 }
+
+// UseMemoryStorage reports whether repositories keep their data in process memory instead of MongoDB.
+func (c *Config) UseMemoryStorage() bool {
+	return c.StorageBackend == StorageBackendMemory
+}
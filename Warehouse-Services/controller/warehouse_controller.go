@@ -2,10 +2,14 @@ package controller
 
 import (
 	"Warehouse-Services/model"
+	"Warehouse-Services/repository"
 	"Warehouse-Services/service"
 	"context"
 	"net/http"
+	"net/url"
 	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +28,7 @@ func NewWarehouseController(s service.WarehouseService) *WarehouseController {
 func (c *WarehouseController) CreateWarehouse(ctx *gin.Context) {
 	var warehouse model.Warehouse
 	if err := ctx.ShouldBindJSON(&warehouse); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -33,22 +37,32 @@ func (c *WarehouseController) CreateWarehouse(ctx *gin.Context) {
 
 	createdWarehouse, err := c.warehouseService.CreateWarehouse(timeoutCtx, &warehouse)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, createdWarehouse)
 }
 
 // GetAllWarehouses handles GET /warehouses requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.WarehouseListSpec.
 func (c *WarehouseController) GetAllWarehouses(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.WarehouseListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	warehouses, err := c.warehouseService.GetAllWarehouses(timeoutCtx)
+	warehouses, nextCursor, err := c.warehouseService.GetAllWarehouses(timeoutCtx, params)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
 	ctx.JSON(http.StatusOK, warehouses)
 }
 
@@ -61,11 +75,7 @@ func (c *WarehouseController) GetWarehouseByID(ctx *gin.Context) {
 
 	warehouse, err := c.warehouseService.GetWarehouseByID(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "warehouse not found" || err.Error() == "invalid warehouse ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, warehouse)
@@ -76,7 +86,7 @@ func (c *WarehouseController) UpdateWarehouse(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var warehouse model.Warehouse
 	if err := ctx.ShouldBindJSON(&warehouse); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -85,11 +95,7 @@ func (c *WarehouseController) UpdateWarehouse(ctx *gin.Context) {
 
 	updatedWarehouse, err := c.warehouseService.UpdateWarehouse(timeoutCtx, id, &warehouse)
 	if err != nil {
-		if err.Error() == "warehouse not found" || err.Error() == "invalid warehouse ID format" || err.Error() == "warehouse not found or no changes made" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, updatedWarehouse)
@@ -104,12 +110,73 @@ func (c *WarehouseController) DeleteWarehouse(ctx *gin.Context) {
 
 	err := c.warehouseService.DeleteWarehouse(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "warehouse not found" || err.Error() == "invalid warehouse ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+// GetWarehouseInventory handles GET /warehouses/:id/inventory requests.
+// The ?limit=, ?after= and ?sort= parameters are passed through to the Inventory service.
+func (c *WarehouseController) GetWarehouseInventory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	page := url.Values{}
+	for _, key := range []string{"limit", "after", "sort"} {
+		if value := ctx.Query(key); value != "" {
+			page.Set(key, value)
+		}
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	inventories, nextCursor, err := c.warehouseService.GetWarehouseInventory(timeoutCtx, id, page)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, inventories)
+}
+
+// GetWarehouseUtilization handles GET /warehouses/:id/utilization requests.
+func (c *WarehouseController) GetWarehouseUtilization(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	utilization, err := c.warehouseService.GetWarehouseUtilization(timeoutCtx, id)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, utilization)
+}
+
+// GetWarehouseCustomers handles GET /warehouses/:id/customers requests.
+// The ?limit=, ?after= and ?sort= parameters are passed through to the Customer service.
+func (c *WarehouseController) GetWarehouseCustomers(ctx *gin.Context) {
+	id := ctx.Param("id")
+	page := url.Values{}
+	for _, key := range []string{"limit", "after", "sort"} {
+		if value := ctx.Query(key); value != "" {
+			page.Set(key, value)
+		}
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	customers, nextCursor, err := c.warehouseService.GetWarehouseCustomers(timeoutCtx, id, page)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
+	ctx.JSON(http.StatusOK, customers)
+}
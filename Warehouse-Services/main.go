@@ -12,43 +12,87 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	"wms-common/health"
+	"wms-common/logging"
+	"wms-common/metrics"
+	"wms-common/pagination"
+	"wms-common/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// serviceName identifies this service in health reports, traces and logs.
+const serviceName = "warehouse-service"
+
 func main() {
+	if err := logging.Setup(serviceName); err != nil {
+		log.Fatalf("Error setting up logging: %v", err)
+	}
+
 	err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	client, err := database.ConnectDB() // ConnectDB should take no arguments here
+	tracingConfig, err := tracing.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Error loading tracing config: %v", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), serviceName, tracingConfig)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		log.Fatalf("Failed to set up tracing: %v", err)
 	}
 	defer func() {
-		if err = client.Disconnect(context.Background()); err != nil {
-			log.Fatalf("Error disconnecting from MongoDB: %v", err)
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Error flushing traces: %v", err)
 		}
 	}()
 
+	// With STORAGE_BACKEND=memory the repositories never touch MongoDB, so no connection is made.
+	if config.Cfg.UseMemoryStorage() {
+		log.Println("Storage backend is memory: data will not survive a restart")
+	} else {
+		client, err := database.ConnectDB() // ConnectDB should take no arguments here
+		if err != nil {
+			log.Fatalf("Failed to connect to MongoDB: %v", err)
+		}
+		defer func() {
+			if err = client.Disconnect(context.Background()); err != nil {
+				log.Fatalf("Error disconnecting from MongoDB: %v", err)
+			}
+		}()
+	}
+
 	gin.SetMode(config.Cfg.GinMode)
-	router := gin.Default()
+	router := gin.New()
+
+	// A span per request, continuing the trace started by the gateway or another service
+	router.Use(tracing.Middleware(serviceName))
+	// One JSON log line per request, tagged with the X-Request-ID the gateway forwarded
+	router.Use(logging.Middleware("/healthz", "/readyz", "/metrics"), logging.Recovery())
 
 	// --- CORS Configuration for Warehouse Service ---
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000", "http://localhost:8080"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", pagination.NextCursorHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Request counts and latencies per route, scraped from /metrics
+	router.Use(metrics.Middleware())
+
 	// Register warehouse-specific routes
 	routes.WarehouseRoutes(router) // Correct function name
 
+	// Liveness and readiness probes for compose and orchestrators
+	router.GET("/healthz", gin.WrapF(health.Liveness(serviceName)))
+	router.GET("/readyz", gin.WrapF(health.Readiness(serviceName, readinessChecks()...)))
+	router.GET("/metrics", metrics.Handler())
+
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.Cfg.Port), // Use config.Cfg.Port
 		Handler:      router,
@@ -78,3 +122,15 @@ func main() {
 	}
 	log.Println("Warehouse Service (Warehouse-Services) stopped.")
 }
+
+// readinessChecks lists what must be reachable before the service takes traffic.
+func readinessChecks() []health.Check {
+	checks := []health.Check{
+		health.ServiceCheck("customer-service", config.Cfg.CustomerServiceURL),
+		health.ServiceCheck("inventory-service", config.Cfg.InventoryServiceURL),
+	}
+	if !config.Cfg.UseMemoryStorage() {
+		checks = append(checks, health.MongoCheck(database.Client))
+	}
+	return checks
+}
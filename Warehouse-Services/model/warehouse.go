@@ -7,5 +7,5 @@ type Warehouse struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Name     string             `bson:"name" json:"name"`
 	Location string             `bson:"location" json:"location"`
-	Storage  int                `bson:"storage" json:"storage"` // Capacity in some unit
+	Storage  int                `bson:"storage" json:"storage"` // Capacity in storage units; commodities declare their UnitVolume in the same unit
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"Warehouse-Services/model"
+	"context"
+	"sync"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryWarehouseRepository is a WarehouseRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryWarehouseRepository struct {
+	mu         sync.RWMutex
+	warehouses map[primitive.ObjectID]model.Warehouse
+}
+
+// NewInMemoryWarehouseRepository creates an empty InMemoryWarehouseRepository.
+func NewInMemoryWarehouseRepository() *InMemoryWarehouseRepository {
+	return &InMemoryWarehouseRepository{warehouses: map[primitive.ObjectID]model.Warehouse{}}
+}
+
+func (r *InMemoryWarehouseRepository) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) (*model.Warehouse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if warehouse.ID.IsZero() {
+		warehouse.ID = primitive.NewObjectID()
+	}
+	r.warehouses[warehouse.ID] = *warehouse
+	return warehouse, nil
+}
+
+func (r *InMemoryWarehouseRepository) GetAllWarehouses(ctx context.Context, params *pagination.Params) ([]model.Warehouse, string, error) {
+	r.mu.RLock()
+	warehouses := make([]model.Warehouse, 0, len(r.warehouses))
+	for _, warehouse := range r.warehouses {
+		warehouses = append(warehouses, warehouse)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(warehouses, params)
+}
+
+func (r *InMemoryWarehouseRepository) GetWarehouseByID(ctx context.Context, id primitive.ObjectID) (*model.Warehouse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	warehouse, ok := r.warehouses[id]
+	if !ok {
+		return nil, ErrWarehouseNotFound
+	}
+	return &warehouse, nil
+}
+
+func (r *InMemoryWarehouseRepository) UpdateWarehouse(ctx context.Context, id primitive.ObjectID, warehouse *model.Warehouse) (*model.Warehouse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.warehouses[id]
+	if !ok {
+		return nil, ErrWarehouseNotFound
+	}
+	stored.Name = warehouse.Name
+	stored.Location = warehouse.Location
+	stored.Storage = warehouse.Storage
+	r.warehouses[id] = stored
+	return &stored, nil
+}
+
+func (r *InMemoryWarehouseRepository) DeleteWarehouse(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.warehouses[id]; !ok {
+		return ErrWarehouseNotFound
+	}
+	delete(r.warehouses, id)
+	return nil
+}
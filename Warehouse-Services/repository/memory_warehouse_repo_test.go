@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"Warehouse-Services/model"
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"testing"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInMemoryWarehouseRepository(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryWarehouseRepository()
+
+	created, err := r.CreateWarehouse(ctx, &model.Warehouse{Name: "Main", Location: "Berlin", Storage: 100})
+	if err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+	if created.ID.IsZero() {
+		t.Fatal("CreateWarehouse did not assign an ID")
+	}
+
+	got, err := r.GetWarehouseByID(ctx, created.ID)
+	if err != nil || *got != *created {
+		t.Fatalf("GetWarehouseByID = %+v, %v; want %+v", got, err, created)
+	}
+
+	updated, err := r.UpdateWarehouse(ctx, created.ID, &model.Warehouse{ID: primitive.NewObjectID(), Name: "Annex", Location: "Hamburg", Storage: 250})
+	if err != nil {
+		t.Fatalf("UpdateWarehouse: %v", err)
+	}
+	want := model.Warehouse{ID: created.ID, Name: "Annex", Location: "Hamburg", Storage: 250}
+	if *updated != want {
+		t.Errorf("UpdateWarehouse = %+v, want %+v: the ID is kept", updated, want)
+	}
+	if got, _ := r.GetWarehouseByID(ctx, created.ID); *got != want {
+		t.Errorf("stored warehouse = %+v, want %+v", got, want)
+	}
+
+	// The caller's copy is not the stored one.
+	updated.Name = "Changed"
+	if got, _ := r.GetWarehouseByID(ctx, created.ID); got.Name != "Annex" {
+		t.Errorf("stored name = %q after changing a returned warehouse, want Annex", got.Name)
+	}
+
+	if err := r.DeleteWarehouse(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteWarehouse: %v", err)
+	}
+	if _, err := r.GetWarehouseByID(ctx, created.ID); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Errorf("GetWarehouseByID after delete error = %v, want %v", err, ErrWarehouseNotFound)
+	}
+}
+
+func TestInMemoryWarehouseRepositoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryWarehouseRepository()
+	missing := primitive.NewObjectID()
+
+	if _, err := r.GetWarehouseByID(ctx, missing); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Errorf("GetWarehouseByID error = %v, want %v", err, ErrWarehouseNotFound)
+	}
+	if _, err := r.UpdateWarehouse(ctx, missing, &model.Warehouse{Name: "Annex"}); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Errorf("UpdateWarehouse error = %v, want %v", err, ErrWarehouseNotFound)
+	}
+	if err := r.DeleteWarehouse(ctx, missing); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Errorf("DeleteWarehouse error = %v, want %v", err, ErrWarehouseNotFound)
+	}
+}
+
+func TestInMemoryWarehouseRepositoryPages(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryWarehouseRepository()
+	ids := map[string]primitive.ObjectID{}
+	for i, name := range []string{"Essen", "Bremen", "Leipzig", "Dresden", "Bonn"} {
+		created, err := r.CreateWarehouse(ctx, &model.Warehouse{Name: name, Location: []string{"West", "North", "East"}[i%3], Storage: i})
+		if err != nil {
+			t.Fatalf("CreateWarehouse: %v", err)
+		}
+		ids[name] = created.ID
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{name: "by name", query: url.Values{"sort": {"name"}, "limit": {"2"}}, want: []string{"Bonn", "Bremen", "Dresden", "Essen", "Leipzig"}},
+		{name: "by storage descending", query: url.Values{"sort": {"-storage"}, "limit": {"3"}}, want: []string{"Bonn", "Dresden", "Leipzig", "Bremen", "Essen"}},
+		{name: "filtered by name", query: url.Values{"name": {"Dresden"}}, want: []string{"Dresden"}},
+		{name: "filtered by location", query: url.Values{"sort": {"name"}, "limit": {"1"}, "location": {"North"}}, want: []string{"Bonn", "Bremen"}},
+		{name: "filtered by IDs", query: url.Values{"sort": {"name"}, "limit": {"1"}, "ids": {ids["Leipzig"].Hex() + "," + ids["Bonn"].Hex()}}, want: []string{"Bonn", "Leipzig"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			query := tt.query
+			for {
+				params, err := pagination.Parse(query, WarehouseListSpec)
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				warehouses, next, err := r.GetAllWarehouses(ctx, params)
+				if err != nil {
+					t.Fatalf("GetAllWarehouses: %v", err)
+				}
+				if len(warehouses) > params.Limit {
+					t.Fatalf("page of %d warehouses, want at most %d", len(warehouses), params.Limit)
+				}
+				for _, warehouse := range warehouses {
+					got = append(got, warehouse.Name)
+				}
+				if next == "" {
+					break
+				}
+				query = maps.Clone(tt.query)
+				query.Set("after", next)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("warehouses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,22 +1,43 @@
 package repository
 
 import (
+	"Warehouse-Services/config"
 	"Warehouse-Services/database"
 	"Warehouse-Services/model"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrWarehouseNotFound is returned when no warehouse matches the given ID.
+var ErrWarehouseNotFound = apperrors.NotFound("warehouse not found")
+
+// WarehouseListSpec lists the fields clients may sort and filter warehouses on.
+var WarehouseListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"name":     "name",
+		"location": "location",
+		"storage":  "storage",
+	},
+	FilterFields: map[string]pagination.Field{
+		"name":     {BSON: "name", Kind: pagination.String},
+		"location": {BSON: "location", Kind: pagination.String},
+		"ids":      {BSON: "_id", Kind: pagination.ObjectIDs},
+	},
+}
+
 // WarehouseRepository defines the interface for warehouse data operations.
 type WarehouseRepository interface {
 	CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) (*model.Warehouse, error)
-	GetAllWarehouses(ctx context.Context) ([]model.Warehouse, error)
+	GetAllWarehouses(ctx context.Context, params *pagination.Params) ([]model.Warehouse, string, error)
 	GetWarehouseByID(ctx context.Context, id primitive.ObjectID) (*model.Warehouse, error)
 	UpdateWarehouse(ctx context.Context, id primitive.ObjectID, warehouse *model.Warehouse) (*model.Warehouse, error)
 	DeleteWarehouse(ctx context.Context, id primitive.ObjectID) error
@@ -27,8 +48,12 @@ type warehouseRepositoryImpl struct {
 	collection *mongo.Collection
 }
 
-// NewWarehouseRepository creates a new instance of WarehouseRepository.
+// NewWarehouseRepository creates a new instance of WarehouseRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
 func NewWarehouseRepository() WarehouseRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryWarehouseRepository()
+	}
 	if database.Client == nil {
 		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
 	}
@@ -37,6 +62,9 @@ func NewWarehouseRepository() WarehouseRepository {
 }
 
 func (r *warehouseRepositoryImpl) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) (*model.Warehouse, error) {
+	ctx, done := instrument.Repository(ctx, "warehouse", "CreateWarehouse")
+	defer done()
+
 	result, err := r.collection.InsertOne(ctx, warehouse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create warehouse in repository: %w", err)
@@ -45,26 +73,33 @@ func (r *warehouseRepositoryImpl) CreateWarehouse(ctx context.Context, warehouse
 	return warehouse, nil
 }
 
-func (r *warehouseRepositoryImpl) GetAllWarehouses(ctx context.Context) ([]model.Warehouse, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// GetAllWarehouses returns one page of warehouses and the cursor for the next page.
+func (r *warehouseRepositoryImpl) GetAllWarehouses(ctx context.Context, params *pagination.Params) ([]model.Warehouse, string, error) {
+	ctx, done := instrument.Repository(ctx, "warehouse", "GetAllWarehouses")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve warehouses from repository: %w", err)
+		return nil, "", fmt.Errorf("failed to retrieve warehouses from repository: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var warehouses []model.Warehouse
+	warehouses := []model.Warehouse{}
 	if err = cursor.All(ctx, &warehouses); err != nil {
-		return nil, fmt.Errorf("failed to decode warehouses from cursor: %w", err)
+		return nil, "", fmt.Errorf("failed to decode warehouses from cursor: %w", err)
 	}
-	return warehouses, nil
+	return pagination.Page(warehouses, params)
 }
 
 func (r *warehouseRepositoryImpl) GetWarehouseByID(ctx context.Context, id primitive.ObjectID) (*model.Warehouse, error) {
+	ctx, done := instrument.Repository(ctx, "warehouse", "GetWarehouseByID")
+	defer done()
+
 	var warehouse model.Warehouse
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&warehouse)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("warehouse not found in repository")
+			return nil, ErrWarehouseNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve warehouse by ID from repository: %w", err)
 	}
@@ -72,6 +107,9 @@ func (r *warehouseRepositoryImpl) GetWarehouseByID(ctx context.Context, id primi
 }
 
 func (r *warehouseRepositoryImpl) UpdateWarehouse(ctx context.Context, id primitive.ObjectID, warehouse *model.Warehouse) (*model.Warehouse, error) {
+	ctx, done := instrument.Repository(ctx, "warehouse", "UpdateWarehouse")
+	defer done()
+
 	updateDoc := bson.M{
 		"$set": bson.M{
 			"name":     warehouse.Name,
@@ -84,20 +122,23 @@ func (r *warehouseRepositoryImpl) UpdateWarehouse(ctx context.Context, id primit
 	if err != nil {
 		return nil, fmt.Errorf("failed to update warehouse: %w", err)
 	}
-	if result.ModifiedCount == 0 {
-		return nil, errors.New("warehouse not found or no changes made")
+	if result.MatchedCount == 0 {
+		return nil, ErrWarehouseNotFound
 	}
 
 	return r.GetWarehouseByID(ctx, id)
 }
 
 func (r *warehouseRepositoryImpl) DeleteWarehouse(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "warehouse", "DeleteWarehouse")
+	defer done()
+
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return fmt.Errorf("failed to delete warehouse: %w", err)
 	}
 	if result.DeletedCount == 0 {
-		return errors.New("warehouse not found")
+		return ErrWarehouseNotFound
 	}
 	return nil
 }
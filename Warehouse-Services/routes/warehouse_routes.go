@@ -24,6 +24,13 @@ func WarehouseRoutes(router *gin.Engine) {
 		warehouseGroup.GET("/:id", warehouseController.GetWarehouseByID) // Matches /warehouses/:id
 		warehouseGroup.PUT("/:id", warehouseController.UpdateWarehouse)
 		warehouseGroup.DELETE("/:id", warehouseController.DeleteWarehouse)
+
+		// Stock held in a warehouse, resolved through the Inventory service
+		warehouseGroup.GET("/:id/inventory", warehouseController.GetWarehouseInventory)
+		warehouseGroup.GET("/:id/utilization", warehouseController.GetWarehouseUtilization)
+
+		// Customers served by a warehouse, resolved through the Customer service
+		warehouseGroup.GET("/:id/customers", warehouseController.GetWarehouseCustomers)
 	}
 
 	// Add explicit 301 redirects for paths that might come in WITH trailing slashes.
@@ -1,45 +1,90 @@
 package service
 
 import (
+	"Warehouse-Services/client"
 	"Warehouse-Services/model"
 	"Warehouse-Services/repository"
 	"context"
-	"errors"
+	"fmt"
+	"net/url"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidWarehouseID is returned when a warehouse ID is not a valid ObjectID.
+var ErrInvalidWarehouseID = apperrors.InvalidID("invalid warehouse ID format")
+
+// ErrWarehouseHoldsStock is returned when a warehouse is deleted while the Inventory service still records stock in it.
+var ErrWarehouseHoldsStock = apperrors.Conflict("warehouse still holds stock")
+
+// ErrWarehouseHasCustomers is returned when a warehouse is deleted while the Customer service still links customers to it.
+var ErrWarehouseHasCustomers = apperrors.Conflict("warehouse still has customers")
+
+// ErrNegativeStorage is returned when a warehouse is given a negative storage capacity.
+var ErrNegativeStorage = apperrors.Validation("storage must not be negative")
+
 // WarehouseService defines the interface for warehouse business logic.
 type WarehouseService interface {
 	CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) (*model.Warehouse, error)
-	GetAllWarehouses(ctx context.Context) ([]model.Warehouse, error)
+	GetAllWarehouses(ctx context.Context, params *pagination.Params) ([]model.Warehouse, string, error)
 	GetWarehouseByID(ctx context.Context, id string) (*model.Warehouse, error)
 	UpdateWarehouse(ctx context.Context, id string, warehouse *model.Warehouse) (*model.Warehouse, error)
 	DeleteWarehouse(ctx context.Context, id string) error
+	GetWarehouseInventory(ctx context.Context, id string, page url.Values) ([]client.InventoryRecord, string, error)
+	GetWarehouseUtilization(ctx context.Context, id string) (*client.Utilization, error)
+	GetWarehouseCustomers(ctx context.Context, id string, page url.Values) ([]client.CustomerRecord, string, error)
+}
+
+// Dependencies groups the collaborators a WarehouseService is built from.
+type Dependencies struct {
+	Warehouses repository.WarehouseRepository
+	Inventory  client.InventoryClient
+	Customers  client.CustomerClient
 }
 
 // warehouseServiceImpl implements WarehouseService.
 type warehouseServiceImpl struct {
 	repository repository.WarehouseRepository
+	inventory  client.InventoryClient
+	customers  client.CustomerClient
 }
 
 // NewWarehouseService creates a new instance of WarehouseService.
 func NewWarehouseService() WarehouseService {
-	return &warehouseServiceImpl{repository: repository.NewWarehouseRepository()}
+	return NewWarehouseServiceWithDependencies(Dependencies{
+		Warehouses: repository.NewWarehouseRepository(),
+		Inventory:  client.NewInventoryClient(),
+		Customers:  client.NewCustomerClient(),
+	})
+}
+
+// NewWarehouseServiceWithDependencies creates a WarehouseService from explicit collaborators,
+// so tests can substitute in-memory repositories and clients.
+func NewWarehouseServiceWithDependencies(deps Dependencies) WarehouseService {
+	return &warehouseServiceImpl{
+		repository: deps.Warehouses,
+		inventory:  deps.Inventory,
+		customers:  deps.Customers,
+	}
 }
 
 func (s *warehouseServiceImpl) CreateWarehouse(ctx context.Context, warehouse *model.Warehouse) (*model.Warehouse, error) {
+	if warehouse.Storage < 0 {
+		return nil, ErrNegativeStorage
+	}
 	return s.repository.CreateWarehouse(ctx, warehouse)
 }
 
-func (s *warehouseServiceImpl) GetAllWarehouses(ctx context.Context) ([]model.Warehouse, error) {
-	return s.repository.GetAllWarehouses(ctx)
+func (s *warehouseServiceImpl) GetAllWarehouses(ctx context.Context, params *pagination.Params) ([]model.Warehouse, string, error) {
+	return s.repository.GetAllWarehouses(ctx, params)
 }
 
 func (s *warehouseServiceImpl) GetWarehouseByID(ctx context.Context, id string) (*model.Warehouse, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid warehouse ID format")
+		return nil, ErrInvalidWarehouseID
 	}
 	return s.repository.GetWarehouseByID(ctx, objID)
 }
@@ -47,15 +92,76 @@ func (s *warehouseServiceImpl) GetWarehouseByID(ctx context.Context, id string)
 func (s *warehouseServiceImpl) UpdateWarehouse(ctx context.Context, id string, warehouse *model.Warehouse) (*model.Warehouse, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid warehouse ID format")
+		return nil, ErrInvalidWarehouseID
+	}
+	if warehouse.Storage < 0 {
+		return nil, ErrNegativeStorage
 	}
 	return s.repository.UpdateWarehouse(ctx, objID, warehouse)
 }
 
+// DeleteWarehouse deletes a warehouse that holds no stock and has no customers. The Inventory
+// service is asked first, for its record with the largest quantity, then the Customer service
+// for one linked customer; if either cannot answer, the warehouse is kept.
 func (s *warehouseServiceImpl) DeleteWarehouse(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return errors.New("invalid warehouse ID format")
+		return ErrInvalidWarehouseID
+	}
+	if _, err := s.repository.GetWarehouseByID(ctx, objID); err != nil {
+		return err
+	}
+	records, _, err := s.inventory.GetInventoriesByWarehouse(ctx, objID.Hex(), url.Values{"limit": {"1"}, "sort": {"-quantity"}})
+	if err != nil {
+		return fmt.Errorf("failed to check the warehouse's stock: %w", err)
+	}
+	if len(records) > 0 && records[0].Quantity > 0 {
+		return fmt.Errorf("%w: move or remove its stock first", ErrWarehouseHoldsStock)
+	}
+	customers, _, err := s.customers.GetCustomersByWarehouse(ctx, objID.Hex(), url.Values{"limit": {"1"}})
+	if err != nil {
+		return fmt.Errorf("failed to check the warehouse's customers: %w", err)
+	}
+	if len(customers) > 0 {
+		return fmt.Errorf("%w: unlink its customers first", ErrWarehouseHasCustomers)
 	}
 	return s.repository.DeleteWarehouse(ctx, objID)
 }
+
+// GetWarehouseInventory lists every stock record held in the warehouse, as reported by the Inventory service.
+// The Inventory service does the paging; page is forwarded to it unchanged.
+func (s *warehouseServiceImpl) GetWarehouseInventory(ctx context.Context, id string, page url.Values) ([]client.InventoryRecord, string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, "", ErrInvalidWarehouseID
+	}
+	if _, err := s.repository.GetWarehouseByID(ctx, objID); err != nil {
+		return nil, "", err
+	}
+	return s.inventory.GetInventoriesByWarehouse(ctx, objID.Hex(), page)
+}
+
+// GetWarehouseUtilization reports used and free capacity, based on the stock the Inventory service holds.
+func (s *warehouseServiceImpl) GetWarehouseUtilization(ctx context.Context, id string) (*client.Utilization, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidWarehouseID
+	}
+	if _, err := s.repository.GetWarehouseByID(ctx, objID); err != nil {
+		return nil, err
+	}
+	return s.inventory.GetUtilization(ctx, objID.Hex())
+}
+
+// GetWarehouseCustomers lists the customers linked to the warehouse, as reported by the Customer service.
+// The Customer service does the paging; page is forwarded to it unchanged.
+func (s *warehouseServiceImpl) GetWarehouseCustomers(ctx context.Context, id string, page url.Values) ([]client.CustomerRecord, string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, "", ErrInvalidWarehouseID
+	}
+	if _, err := s.repository.GetWarehouseByID(ctx, objID); err != nil {
+		return nil, "", err
+	}
+	return s.customers.GetCustomersByWarehouse(ctx, objID.Hex(), page)
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"Warehouse-Services/client"
+	"Warehouse-Services/model"
+	"Warehouse-Services/repository"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"wms-common/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testSite is a WarehouseService on memory storage with one warehouse, and the in-memory
+// Inventory and Customer services it asks.
+type testSite struct {
+	service    WarehouseService
+	warehouses repository.WarehouseRepository
+	inventory  *client.InMemoryInventoryClient
+	customers  *client.InMemoryCustomerClient
+	warehouse  *model.Warehouse
+}
+
+// newTestSite creates a testSite whose Inventory service holds the quantities in the warehouse
+// and whose Customer service links customers to it.
+func newTestSite(t *testing.T, quantities []int, customers int) *testSite {
+	t.Helper()
+	warehouses := repository.NewInMemoryWarehouseRepository()
+	warehouse, err := warehouses.CreateWarehouse(context.Background(), &model.Warehouse{Name: "Main", Storage: 100})
+	if err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+	var records []client.InventoryRecord
+	for _, quantity := range quantities {
+		records = append(records, client.InventoryRecord{ID: primitive.NewObjectID().Hex(), WarehouseID: warehouse.ID.Hex(), Quantity: quantity})
+	}
+	var linked []client.CustomerRecord
+	for i := 0; i < customers; i++ {
+		linked = append(linked, client.CustomerRecord{ID: primitive.NewObjectID().Hex(), WarehouseIDs: []string{warehouse.ID.Hex()}})
+	}
+	s := &testSite{
+		warehouses: warehouses,
+		inventory:  client.NewInMemoryInventoryClient(records...),
+		customers:  client.NewInMemoryCustomerClient(linked...),
+		warehouse:  warehouse,
+	}
+	s.inventory.SetUtilization(client.Utilization{WarehouseID: warehouse.ID.Hex(), Capacity: 100, Used: 40, Free: 60, FillPercent: 40})
+	s.service = s.over(s.inventory, s.customers)
+	return s
+}
+
+// over builds a WarehouseService over the site's warehouses that asks inventory and customers.
+func (s *testSite) over(inventory client.InventoryClient, customers client.CustomerClient) WarehouseService {
+	return NewWarehouseServiceWithDependencies(Dependencies{Warehouses: s.warehouses, Inventory: inventory, Customers: customers})
+}
+
+// unavailableInventory is an Inventory service that cannot be reached.
+type unavailableInventory struct{}
+
+func (unavailableInventory) GetInventoriesByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]client.InventoryRecord, string, error) {
+	return nil, "", client.ErrInventoryUnavailable
+}
+
+func (unavailableInventory) GetUtilization(ctx context.Context, warehouseID string) (*client.Utilization, error) {
+	return nil, client.ErrInventoryUnavailable
+}
+
+// unavailableCustomers is a Customer service that cannot be reached.
+type unavailableCustomers struct{}
+
+func (unavailableCustomers) GetCustomersByWarehouse(ctx context.Context, warehouseID string, page url.Values) ([]client.CustomerRecord, string, error) {
+	return nil, "", client.ErrCustomersUnavailable
+}
+
+func TestDeleteWarehouse(t *testing.T) {
+	tests := []struct {
+		name                 string
+		quantities           []int
+		customers            int
+		inventoryUnavailable bool
+		customersUnavailable bool
+		id                   func(existing string) string
+		wantErr              error
+		wantStatus           int
+	}{
+		{name: "empty warehouse"},
+		{name: "only emptied records", quantities: []int{0, 0}},
+		{name: "stock behind an empty record", quantities: []int{0, 5, 2}, wantErr: ErrWarehouseHoldsStock, wantStatus: http.StatusConflict},
+		{name: "linked customers", customers: 2, wantErr: ErrWarehouseHasCustomers, wantStatus: http.StatusConflict},
+		{name: "inventory service unavailable", inventoryUnavailable: true, wantErr: client.ErrInventoryUnavailable, wantStatus: http.StatusServiceUnavailable},
+		{name: "customer service unavailable", customersUnavailable: true, wantErr: client.ErrCustomersUnavailable, wantStatus: http.StatusServiceUnavailable},
+		{name: "missing warehouse", id: func(string) string { return primitive.NewObjectID().Hex() }, wantErr: repository.ErrWarehouseNotFound, wantStatus: http.StatusNotFound},
+		{name: "malformed ID", id: func(string) string { return "not-an-id" }, wantErr: ErrInvalidWarehouseID, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			site := newTestSite(t, tt.quantities, tt.customers)
+			var (
+				inventory client.InventoryClient = site.inventory
+				customers client.CustomerClient  = site.customers
+			)
+			if tt.inventoryUnavailable {
+				inventory = unavailableInventory{}
+			}
+			if tt.customersUnavailable {
+				customers = unavailableCustomers{}
+			}
+			service := site.over(inventory, customers)
+			id := site.warehouse.ID.Hex()
+			if tt.id != nil {
+				id = tt.id(id)
+			}
+
+			err := service.DeleteWarehouse(ctx, id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteWarehouse error = %v, want %v", err, tt.wantErr)
+			}
+			if status, _ := apperrors.Status(err); tt.wantErr != nil && status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			_, getErr := service.GetWarehouseByID(ctx, site.warehouse.ID.Hex())
+			if deleted := errors.Is(getErr, repository.ErrWarehouseNotFound); deleted != (tt.wantErr == nil) {
+				t.Errorf("warehouse deleted = %v, want %v", deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestDeleteWarehouseAsksForTheLargestRecord(t *testing.T) {
+	site := newTestSite(t, []int{0, 3}, 0)
+	if err := site.service.DeleteWarehouse(context.Background(), site.warehouse.ID.Hex()); !errors.Is(err, ErrWarehouseHoldsStock) {
+		t.Fatalf("DeleteWarehouse error = %v, want %v", err, ErrWarehouseHoldsStock)
+	}
+	want := []url.Values{{"limit": {"1"}, "sort": {"-quantity"}}}
+	if queries := site.inventory.Queries(); !reflect.DeepEqual(queries, want) {
+		t.Errorf("inventory queries = %v, want %v", queries, want)
+	}
+}
+
+func TestGetWarehouseInventory(t *testing.T) {
+	ctx := context.Background()
+	site := newTestSite(t, []int{4, 9, 1}, 0)
+	page := url.Values{"limit": {"2"}, "sort": {"-quantity"}}
+
+	records, _, err := site.service.GetWarehouseInventory(ctx, site.warehouse.ID.Hex(), page)
+	if err != nil {
+		t.Fatalf("GetWarehouseInventory: %v", err)
+	}
+	var quantities []int
+	for _, record := range records {
+		quantities = append(quantities, record.Quantity)
+	}
+	if !reflect.DeepEqual(quantities, []int{9, 4}) {
+		t.Errorf("quantities = %v, want [9 4]", quantities)
+	}
+	if queries := site.inventory.Queries(); !reflect.DeepEqual(queries, []url.Values{page}) {
+		t.Errorf("inventory queries = %v, want the page forwarded unchanged", queries)
+	}
+
+	if _, _, err := site.service.GetWarehouseInventory(ctx, primitive.NewObjectID().Hex(), page); !errors.Is(err, repository.ErrWarehouseNotFound) {
+		t.Errorf("missing warehouse error = %v, want %v", err, repository.ErrWarehouseNotFound)
+	}
+	if n := len(site.inventory.Queries()); n != 1 {
+		t.Errorf("inventory asked %d times, want once: a missing warehouse is not looked up there", n)
+	}
+}
+
+func TestGetWarehouseUtilization(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		inventory  func() client.InventoryClient
+		id         func(existing string) string
+		wantErr    error
+		wantStatus int
+	}{
+		{name: "reported by the inventory service"},
+		{
+			name:       "unknown to the inventory service",
+			inventory:  func() client.InventoryClient { return client.NewInMemoryInventoryClient() },
+			wantErr:    client.ErrUnknownToInventory,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "inventory service unavailable",
+			inventory:  func() client.InventoryClient { return unavailableInventory{} },
+			wantErr:    client.ErrInventoryUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{name: "missing warehouse", id: func(string) string { return primitive.NewObjectID().Hex() }, wantErr: repository.ErrWarehouseNotFound, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newTestSite(t, nil, 0)
+			service := site.service
+			if tt.inventory != nil {
+				service = site.over(tt.inventory(), site.customers)
+			}
+			id := site.warehouse.ID.Hex()
+			if tt.id != nil {
+				id = tt.id(id)
+			}
+
+			utilization, err := service.GetWarehouseUtilization(ctx, id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetWarehouseUtilization error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if status, _ := apperrors.Status(err); status != tt.wantStatus {
+					t.Errorf("status = %d, want %d", status, tt.wantStatus)
+				}
+				return
+			}
+			if utilization.Used != 40 || utilization.Free != 60 {
+				t.Errorf("utilization = %+v, want 40 used and 60 free", utilization)
+			}
+		})
+	}
+}
+
+func TestGetWarehouseCustomers(t *testing.T) {
+	ctx := context.Background()
+	site := newTestSite(t, nil, 3)
+
+	customers, _, err := site.service.GetWarehouseCustomers(ctx, site.warehouse.ID.Hex(), url.Values{"limit": {"2"}})
+	if err != nil {
+		t.Fatalf("GetWarehouseCustomers: %v", err)
+	}
+	if len(customers) != 2 {
+		t.Errorf("%d customers, want a page of 2", len(customers))
+	}
+	if _, _, err := site.service.GetWarehouseCustomers(ctx, "not-an-id", nil); !errors.Is(err, ErrInvalidWarehouseID) {
+		t.Errorf("malformed ID error = %v, want %v", err, ErrInvalidWarehouseID)
+	}
+	if _, _, err := site.service.GetWarehouseCustomers(ctx, primitive.NewObjectID().Hex(), nil); !errors.Is(err, repository.ErrWarehouseNotFound) {
+		t.Errorf("missing warehouse error = %v, want %v", err, repository.ErrWarehouseNotFound)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage int
+		wantErr error
+	}{
+		{name: "positive", storage: 250},
+		{name: "zero means unlimited", storage: 0},
+		{name: "negative", storage: -1, wantErr: ErrNegativeStorage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			site := newTestSite(t, nil, 0)
+
+			_, createErr := site.service.CreateWarehouse(ctx, &model.Warehouse{Name: "Annex", Storage: tt.storage})
+			_, updateErr := site.service.UpdateWarehouse(ctx, site.warehouse.ID.Hex(), &model.Warehouse{Name: "Main", Storage: tt.storage})
+			for operation, err := range map[string]error{"create": createErr, "update": updateErr} {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", operation, err, tt.wantErr)
+				}
+				if status, _ := apperrors.Status(err); tt.wantErr != nil && status != http.StatusBadRequest {
+					t.Errorf("%s status = %d, want %d", operation, status, http.StatusBadRequest)
+				}
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if stored, _ := site.service.GetWarehouseByID(ctx, site.warehouse.ID.Hex()); stored.Storage != 100 {
+				t.Errorf("storage after the refused update = %d, want 100", stored.Storage)
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	os.Exit(m.Run())
+}
+
+var (
+	hmacSecret = []byte("gateway-test-secret")
+	octSecret  = []byte("jwks-oct-secret")
+	rsaKey     = mustRSAKey()
+	ecKey      = mustECKey()
+)
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func mustECKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// writeJWKS writes a JWKS file holding the public halves of rsaKey ("rsa-1") and ecKey ("ec-1")
+// and octSecret ("oct-1"), and returns its path.
+func writeJWKS(t *testing.T) string {
+	t.Helper()
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	doc := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "oct", "kid": "oct-1", "k": base64.RawURLEncoding.EncodeToString(octSecret)},
+		{"kty": "RSA", "kid": "rsa-enc", "use": "enc", "n": "not base64!", "e": "AQAB"},
+	}}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// claims returns valid claims for alice with role, expiring in an hour.
+func claims(role string) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "wms-idp",
+			Audience:  jwt.ClaimStrings{"wms"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Role: role,
+	}
+}
+
+// sign signs c with method and key, setting the key ID header when kid is not empty.
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, c Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing a %s token: %v", method.Alg(), err)
+	}
+	return raw
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	brokenJWKS := filepath.Join(t.TempDir(), "broken.json")
+	if err := os.WriteFile(brokenJWKS, []byte(`{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-192"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyJWKS := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(emptyJWKS, []byte(`{"keys":[{"kty":"OKP","kid":"ed-1"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "HMAC secret", opts: Options{HMACSecret: hmacSecret}},
+		{name: "JWKS file", opts: Options{JWKSFile: writeJWKS(t)}},
+		{name: "no keys", wantErr: true},
+		{name: "missing JWKS file", opts: Options{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}, wantErr: true},
+		{name: "unsupported curve", opts: Options{JWKSFile: brokenJWKS}, wantErr: true},
+		{name: "no usable keys", opts: Options{JWKSFile: emptyJWKS}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("NewAuthenticator error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	jwksFile := writeJWKS(t)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublic, err := json.Marshal(rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		token   func(t *testing.T) string
+		wantErr bool
+	}{
+		{
+			name:  "HMAC secret",
+			opts:  Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims("clerk")) },
+		},
+		{
+			name: "HMAC with the wrong secret",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, []byte("guessed"), "", claims("clerk"))
+			},
+			wantErr: true,
+		},
+		{
+			name:  "JWKS RSA key",
+			opts:  Options{JWKSFile: jwksFile},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("clerk")) },
+		},
+		{
+			name:  "JWKS RSA-PSS key",
+			opts:  Options{JWKSFile: jwksFile},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodPS256, rsaKey, "rsa-1", claims("clerk")) },
+		},
+		{
+			name:  "JWKS EC key without a key ID",
+			opts:  Options{JWKSFile: jwksFile},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodES256, ecKey, "", claims("clerk")) },
+		},
+		{
+			name:  "JWKS oct key",
+			opts:  Options{JWKSFile: jwksFile},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodHS512, octSecret, "oct-1", claims("clerk")) },
+		},
+		{
+			name:    "unknown key ID",
+			opts:    Options{JWKSFile: jwksFile},
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-2", claims("clerk")) },
+			wantErr: true,
+		},
+		{
+			name:    "RSA token without a JWKS file",
+			opts:    Options{HMACSecret: hmacSecret},
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", claims("clerk")) },
+			wantErr: true,
+		},
+		{
+			name: "HMAC token keyed with the RSA public key",
+			opts: Options{JWKSFile: jwksFile},
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, rsaPublic, "rsa-1", claims("clerk"))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "algorithm outside the allow-list",
+			opts:    Options{HMACSecret: hmacSecret, JWKSFile: jwksFile},
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodEdDSA, edKey, "", claims("clerk")) },
+			wantErr: true,
+		},
+		{
+			name: "unsigned token",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims("clerk"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				c := claims("clerk")
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return sign(t, jwt.SigningMethodHS256, hmacSecret, "", c)
+			},
+			wantErr: true,
+		},
+		{
+			name: "not yet valid",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				c := claims("clerk")
+				c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute))
+				return sign(t, jwt.SigningMethodHS256, hmacSecret, "", c)
+			},
+			wantErr: true,
+		},
+		{
+			name: "no expiry",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				c := claims("clerk")
+				c.ExpiresAt = nil
+				return sign(t, jwt.SigningMethodHS256, hmacSecret, "", c)
+			},
+			wantErr: true,
+		},
+		{
+			name: "no subject",
+			opts: Options{HMACSecret: hmacSecret},
+			token: func(t *testing.T) string {
+				c := claims("clerk")
+				c.Subject = ""
+				return sign(t, jwt.SigningMethodHS256, hmacSecret, "", c)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "expected issuer and audience",
+			opts:  Options{HMACSecret: hmacSecret, Issuer: "wms-idp", Audience: "wms"},
+			token: func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims("clerk")) },
+		},
+		{
+			name:    "other issuer",
+			opts:    Options{HMACSecret: hmacSecret, Issuer: "corporate-idp"},
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims("clerk")) },
+			wantErr: true,
+		},
+		{
+			name:    "other audience",
+			opts:    Options{HMACSecret: hmacSecret, Audience: "billing"},
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims("clerk")) },
+			wantErr: true,
+		},
+		{
+			name:    "not a JWT",
+			opts:    Options{HMACSecret: hmacSecret},
+			token:   func(*testing.T) string { return "not-a-token" },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuthenticator(tt.opts)
+			if err != nil {
+				t.Fatalf("NewAuthenticator: %v", err)
+			}
+			got, err := a.Authenticate(tt.token(t))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Subject != "alice" || got.Role != "clerk") {
+				t.Errorf("claims = %+v, want alice as clerk", got)
+			}
+		})
+	}
+}
+
+func TestPolicyRequired(t *testing.T) {
+	tests := []struct {
+		group  string
+		method string
+		want   Role
+	}{
+		{group: "inventory", method: http.MethodGet, want: RoleViewer},
+		{group: "inventory", method: http.MethodPost, want: RoleClerk},
+		{group: "inventory", method: http.MethodDelete, want: RoleManager},
+		{group: "warehouses", method: http.MethodGet, want: RoleViewer},
+		{group: "warehouses", method: http.MethodPost, want: RoleManager},
+		{group: "commodities", method: http.MethodPut, want: RoleManager},
+		{group: "commodities", method: http.MethodPatch, want: RoleClerk},
+		{group: "orders", method: http.MethodOptions, want: RoleNone},
+		{group: "orders", method: "PROPFIND", want: RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.group, func(t *testing.T) {
+			if got := DefaultPolicy.Required(tt.group, tt.method); got != tt.want {
+				t.Errorf("Required = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name string
+		want Role
+	}{
+		{name: "viewer", want: RoleViewer},
+		{name: "Manager", want: RoleManager},
+		{name: "ADMIN", want: RoleAdmin},
+		{name: "superuser", want: RoleNone},
+		{name: "", want: RoleNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRole(tt.name); got != tt.want {
+				t.Errorf("ParseRole(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	tests := map[string]string{
+		"/api/warehouses":             "warehouses",
+		"/api/warehouses/42":          "warehouses",
+		"/api/inventory/42/movements": "inventory",
+		"/api":                        "",
+		"/health":                     "health",
+	}
+	for path, want := range tests {
+		if got := RouteGroup(path); got != want {
+			t.Errorf("RouteGroup(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// serve sends a request through handler to an endpoint that echoes the identity headers it
+// received, and returns the response.
+func serve(handler gin.HandlerFunc, method, path string, header http.Header) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(handler)
+	router.Handle(method, path, func(ctx *gin.Context) {
+		ctx.Header("Seen-User-ID", ctx.GetHeader(UserIDHeader))
+		ctx.Header("Seen-User-Role", ctx.GetHeader(UserRoleHeader))
+		ctx.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(method, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(Options{HMACSecret: hmacSecret})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	bearer := func(role string) func(t *testing.T) string {
+		return func(t *testing.T) string {
+			return "Bearer " + sign(t, jwt.SigningMethodHS256, hmacSecret, "", claims(role))
+		}
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization func(t *testing.T) string
+		wantStatus    int
+		wantRole      string // Passed downstream when the request is let through
+	}{
+		{name: "viewer reads", method: http.MethodGet, path: "/api/warehouses", authorization: bearer("viewer"), wantStatus: http.StatusOK, wantRole: "viewer"},
+		{name: "clerk records stock", method: http.MethodPost, path: "/api/inventory", authorization: bearer("clerk"), wantStatus: http.StatusOK, wantRole: "clerk"},
+		{name: "viewer records stock", method: http.MethodPost, path: "/api/inventory", authorization: bearer("viewer"), wantStatus: http.StatusForbidden},
+		{name: "clerk creates a warehouse", method: http.MethodPost, path: "/api/warehouses", authorization: bearer("clerk"), wantStatus: http.StatusForbidden},
+		{name: "manager creates a warehouse", method: http.MethodPost, path: "/api/warehouses", authorization: bearer("manager"), wantStatus: http.StatusOK, wantRole: "manager"},
+		{name: "clerk deletes", method: http.MethodDelete, path: "/api/orders/42", authorization: bearer("clerk"), wantStatus: http.StatusForbidden},
+		{name: "manager deletes", method: http.MethodDelete, path: "/api/orders/42", authorization: bearer("manager"), wantStatus: http.StatusOK, wantRole: "manager"},
+		{name: "admin deletes", method: http.MethodDelete, path: "/api/orders/42", authorization: bearer("admin"), wantStatus: http.StatusOK, wantRole: "admin"},
+		{name: "unknown role reads", method: http.MethodGet, path: "/api/warehouses", authorization: bearer("superuser"), wantStatus: http.StatusForbidden},
+		{name: "no role reads", method: http.MethodGet, path: "/api/warehouses", authorization: bearer(""), wantStatus: http.StatusForbidden},
+		{name: "manager uses an unlisted method", method: "PROPFIND", path: "/api/orders", authorization: bearer("manager"), wantStatus: http.StatusForbidden},
+		{name: "preflight without a token", method: http.MethodOptions, path: "/api/warehouses", wantStatus: http.StatusOK},
+		{name: "no token", method: http.MethodGet, path: "/api/warehouses", wantStatus: http.StatusUnauthorized},
+		{
+			name:          "other scheme",
+			method:        http.MethodGet,
+			path:          "/api/warehouses",
+			authorization: func(*testing.T) string { return "Basic YWxpY2U6c2VjcmV0" },
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "invalid token",
+			method:        http.MethodGet,
+			path:          "/api/warehouses",
+			authorization: func(*testing.T) string { return "Bearer not-a-token" },
+			wantStatus:    http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every request also claims to come from an admin; only the token may say who it is.
+			header := http.Header{}
+			header.Set(UserIDHeader, "mallory")
+			header.Set(UserRoleHeader, "admin")
+			if tt.authorization != nil {
+				header.Set("Authorization", tt.authorization(t))
+			}
+
+			rec := serve(Middleware(a, DefaultPolicy), tt.method, tt.path, header)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate challenge")
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			wantUser := "alice"
+			if tt.wantRole == "" {
+				wantUser = ""
+			}
+			if user, role := rec.Header().Get("Seen-User-ID"), rec.Header().Get("Seen-User-Role"); user != wantUser || role != tt.wantRole {
+				t.Errorf("downstream saw user %q with role %q, want %q with %q", user, role, wantUser, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestStripIdentity(t *testing.T) {
+	header := http.Header{}
+	header.Set(UserIDHeader, "mallory")
+	header.Set(UserRoleHeader, "admin")
+
+	rec := serve(StripIdentity(), http.MethodGet, "/api/warehouses", header)
+	if user, role := rec.Header().Get("Seen-User-ID"), rec.Header().Get("Seen-User-Role"); rec.Code != http.StatusOK || user != "" || role != "" {
+		t.Errorf("status %d, downstream saw user %q with role %q, want both headers dropped", rec.Code, user, role)
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// keySet holds verification keys loaded from a JWKS document, indexed by key ID.
+type keySet struct {
+	rsa   map[string]*rsa.PublicKey
+	ec    map[string]*ecdsa.PublicKey
+	hmac  map[string][]byte
+	count int
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// loadKeySet reads a JWKS file with RSA, EC or symmetric ("oct") signing keys.
+func loadKeySet(path string) (*keySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %w", err)
+	}
+
+	set := &keySet{
+		rsa:  map[string]*rsa.PublicKey{},
+		ec:   map[string]*ecdsa.PublicKey{},
+		hmac: map[string][]byte{},
+	}
+	for _, key := range doc.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		switch key.Kty {
+		case "RSA":
+			n, err := decodeBigInt(key.N)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid modulus: %w", key.Kid, err)
+			}
+			e, err := decodeBigInt(key.E)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid exponent: %w", key.Kid, err)
+			}
+			set.rsa[key.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			curve, err := curveFor(key.Crv)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: %w", key.Kid, err)
+			}
+			x, err := decodeBigInt(key.X)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid x coordinate: %w", key.Kid, err)
+			}
+			y, err := decodeBigInt(key.Y)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid y coordinate: %w", key.Kid, err)
+			}
+			set.ec[key.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return nil, fmt.Errorf("JWKS key %q: invalid key value: %w", key.Kid, err)
+			}
+			set.hmac[key.Kid] = secret
+		default:
+			continue
+		}
+		set.count++
+	}
+	if set.count == 0 {
+		return nil, fmt.Errorf("JWKS file %s holds no usable signing keys", path)
+	}
+	return set, nil
+}
+
+// lookup picks the key for kid from keys. A token without a kid may use the only key of its type.
+func lookup[K any](keys map[string]K, kid string) (K, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+func decodeBigInt(encoded string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func curveFor(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+	return nil, fmt.Errorf("unsupported curve %q", name)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Role is a user's access level. Each role includes everything the roles below it may do.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleClerk
+	RoleManager
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleViewer:  "viewer",
+	RoleClerk:   "clerk",
+	RoleManager: "manager",
+	RoleAdmin:   "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return "none"
+}
+
+// ParseRole maps a role name from a token to a Role; unknown names give RoleNone.
+func ParseRole(name string) Role {
+	for role, roleName := range roleNames {
+		if strings.EqualFold(name, roleName) {
+			return role
+		}
+	}
+	return RoleNone
+}
+
+// MethodRoles gives the minimum role per HTTP method. Methods not listed fall back to the default policy.
+type MethodRoles map[string]Role
+
+// Policy gives the minimum role needed per route group (the first path segment after /api)
+// and HTTP method.
+type Policy struct {
+	Default MethodRoles
+	Groups  map[string]MethodRoles
+}
+
+// DefaultPolicy lets viewers read everything and clerks record day-to-day changes, while
+// changes to master data (warehouses, commodities) and deletions are kept for managers.
+var DefaultPolicy = Policy{
+	Default: MethodRoles{
+		http.MethodGet:     RoleViewer,
+		http.MethodHead:    RoleViewer,
+		http.MethodPost:    RoleClerk,
+		http.MethodPut:     RoleClerk,
+		http.MethodPatch:   RoleClerk,
+		http.MethodDelete:  RoleManager,
+		http.MethodOptions: RoleNone,
+	},
+	Groups: map[string]MethodRoles{
+		"warehouses": {
+			http.MethodPost: RoleManager,
+			http.MethodPut:  RoleManager,
+		},
+		"commodities": {
+			http.MethodPost: RoleManager,
+			http.MethodPut:  RoleManager,
+		},
+	},
+}
+
+// Required returns the minimum role for a method on a route group. Methods the policy does
+// not mention require RoleAdmin.
+func (p Policy) Required(group, method string) Role {
+	if role, ok := p.Groups[group][method]; ok {
+		return role
+	}
+	if role, ok := p.Default[method]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+// RouteGroup returns the route group of a gateway path, the first segment after /api: e.g.
+// "warehouses" for /api/warehouses/42. Policies and rate limits are both set per route group.
+func RouteGroup(path string) string {
+	path = strings.TrimPrefix(path, "/api")
+	path = strings.TrimPrefix(path, "/")
+	group, _, _ := strings.Cut(path, "/")
+	return group
+}
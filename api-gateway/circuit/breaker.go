@@ -0,0 +1,191 @@
+// Package circuit implements the circuit breaker the gateway keeps per upstream service.
+//
+// A closed circuit lets every request through. After Threshold consecutive failures it opens
+// and callers are turned away at once instead of waiting on a dead service. Once Cooldown has
+// passed the circuit is half-open: one trial request per cooldown period is let through, and
+// only the success of such a trial closes the circuit again. A passing health probe ends the
+// cooldown early but does not close the circuit by itself.
+//
+// Allow hands out a Ticket with every request it lets through, and outcomes are reported with
+// it. An outcome only counts while the circuit is still in the state its request was let
+// through in, so a slow request sent before the circuit opened cannot close it again. A request
+// abandoned by its caller has no outcome; it is released, which frees the trial slot it held.
+package circuit
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit.
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// MarshalText renders the state by name in JSON.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Ticket identifies the state period in which Allow let a request through and, for a trial,
+// when the trial started.
+type Ticket struct {
+	period uint64
+	trial  time.Time
+}
+
+// Breaker tracks the failures of one upstream. It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	period   uint64 // Counts state changes, so that tickets from an earlier state can be told apart
+	failures int
+	openedAt time.Time
+	trialAt  time.Time
+}
+
+// NewBreaker creates a closed breaker that opens after threshold consecutive failures and
+// stays open for cooldown.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now, period: 1}
+}
+
+// Allow reports whether a request may be sent to the upstream now, and if so returns the
+// ticket its outcome is to be reported with.
+func (b *Breaker) Allow() (Ticket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case Open:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return Ticket{}, false
+		}
+		b.moveTo(HalfOpen)
+		b.trialAt = now
+	case HalfOpen:
+		// A trial whose outcome never got reported must not keep the circuit shut for good.
+		if now.Sub(b.trialAt) < b.cooldown {
+			return Ticket{}, false
+		}
+		b.trialAt = now
+	default:
+		return Ticket{period: b.period}, true
+	}
+	return Ticket{period: b.period, trial: now}, true
+}
+
+// Success records a successful call. It resets the failure count of a closed circuit and
+// closes a half-open one.
+func (b *Breaker) Success(ticket Ticket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ticket.period != b.period {
+		return
+	}
+	if b.state == HalfOpen {
+		b.moveTo(Closed)
+	}
+	b.failures = 0
+}
+
+// Failure records a failed call, opening the circuit once the threshold is reached or when a
+// half-open trial fails.
+func (b *Breaker) Failure(ticket Ticket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ticket.period != b.period {
+		return
+	}
+	b.fail()
+}
+
+// Release records that a request was abandoned before it had an outcome, e.g. because the
+// client went away. It counts as neither success nor failure, but if the request was the
+// current half-open trial, the next request may be let through as a trial at once.
+func (b *Breaker) Release(ticket Ticket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ticket.period != b.period || b.state != HalfOpen || !ticket.trial.Equal(b.trialAt) {
+		return
+	}
+	b.trialAt = time.Time{}
+}
+
+// Probe records the outcome of a health check of the upstream. A failed probe counts like a
+// failed call. A passing one resets the failure count of a closed circuit, so failures spread
+// far apart do not add up to the threshold, and ends the cooldown of an open circuit early, so
+// the next request is let through as its trial; the circuit closes only once that trial succeeds.
+func (b *Breaker) Probe(healthy bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !healthy && b.state != Open:
+		b.fail()
+	case healthy && b.state == Closed:
+		b.failures = 0
+	case healthy && b.state == Open:
+		b.moveTo(HalfOpen)
+		b.trialAt = time.Time{}
+	}
+}
+
+func (b *Breaker) fail() {
+	b.failures++
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.moveTo(Open)
+		b.openedAt = b.now()
+	}
+}
+
+// moveTo changes the state, which voids the tickets handed out so far.
+func (b *Breaker) moveTo(state State) {
+	b.state = state
+	b.period++
+}
+
+// State returns the current state and the number of consecutive failures.
+func (b *Breaker) State() (State, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state, b.failures
+}
+
+// RetryAfter estimates how long until the circuit lets a request through again.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	since := b.openedAt
+	if b.state == HalfOpen {
+		since = b.trialAt
+	} else if b.state == Closed {
+		return 0
+	}
+	return max(b.cooldown-b.now().Sub(since), 0)
+}
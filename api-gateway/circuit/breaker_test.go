@@ -0,0 +1,129 @@
+package circuit
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cooldown = 10 * time.Second
+
+// run plays steps against a breaker with a threshold of 2 whose time only moves on "wait":
+//
+//	allow       Allow must let a request through; its ticket is numbered from 0
+//	refuse      Allow must turn the request away
+//	ok N        the request with ticket N succeeded
+//	fail N      the request with ticket N failed
+//	release N   the request with ticket N was abandoned by its caller
+//	probe up    a health probe passed
+//	probe down  a health probe failed
+//	wait        the cooldown passes
+func run(t *testing.T, steps []string) *Breaker {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, cooldown)
+	b.now = func() time.Time { return now }
+
+	var tickets []Ticket
+	for i, step := range steps {
+		op, arg, _ := strings.Cut(step, " ")
+		switch op {
+		case "allow", "refuse":
+			ticket, allowed := b.Allow()
+			if allowed != (op == "allow") {
+				t.Fatalf("step %d (%s): Allow = %v in state %s", i+1, step, allowed, b.state)
+			}
+			if allowed {
+				tickets = append(tickets, ticket)
+			}
+		case "ok", "fail", "release":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n >= len(tickets) {
+				t.Fatalf("step %d (%s): no such ticket", i+1, step)
+			}
+			switch op {
+			case "ok":
+				b.Success(tickets[n])
+			case "fail":
+				b.Failure(tickets[n])
+			default:
+				b.Release(tickets[n])
+			}
+		case "probe":
+			b.Probe(arg == "up")
+		case "wait":
+			now = now.Add(cooldown)
+		default:
+			t.Fatalf("step %d: unknown step %q", i+1, step)
+		}
+	}
+	return b
+}
+
+func TestBreaker(t *testing.T) {
+	opened := []string{"allow", "allow", "allow", "fail 0", "fail 1"} // Ticket 2 is still in flight
+
+	tests := []struct {
+		name         string
+		steps        []string
+		wantState    State
+		wantFailures int
+	}{
+		{name: "closed lets requests through", steps: []string{"allow", "fail 0", "allow"}, wantState: Closed, wantFailures: 1},
+		{name: "opens at the threshold", steps: append(opened, "refuse"), wantState: Open, wantFailures: 2},
+		{name: "success resets the failures", steps: []string{"allow", "fail 0", "allow", "ok 1", "allow", "fail 2"}, wantState: Closed, wantFailures: 1},
+		{name: "half-open after the cooldown", steps: append(opened, "wait", "allow", "refuse"), wantState: HalfOpen, wantFailures: 2},
+		{name: "successful trial closes", steps: append(opened, "wait", "allow", "ok 3", "allow"), wantState: Closed},
+		{name: "failed trial reopens", steps: append(opened, "wait", "allow", "fail 3", "refuse"), wantState: Open, wantFailures: 3},
+		{name: "lost trial is replaced after the cooldown", steps: append(opened, "wait", "allow", "refuse", "wait", "allow", "ok 4"), wantState: Closed},
+		{name: "late success does not close an open circuit", steps: append(opened, "ok 2", "refuse"), wantState: Open, wantFailures: 2},
+		{name: "late success does not close a half-open circuit", steps: append(opened, "wait", "allow", "ok 2", "refuse"), wantState: HalfOpen, wantFailures: 2},
+		{name: "late failure does not reopen a half-open circuit", steps: append(opened, "wait", "allow", "fail 2", "ok 3"), wantState: Closed},
+		{name: "late trial failure does not count once closed", steps: append(opened, "wait", "allow", "wait", "allow", "ok 4", "fail 3"), wantState: Closed},
+		{name: "passing probe ends the cooldown", steps: append(opened, "probe up", "allow", "refuse"), wantState: HalfOpen, wantFailures: 2},
+		{name: "passing probe does not close", steps: append(opened, "probe up", "probe up"), wantState: HalfOpen, wantFailures: 2},
+		{name: "trial after a passing probe closes", steps: append(opened, "probe up", "allow", "ok 3"), wantState: Closed},
+		{name: "failing probe reopens a half-open circuit", steps: append(opened, "probe up", "probe down", "refuse"), wantState: Open, wantFailures: 3},
+		{name: "failing probes open an idle circuit", steps: []string{"probe down", "probe down", "refuse"}, wantState: Open, wantFailures: 2},
+		{name: "passing probe resets the failures of a closed circuit", steps: []string{"allow", "fail 0", "probe up", "allow", "fail 1"}, wantState: Closed, wantFailures: 1},
+		{name: "released trial frees its slot", steps: append(opened, "wait", "allow", "release 3", "allow", "ok 4"), wantState: Closed},
+		{name: "released request counts for nothing", steps: []string{"allow", "fail 0", "allow", "release 1", "allow"}, wantState: Closed, wantFailures: 1},
+		{name: "late release does not free a newer trial's slot", steps: append(opened, "wait", "allow", "wait", "allow", "release 3", "refuse"), wantState: HalfOpen, wantFailures: 2},
+		{name: "release of a request from before the circuit opened", steps: append(opened, "wait", "allow", "release 2", "refuse"), wantState: HalfOpen, wantFailures: 2},
+		{name: "failing probe leaves an open circuit's cooldown", steps: append(opened, "probe down", "wait", "allow"), wantState: HalfOpen, wantFailures: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, failures := run(t, tt.steps).State()
+			if state != tt.wantState || failures != tt.wantFailures {
+				t.Errorf("state = %s with %d failures, want %s with %d", state, failures, tt.wantState, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestBreakerRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []string
+		wait  time.Duration
+		want  time.Duration
+	}{
+		{name: "closed", steps: []string{"allow"}, want: 0},
+		{name: "just opened", steps: []string{"probe down", "probe down"}, want: cooldown},
+		{name: "open for a while", steps: []string{"probe down", "probe down"}, wait: 4 * time.Second, want: 6 * time.Second},
+		{name: "trial in flight", steps: []string{"probe down", "probe down", "wait", "allow"}, want: cooldown},
+		{name: "half-open by a probe", steps: []string{"probe down", "probe down", "probe up"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := run(t, tt.steps)
+			now := b.now().Add(tt.wait)
+			b.now = func() time.Time { return now }
+			if got := b.RetryAfter(); got != tt.want {
+				t.Errorf("RetryAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
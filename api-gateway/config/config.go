@@ -3,8 +3,10 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds the application configuration.
@@ -15,6 +17,54 @@ type Config struct {
 	WarehouseServiceURL   string `json:"warehouse_service_url"`
 	CommoditiesServiceURL string `json:"commodities_service_url"`
 	InventoryServiceURL   string `json:"inventory_service_url"`
+	OrderServiceURL       string `json:"order_service_url"`
+	// RoutesFile is a YAML or JSON routing table, reloaded on SIGHUP. When empty the gateway
+	// routes the built-in services to the URLs above. See Route.
+	RoutesFile string `json:"routes_file"`
+
+	// JWT validation. At least one key source is required unless AuthDisabled is set.
+	JWTHMACSecret string `json:"-"`
+	JWTJWKSFile   string `json:"jwt_jwks_file"`
+	JWTIssuer     string `json:"jwt_issuer"`
+	JWTAudience   string `json:"jwt_audience"`
+	AuthDisabled  bool   `json:"auth_disabled"`
+
+	// Rate limits, each written as "rate:burst" in requests per second. See package ratelimit.
+	RateLimitDefault string `json:"rate_limit_default"`
+	// RateLimitGroups overrides the default per route group, e.g. "inventory=20:40,customers=5:10".
+	RateLimitGroups string `json:"rate_limit_groups"`
+	// RateLimitAPIKeys overrides the limit per API key. Only the keys listed here identify a
+	// client; each must be a secret of at least ratelimit.MinAPIKeyLength characters, so it is not printed.
+	RateLimitAPIKeys string `json:"-"`
+	// RateLimitSubjects overrides the limit per authenticated user ID, e.g. "alice=2:2".
+	RateLimitSubjects string `json:"rate_limit_subjects"`
+	// RateLimitIPs overrides the limit per client IP address, e.g. "10.0.0.5=100:200".
+	RateLimitIPs string `json:"rate_limit_ips"`
+	// RateLimitMaxBuckets caps the token buckets kept in memory; clients beyond it share one per route group.
+	RateLimitMaxBuckets int `json:"rate_limit_max_buckets"`
+	// TrustedProxies lists the addresses or CIDR ranges of proxies allowed to report the client's
+	// IP in X-Forwarded-For. When empty, the client IP is always the connecting address.
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// Connection pool shared by the upstream proxies.
+	UpstreamMaxIdleConns           int `json:"upstream_max_idle_conns"`
+	UpstreamMaxIdleConnsPerHost    int `json:"upstream_max_idle_conns_per_host"`
+	UpstreamMaxConnsPerHost        int `json:"upstream_max_conns_per_host"` // 0 means unlimited
+	UpstreamIdleConnTimeoutSeconds int `json:"upstream_idle_conn_timeout_seconds"`
+	UpstreamDialTimeoutSeconds     int `json:"upstream_dial_timeout_seconds"`
+	UpstreamResponseTimeoutSeconds int `json:"upstream_response_timeout_seconds"`
+
+	// Background health probes and circuit breaking per upstream.
+	UpstreamHealthPath         string `json:"upstream_health_path"`
+	HealthCheckIntervalSeconds int    `json:"health_check_interval_seconds"`
+	HealthCheckTimeoutSeconds  int    `json:"health_check_timeout_seconds"`
+	BreakerFailureThreshold    int    `json:"breaker_failure_threshold"`
+	BreakerOpenSeconds         int    `json:"breaker_open_seconds"`
+
+	// Composite views such as /api/views/stock give each service they read this long to answer.
+	ViewUpstreamTimeoutSeconds int `json:"view_upstream_timeout_seconds"`
+	// Commodity and warehouse names resolved by composite views are reused this long; 0 reads them on every request.
+	ViewNameCacheSeconds int `json:"view_name_cache_seconds"`
 }
 
 // Cfg is the global configuration instance.
@@ -31,6 +81,25 @@ func LoadConfig() error {
 		WarehouseServiceURL:   "http://warehouse-service:8085",
 		CommoditiesServiceURL: "http://commodity-service:8086",
 		InventoryServiceURL:   "http://inventory-service:8088",
+		OrderServiceURL:       "http://order-service:8089",
+
+		RateLimitDefault:    "20:40",
+		RateLimitMaxBuckets: 10000,
+
+		UpstreamMaxIdleConns:           256,
+		UpstreamMaxIdleConnsPerHost:    64,
+		UpstreamIdleConnTimeoutSeconds: 90,
+		UpstreamDialTimeoutSeconds:     5,
+		UpstreamResponseTimeoutSeconds: 10, // Matches the server's WriteTimeout
+
+		UpstreamHealthPath:         "/healthz",
+		HealthCheckIntervalSeconds: 10,
+		HealthCheckTimeoutSeconds:  2,
+		BreakerFailureThreshold:    5,
+		BreakerOpenSeconds:         30,
+
+		ViewUpstreamTimeoutSeconds: 3,
+		ViewNameCacheSeconds:       60,
 	}
 
 	// Override with environment variables if set
@@ -54,6 +123,76 @@ func LoadConfig() error {
 	if inventoryURL := os.Getenv("INVENTORY_SERVICE_URL"); inventoryURL != "" {
 		Cfg.InventoryServiceURL = inventoryURL
 	}
+	if orderURL := os.Getenv("ORDER_SERVICE_URL"); orderURL != "" {
+		Cfg.OrderServiceURL = orderURL
+	}
+	Cfg.RoutesFile = os.Getenv("ROUTES_FILE")
+	Cfg.JWTHMACSecret = os.Getenv("JWT_HMAC_SECRET")
+	Cfg.JWTJWKSFile = os.Getenv("JWT_JWKS_FILE")
+	Cfg.JWTIssuer = os.Getenv("JWT_ISSUER")
+	Cfg.JWTAudience = os.Getenv("JWT_AUDIENCE")
+	if disabledStr := os.Getenv("AUTH_DISABLED"); disabledStr != "" {
+		disabled, err := strconv.ParseBool(disabledStr)
+		if err != nil {
+			return fmt.Errorf("invalid AUTH_DISABLED %q: %w", disabledStr, err)
+		}
+		Cfg.AuthDisabled = disabled
+	}
+	if rateLimit := os.Getenv("RATE_LIMIT_DEFAULT"); rateLimit != "" {
+		Cfg.RateLimitDefault = rateLimit
+	}
+	Cfg.RateLimitGroups = os.Getenv("RATE_LIMIT_GROUPS")
+	Cfg.RateLimitAPIKeys = os.Getenv("RATE_LIMIT_API_KEYS")
+	Cfg.RateLimitSubjects = os.Getenv("RATE_LIMIT_SUBJECTS")
+	Cfg.RateLimitIPs = os.Getenv("RATE_LIMIT_IPS")
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP address or CIDR range", proxy)
+		}
+		Cfg.TrustedProxies = append(Cfg.TrustedProxies, proxy)
+	}
+
+	if healthPath := os.Getenv("UPSTREAM_HEALTH_PATH"); healthPath != "" {
+		Cfg.UpstreamHealthPath = healthPath
+	}
+	for env, target := range map[string]*int{
+		"UPSTREAM_MAX_IDLE_CONNS":            &Cfg.UpstreamMaxIdleConns,
+		"UPSTREAM_MAX_IDLE_CONNS_PER_HOST":   &Cfg.UpstreamMaxIdleConnsPerHost,
+		"UPSTREAM_MAX_CONNS_PER_HOST":        &Cfg.UpstreamMaxConnsPerHost,
+		"UPSTREAM_IDLE_CONN_TIMEOUT_SECONDS": &Cfg.UpstreamIdleConnTimeoutSeconds,
+		"UPSTREAM_DIAL_TIMEOUT_SECONDS":      &Cfg.UpstreamDialTimeoutSeconds,
+		"UPSTREAM_RESPONSE_TIMEOUT_SECONDS":  &Cfg.UpstreamResponseTimeoutSeconds,
+		"HEALTH_CHECK_INTERVAL_SECONDS":      &Cfg.HealthCheckIntervalSeconds,
+		"HEALTH_CHECK_TIMEOUT_SECONDS":       &Cfg.HealthCheckTimeoutSeconds,
+		"BREAKER_FAILURE_THRESHOLD":          &Cfg.BreakerFailureThreshold,
+		"BREAKER_OPEN_SECONDS":               &Cfg.BreakerOpenSeconds,
+		"VIEW_UPSTREAM_TIMEOUT_SECONDS":      &Cfg.ViewUpstreamTimeoutSeconds,
+		"VIEW_NAME_CACHE_SECONDS":            &Cfg.ViewNameCacheSeconds,
+		"RATE_LIMIT_MAX_BUCKETS":             &Cfg.RateLimitMaxBuckets,
+	} {
+		if valueStr := os.Getenv(env); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil || value < 0 {
+				return fmt.Errorf("invalid %s %q: must be a non-negative integer", env, valueStr)
+			}
+			*target = value
+		}
+	}
+
+	if Cfg.HealthCheckIntervalSeconds < 1 || Cfg.HealthCheckTimeoutSeconds < 1 {
+		return fmt.Errorf("HEALTH_CHECK_INTERVAL_SECONDS and HEALTH_CHECK_TIMEOUT_SECONDS must be at least 1")
+	}
+	if Cfg.ViewUpstreamTimeoutSeconds < 1 {
+		return fmt.Errorf("VIEW_UPSTREAM_TIMEOUT_SECONDS must be at least 1")
+	}
+
+	if !Cfg.AuthDisabled && Cfg.JWTHMACSecret == "" && Cfg.JWTJWKSFile == "" {
+		return fmt.Errorf("JWT_HMAC_SECRET or JWT_JWKS_FILE must be set (or AUTH_DISABLED=true for local development)")
+	}
 
 	configJSON, _ := json.MarshalIndent(Cfg, "", "  ")
 	fmt.Printf("API Gateway Configuration:\n%s\n", string(configJSON))
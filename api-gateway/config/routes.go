@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Route maps a path prefix on the gateway to a downstream service. A request for
+// Prefix + "/rest" is proxied to URL + Root + "/rest".
+type Route struct {
+	// Name identifies the upstream in logs, metrics, traces and /health.
+	Name string `json:"name" yaml:"name"`
+	// Prefix is the gateway path, e.g. "/api/customers". It must lie under /api.
+	Prefix string `json:"prefix" yaml:"prefix"`
+	// URL is the service's base URL, e.g. "http://customer-service:8087".
+	URL string `json:"url" yaml:"url"`
+	// Root is the service's path for the prefix, e.g. "/customers".
+	Root string `json:"root" yaml:"root"`
+	// Methods lists the HTTP methods proxied; empty means DefaultMethods.
+	Methods []string `json:"methods,omitempty" yaml:"methods"`
+	// TimeoutSeconds bounds each proxied request; 0 leaves only the shared transport's
+	// response timeout. The server's 10 second write timeout still applies.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty" yaml:"timeout_seconds"`
+}
+
+// routesFile is the layout of the file named by ROUTES_FILE. YAML and JSON both parse.
+type routesFile struct {
+	Routes []Route `yaml:"routes"`
+}
+
+// ViewsPrefix is served by the gateway's own composite views, so no route may use it.
+const ViewsPrefix = "/api/views"
+
+// DefaultMethods are proxied when a route does not list its own.
+var DefaultMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// LoadRoutes reads the routing table from Cfg.RoutesFile. Without a file, the table routes
+// the built-in services to the *_SERVICE_URL settings.
+func LoadRoutes() ([]Route, error) {
+	if Cfg.RoutesFile == "" {
+		return validateRoutes(DefaultRoutes())
+	}
+	data, err := os.ReadFile(Cfg.RoutesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes file: %w", err)
+	}
+	var file routesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse routes file %s: %w", Cfg.RoutesFile, err)
+	}
+	routes, err := validateRoutes(file.Routes)
+	if err != nil {
+		return nil, fmt.Errorf("routes file %s: %w", Cfg.RoutesFile, err)
+	}
+	return routes, nil
+}
+
+// DefaultRoutes is the routing table used when no ROUTES_FILE is set.
+func DefaultRoutes() []Route {
+	return []Route{
+		{Name: "customer-service", Prefix: "/api/customers", URL: Cfg.CustomerServiceURL, Root: "/customers"},
+		{Name: "warehouse-service", Prefix: "/api/warehouses", URL: Cfg.WarehouseServiceURL, Root: "/warehouses"},
+		{Name: "commodity-service", Prefix: "/api/commodities", URL: Cfg.CommoditiesServiceURL, Root: "/commodities"},
+		{Name: "inventory-service", Prefix: "/api/inventory", URL: Cfg.InventoryServiceURL, Root: "/inventory"},
+		{Name: "order-service", Prefix: "/api/orders", URL: Cfg.OrderServiceURL, Root: "/orders"},
+	}
+}
+
+// validateRoutes checks every route and fills in default methods. Prefixes may not nest,
+// since /api/a/*proxyPath would already cover /api/a/b.
+func validateRoutes(routes []Route) ([]Route, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no routes defined")
+	}
+	names := map[string]bool{}
+	validated := make([]Route, 0, len(routes))
+	for i, route := range routes {
+		if route.Name == "" {
+			return nil, fmt.Errorf("route %d: name is required", i)
+		}
+		if names[route.Name] {
+			return nil, fmt.Errorf("route %q: duplicate name", route.Name)
+		}
+		names[route.Name] = true
+
+		if !strings.HasPrefix(route.Prefix, "/api/") || strings.HasSuffix(route.Prefix, "/") || strings.ContainsAny(route.Prefix, ":*") {
+			return nil, fmt.Errorf("route %q: prefix %q must start with /api/, not end with / and contain no : or *", route.Name, route.Prefix)
+		}
+		if route.Prefix == ViewsPrefix || strings.HasPrefix(route.Prefix, ViewsPrefix+"/") {
+			return nil, fmt.Errorf("route %q: prefix %q is reserved for the gateway's views", route.Name, route.Prefix)
+		}
+		for _, other := range validated {
+			if route.Prefix == other.Prefix || strings.HasPrefix(route.Prefix, other.Prefix+"/") || strings.HasPrefix(other.Prefix, route.Prefix+"/") {
+				return nil, fmt.Errorf("route %q: prefix %q overlaps route %q", route.Name, route.Prefix, other.Name)
+			}
+		}
+
+		target, err := url.Parse(route.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, fmt.Errorf("route %q: url %q must be an absolute http or https URL", route.Name, route.URL)
+		}
+		if !strings.HasPrefix(route.Root, "/") {
+			return nil, fmt.Errorf("route %q: root %q must start with /", route.Name, route.Root)
+		}
+		if route.TimeoutSeconds < 0 {
+			return nil, fmt.Errorf("route %q: timeout_seconds must not be negative", route.Name)
+		}
+
+		if len(route.Methods) == 0 {
+			route.Methods = DefaultMethods
+		} else {
+			methods := make([]string, 0, len(route.Methods))
+			for _, method := range route.Methods {
+				method = strings.ToUpper(method)
+				if !slices.Contains(DefaultMethods, method) {
+					return nil, fmt.Errorf("route %q: unsupported method %q", route.Name, method)
+				}
+				if !slices.Contains(methods, method) {
+					methods = append(methods, method)
+				}
+			}
+			route.Methods = methods
+		}
+		validated = append(validated, route)
+	}
+	return validated, nil
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadRoutesFile writes content to a routes file and loads it the way the gateway does at
+// startup and on SIGHUP.
+func loadRoutesFile(t *testing.T, name, content string) ([]Route, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	Cfg = &Config{RoutesFile: path}
+	return LoadRoutes()
+}
+
+func TestLoadRoutes(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		wantNames   []string
+		wantMethods [][]string
+		wantTimeout int
+	}{
+		{
+			name: "yaml",
+			file: "routes.yaml",
+			content: `
+routes:
+  - name: customer-service
+    prefix: /api/customers
+    url: http://customer-service:8087
+    root: /customers
+    timeout_seconds: 5
+  - name: order-service
+    prefix: /api/orders
+    url: https://order-service:8088
+    root: /orders
+    methods: [get, POST, GET]
+`,
+			wantNames:   []string{"customer-service", "order-service"},
+			wantMethods: [][]string{DefaultMethods, {"GET", "POST"}},
+			wantTimeout: 5,
+		},
+		{
+			name:        "json",
+			file:        "routes.json",
+			content:     `{"routes": [{"name": "warehouse-service", "prefix": "/api/warehouses", "url": "http://warehouse-service:8085", "root": "/", "timeout_seconds": 2}]}`,
+			wantNames:   []string{"warehouse-service"},
+			wantMethods: [][]string{DefaultMethods},
+			wantTimeout: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := loadRoutesFile(t, tt.file, tt.content)
+			if err != nil {
+				t.Fatalf("LoadRoutes: %v", err)
+			}
+			var names []string
+			for i, route := range routes {
+				names = append(names, route.Name)
+				if !slices.Equal(route.Methods, tt.wantMethods[i]) {
+					t.Errorf("route %s methods = %v, want %v", route.Name, route.Methods, tt.wantMethods[i])
+				}
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("routes = %v, want %v", names, tt.wantNames)
+			}
+			if routes[0].TimeoutSeconds != tt.wantTimeout {
+				t.Errorf("timeout = %d, want %d", routes[0].TimeoutSeconds, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadRoutesWithoutFile(t *testing.T) {
+	Cfg = &Config{
+		CustomerServiceURL:    "http://customer-service:8087",
+		WarehouseServiceURL:   "http://warehouse-service:8085",
+		CommoditiesServiceURL: "http://commodity-service:8086",
+		InventoryServiceURL:   "http://inventory-service:8084",
+		OrderServiceURL:       "http://order-service:8088",
+	}
+	routes, err := LoadRoutes()
+	if err != nil {
+		t.Fatalf("LoadRoutes: %v", err)
+	}
+	if len(routes) != len(DefaultRoutes()) {
+		t.Errorf("%d routes, want the %d built-in ones", len(routes), len(DefaultRoutes()))
+	}
+}
+
+func TestLoadRoutesRejects(t *testing.T) {
+	const valid = `
+  - name: customer-service
+    prefix: /api/customers
+    url: http://customer-service:8087
+    root: /customers`
+
+	tests := []struct {
+		name    string
+		routes  string // appended to a valid route
+		wantErr string
+	}{
+		{name: "no routes", routes: "\n", wantErr: "no routes defined"},
+		{name: "missing name", routes: valid + `
+  - prefix: /api/orders
+    url: http://order-service:8088
+    root: /orders`, wantErr: "name is required"},
+		{name: "duplicate name", routes: valid + `
+  - name: customer-service
+    prefix: /api/orders
+    url: http://order-service:8088
+    root: /orders`, wantErr: "duplicate name"},
+		{name: "prefix outside /api", routes: valid + `
+  - name: health
+    prefix: /health
+    url: http://order-service:8088
+    root: /health`, wantErr: "must start with /api/"},
+		{name: "prefix ending in a slash", routes: valid + `
+  - name: order-service
+    prefix: /api/orders/
+    url: http://order-service:8088
+    root: /orders`, wantErr: "must start with /api/"},
+		{name: "prefix with a wildcard", routes: valid + `
+  - name: order-service
+    prefix: /api/orders/:id
+    url: http://order-service:8088
+    root: /orders`, wantErr: "contain no : or *"},
+		{name: "views prefix", routes: valid + `
+  - name: views
+    prefix: /api/views
+    url: http://order-service:8088
+    root: /views`, wantErr: "reserved for the gateway's views"},
+		{name: "prefix below the views prefix", routes: valid + `
+  - name: stock
+    prefix: /api/views/stock
+    url: http://order-service:8088
+    root: /stock`, wantErr: "reserved for the gateway's views"},
+		{name: "same prefix", routes: valid + `
+  - name: order-service
+    prefix: /api/customers
+    url: http://order-service:8088
+    root: /orders`, wantErr: "overlaps route \"customer-service\""},
+		{name: "nested prefix", routes: valid + `
+  - name: order-service
+    prefix: /api/customers/orders
+    url: http://order-service:8088
+    root: /orders`, wantErr: "overlaps route \"customer-service\""},
+		{name: "url without scheme", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: order-service:8088
+    root: /orders`, wantErr: "absolute http or https URL"},
+		{name: "url with another scheme", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: ftp://order-service
+    root: /orders`, wantErr: "absolute http or https URL"},
+		{name: "url without host", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: http://
+    root: /orders`, wantErr: "absolute http or https URL"},
+		{name: "relative root", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: http://order-service:8088
+    root: orders`, wantErr: "must start with /"},
+		{name: "negative timeout", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: http://order-service:8088
+    root: /orders
+    timeout_seconds: -1`, wantErr: "timeout_seconds must not be negative"},
+		{name: "unknown method", routes: valid + `
+  - name: order-service
+    prefix: /api/orders
+    url: http://order-service:8088
+    root: /orders
+    methods: [GET, TRACE]`, wantErr: "unsupported method \"TRACE\""},
+		{name: "malformed yaml", routes: valid + "\n  - name: [", wantErr: "failed to parse routes file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := loadRoutesFile(t, "routes.yaml", "routes:"+tt.routes+"\n")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadRoutes = %v, %v; want an error containing %q", routes, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		Cfg = &Config{RoutesFile: filepath.Join(t.TempDir(), "routes.yaml")}
+		if _, err := LoadRoutes(); err == nil || !strings.Contains(err.Error(), "failed to read routes file") {
+			t.Errorf("LoadRoutes error = %v, want a read error", err)
+		}
+	})
+}
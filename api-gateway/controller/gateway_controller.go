@@ -1,129 +1,104 @@
 package controller
 
 import (
+	"api-gateway/circuit"
 	"api-gateway/config"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
-	"strings" // Ensure strings package is imported
-
-	"github.com/gin-gonic/gin"
+	"sync"
+	"time"
+	"wms-common/tracing"
 )
 
+// Route is an entry of the routing table bound to the upstream that serves it.
+type Route struct {
+	config.Route
+	Upstream *Upstream
+}
+
 // GatewayController handles proxying requests to various microservices.
+// Each route has one long-lived Upstream, and all of them share one connection pool.
 type GatewayController struct {
-	CustomerServiceURL    *url.URL
-	WarehouseServiceURL   *url.URL
-	CommoditiesServiceURL *url.URL
-	InventoryServiceURL   *url.URL
-}
+	transport *http.Transport
 
-// NewGatewayController creates a new instance of GatewayController.
-func NewGatewayController() *GatewayController {
-	customerURL, err := url.Parse(config.Cfg.CustomerServiceURL)
-	if err != nil {
-		log.Fatalf("Invalid Customer Service URL: %v", err)
-	}
-	warehouseURL, err := url.Parse(config.Cfg.WarehouseServiceURL)
-	if err != nil {
-		log.Fatalf("Invalid Warehouse Service URL: %v", err)
-	}
-	commoditiesURL, err := url.Parse(config.Cfg.CommoditiesServiceURL)
-	if err != nil {
-		log.Fatalf("Invalid Commodities Service URL: %v", err)
-	}
-	inventoryURL, err := url.Parse(config.Cfg.InventoryServiceURL)
-	if err != nil {
-		log.Fatalf("Invalid Inventory Service URL: %v", err)
-	}
+	routesM sync.RWMutex
+	routes  []Route
 
-	return &GatewayController{
-		CustomerServiceURL:    customerURL,
-		WarehouseServiceURL:   warehouseURL,
-		CommoditiesServiceURL: commoditiesURL,
-		InventoryServiceURL:   inventoryURL,
-	}
+	names nameCache
 }
 
-// ProxyToService creates a generic reverse proxy for a given target URL.
-// `apiPathPrefix` is the path on the API Gateway (e.g., "/api/customers")
-// `downstreamRootPath` is the root path on the target service (e.g., "/customers")
-func (gc *GatewayController) ProxyToService(targetURL *url.URL, apiPathPrefix string, downstreamRootPath string) gin.HandlerFunc {
-	proxy := httputil.NewSingleHostReverseProxy(targetURL)
-
-	proxy.Director = func(req *http.Request) {
-		originalPath := req.URL.Path
-
-		// Extract the portion of the path that comes after the API Gateway's prefix.
-		// Example: if originalPath is "/api/customers/123" and apiPathPrefix is "/api/customers"
-		// then proxyPathSegment will be "/123".
-		// If originalPath is "/api/customers" or "/api/customers/", proxyPathSegment will be "" or "/".
-		proxyPathSegment := strings.TrimPrefix(originalPath, apiPathPrefix)
-
-		// Normalize the proxyPathSegment and construct the final path for the downstream service.
-		var finalDownstreamPath string
-		if proxyPathSegment == "" || proxyPathSegment == "/" {
-			// If client requested /api/customers or /api/customers/,
-			// send just /customers to the downstream service (no trailing slash).
-			finalDownstreamPath = downstreamRootPath
-		} else {
-			// If client requested /api/customers/123, send /customers/123.
-			// Trim potential leading slash from proxyPathSegment for correct concatenation.
-			trimmedProxyPathSegment := strings.TrimPrefix(proxyPathSegment, "/")
-			// Ensure downstreamRootPath does not have a trailing slash for concatenation
-			cleanedDownstreamRootPath := strings.TrimSuffix(downstreamRootPath, "/")
-			finalDownstreamPath = fmt.Sprintf("%s/%s", cleanedDownstreamRootPath, trimmedProxyPathSegment)
-		}
-
-		req.URL.Path = finalDownstreamPath
-		req.URL.RawQuery = req.URL.RawQuery // Preserve original query parameters
-
-		// Important: Set the Host header to the target service's host (e.g., "customer-service:8087")
-		// This is crucial for Docker's internal DNS resolution.
-		req.Host = targetURL.Host
-		req.URL.Scheme = targetURL.Scheme
-		req.URL.Host = targetURL.Host
-
-		log.Printf("Proxying request: Original Client Path: %s, Rewritten Target Path: '%s', Target Host: %s, Query: %s\n",
-			originalPath, req.URL.Path, req.URL.Host, req.URL.RawQuery)
-	}
+// NewGatewayController creates a new instance of GatewayController with an empty routing
+// table; install one with BindRoutes and SetRoutes.
+func NewGatewayController() *GatewayController {
+	transport := NewTransport(TransportConfig{
+		MaxIdleConns:          config.Cfg.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost:   config.Cfg.UpstreamMaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.Cfg.UpstreamMaxConnsPerHost,
+		IdleConnTimeout:       time.Duration(config.Cfg.UpstreamIdleConnTimeoutSeconds) * time.Second,
+		DialTimeout:           time.Duration(config.Cfg.UpstreamDialTimeoutSeconds) * time.Second,
+		ResponseHeaderTimeout: time.Duration(config.Cfg.UpstreamResponseTimeoutSeconds) * time.Second,
+	})
+	return &GatewayController{transport: transport}
+}
 
-	proxy.ErrorHandler = func(rw http.ResponseWriter, req *http.Request, err error) {
-		log.Printf("Proxy Error: %v for request %s %s\n", err, req.Method, req.URL.Path)
-		rw.WriteHeader(http.StatusBadGateway)
-		_, _ = rw.Write([]byte(fmt.Sprintf("Bad Gateway: Could not reach upstream service (%s) or proxy error occurred: %v", targetURL.String(), err)))
+// BindRoutes creates the upstream for each route. A route whose upstream settings are the same
+// as in the current table keeps its Upstream, so circuit state and health survive a reload.
+// Nothing changes until the result is passed to SetRoutes.
+func (gc *GatewayController) BindRoutes(routes []config.Route) ([]Route, error) {
+	current := map[string]Route{}
+	for _, route := range gc.Routes() {
+		current[route.Name] = route
 	}
 
-	return func(c *gin.Context) {
-		proxy.ServeHTTP(c.Writer, c.Request)
+	bound := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		if existing, ok := current[route.Name]; ok && sameUpstream(existing.Route, route) {
+			bound = append(bound, Route{Route: route, Upstream: existing.Upstream})
+			continue
+		}
+		target, err := url.Parse(route.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for route %q: %w", route.Name, err)
+		}
+		// Proxied requests are traced; the background health probes use the bare transport.
+		upstream := NewUpstream(route.Name, target, route.Prefix, route.Root, tracing.ProxyTransport(route.Name, gc.transport), gc.newBreaker())
+		upstream.Timeout = time.Duration(route.TimeoutSeconds) * time.Second
+		bound = append(bound, Route{Route: route, Upstream: upstream})
 	}
+	return bound, nil
 }
 
-// ProxyToCustomerService proxies requests to the Customer Service.
-func (gc *GatewayController) ProxyToCustomerService(c *gin.Context) {
-	// API Gateway route is /api/customers/*proxyPath
-	// Downstream route is /customers
-	gc.ProxyToService(gc.CustomerServiceURL, "/api/customers", "/customers")(c)
+// SetRoutes makes routes the current table. Its upstreams are the ones health checked and reported.
+func (gc *GatewayController) SetRoutes(routes []Route) {
+	gc.routesM.Lock()
+	defer gc.routesM.Unlock()
+	gc.routes = routes
 }
 
-// ProxyToWarehouseService proxies requests to the Warehouse Service.
-func (gc *GatewayController) ProxyToWarehouseService(c *gin.Context) {
-	gc.ProxyToService(gc.WarehouseServiceURL, "/api/warehouses", "/warehouses")(c)
+// Routes returns the current routing table.
+func (gc *GatewayController) Routes() []Route {
+	gc.routesM.RLock()
+	defer gc.routesM.RUnlock()
+	return gc.routes
 }
 
-// ProxyToCommoditiesService proxies requests to the Commodity Service.
-func (gc *GatewayController) ProxyToCommoditiesService(c *gin.Context) {
-	gc.ProxyToService(gc.CommoditiesServiceURL, "/api/commodities", "/commodities")(c)
+// Upstreams lists every service behind the gateway.
+func (gc *GatewayController) Upstreams() []*Upstream {
+	routes := gc.Routes()
+	upstreams := make([]*Upstream, 0, len(routes))
+	for _, route := range routes {
+		upstreams = append(upstreams, route.Upstream)
+	}
+	return upstreams
 }
 
-// ProxyToInventoryService proxies requests to the Inventory Service.
-func (gc *GatewayController) ProxyToInventoryService(c *gin.Context) {
-	gc.ProxyToService(gc.InventoryServiceURL, "/api/inventory", "/inventory")(c)
+func (gc *GatewayController) newBreaker() *circuit.Breaker {
+	return circuit.NewBreaker(config.Cfg.BreakerFailureThreshold, time.Duration(config.Cfg.BreakerOpenSeconds)*time.Second)
 }
 
-// HealthCheck provides a simple health check endpoint.
-func HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "API Gateway is healthy"})
+// sameUpstream reports whether two routes proxy the same way. Methods are left out because
+// they only affect which requests the router lets through.
+func sameUpstream(a, b config.Route) bool {
+	return a.Name == b.Name && a.Prefix == b.Prefix && a.URL == b.URL && a.Root == b.Root && a.TimeoutSeconds == b.TimeoutSeconds
 }
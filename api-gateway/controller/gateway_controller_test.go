@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"api-gateway/config"
+	"testing"
+)
+
+func TestBindRoutesKeepsUnchangedUpstreams(t *testing.T) {
+	gc := NewGatewayController()
+	initial := []config.Route{
+		{Name: "customer-service", Prefix: "/api/customers", URL: "http://customer-service:8087", Root: "/customers"},
+		{Name: "order-service", Prefix: "/api/orders", URL: "http://order-service:8088", Root: "/orders"},
+	}
+	bound, err := gc.BindRoutes(initial)
+	if err != nil {
+		t.Fatalf("BindRoutes: %v", err)
+	}
+	gc.SetRoutes(bound)
+	before := map[string]*Upstream{}
+	for _, route := range bound {
+		before[route.Name] = route.Upstream
+	}
+
+	tests := []struct {
+		name     string
+		change   func(*config.Route)
+		wantKept bool
+	}{
+		{name: "unchanged", change: func(*config.Route) {}, wantKept: true},
+		{name: "methods changed", change: func(r *config.Route) { r.Methods = []string{"GET"} }, wantKept: true},
+		{name: "url changed", change: func(r *config.Route) { r.URL = "http://orders-v2:8088" }},
+		{name: "root changed", change: func(r *config.Route) { r.Root = "/v2/orders" }},
+		{name: "prefix changed", change: func(r *config.Route) { r.Prefix = "/api/sales-orders" }},
+		{name: "timeout changed", change: func(r *config.Route) { r.TimeoutSeconds = 5 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := append([]config.Route(nil), initial...)
+			tt.change(&routes[1])
+			rebound, err := gc.BindRoutes(routes)
+			if err != nil {
+				t.Fatalf("BindRoutes: %v", err)
+			}
+			if rebound[0].Upstream != before["customer-service"] {
+				t.Error("untouched route got a new upstream")
+			}
+			orders := rebound[1].Upstream
+			if kept := orders == before["order-service"]; kept != tt.wantKept {
+				t.Errorf("order-service upstream kept = %v, want %v", kept, tt.wantKept)
+			}
+			if kept := orders.breaker == before["order-service"].breaker; kept != tt.wantKept {
+				t.Errorf("order-service breaker kept = %v, want %v", kept, tt.wantKept)
+			}
+			if orders.Target.String() != routes[1].URL {
+				t.Errorf("order-service target = %s, want %s", orders.Target, routes[1].URL)
+			}
+		})
+	}
+
+	// Binding alone does not change the table in use.
+	if current := gc.Routes(); current[1].Upstream != before["order-service"] {
+		t.Error("BindRoutes replaced the current routing table")
+	}
+}
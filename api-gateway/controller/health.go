@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probeResult is the outcome of the latest background health probe of an upstream.
+type probeResult struct {
+	CheckedAt time.Time
+	Healthy   bool
+	Detail    string
+}
+
+// UpstreamHealth is what /health reports for one upstream.
+type UpstreamHealth struct {
+	Circuit             string     `json:"circuit"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Healthy             *bool      `json:"healthy,omitempty"` // nil until the first probe completes
+	LastCheck           *time.Time `json:"lastCheck,omitempty"`
+	Detail              string     `json:"detail,omitempty"`
+}
+
+// HealthProbe configures the background probes of the upstreams' health endpoints.
+type HealthProbe struct {
+	Path     string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// probe calls the upstream's health endpoint once and feeds the result to its circuit
+// breaker, so a service that is down opens its circuit even when no traffic is flowing
+// and a recovered one gets its trial request without waiting out the cooldown.
+func (u *Upstream) probe(ctx context.Context, client *http.Client, probe HealthProbe) {
+	ctx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	result := probeResult{CheckedAt: time.Now(), Healthy: true}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Target.JoinPath(probe.Path).String(), nil)
+	if err != nil {
+		result.Healthy, result.Detail = false, "invalid health check URL"
+	} else if resp, err := client.Do(req); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return // The gateway is shutting down.
+		}
+		result.Healthy, result.Detail = false, "unreachable"
+	} else {
+		resp.Body.Close()
+		// Any answer below 500 shows the service is up and serving requests.
+		if resp.StatusCode >= http.StatusInternalServerError {
+			result.Healthy, result.Detail = false, fmt.Sprintf("health check returned %d", resp.StatusCode)
+		}
+	}
+
+	u.healthM.Lock()
+	wasHealthy := u.health.CheckedAt.IsZero() || u.health.Healthy
+	u.health = result
+	u.healthM.Unlock()
+
+	u.breaker.Probe(result.Healthy)
+	if wasHealthy != result.Healthy {
+		slog.Info("upstream health changed", "upstream", u.Name, "healthy", result.Healthy, "detail", result.Detail)
+	}
+}
+
+// Health reports the upstream's circuit state and latest probe result.
+func (u *Upstream) Health() UpstreamHealth {
+	state, failures := u.breaker.State()
+	health := UpstreamHealth{Circuit: state.String(), ConsecutiveFailures: failures}
+
+	u.healthM.RLock()
+	defer u.healthM.RUnlock()
+	if !u.health.CheckedAt.IsZero() {
+		healthy, checkedAt := u.health.Healthy, u.health.CheckedAt
+		health.Healthy = &healthy
+		health.LastCheck = &checkedAt
+		health.Detail = u.health.Detail
+	}
+	return health
+}
+
+// StartHealthChecks probes every upstream at the configured interval until ctx is cancelled.
+// Each round probes the upstreams of the current routing table, so routes added by a reload
+// are picked up at the next round.
+func (gc *GatewayController) StartHealthChecks(ctx context.Context, probe HealthProbe) {
+	client := &http.Client{Transport: gc.transport}
+	go func() {
+		ticker := time.NewTicker(probe.Interval)
+		defer ticker.Stop()
+		for {
+			var wg sync.WaitGroup
+			for _, u := range gc.Upstreams() {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					u.probe(ctx, client, probe)
+				}()
+			}
+			wg.Wait()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// HealthCheck reports the gateway's own health and the state of each upstream. The gateway is
+// "degraded" while any upstream's circuit is not closed.
+func (gc *GatewayController) HealthCheck(c *gin.Context) {
+	status := "ok"
+	upstreams := map[string]UpstreamHealth{}
+	for _, u := range gc.Upstreams() {
+		health := u.Health()
+		if health.Circuit != "closed" {
+			status = "degraded"
+		}
+		upstreams[u.Name] = health
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status, "upstreams": upstreams})
+}
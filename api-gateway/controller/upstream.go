@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"api-gateway/circuit"
+	"api-gateway/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wms-common/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransportConfig tunes the connection pool shared by every upstream proxy.
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int // 0 means unlimited
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+// NewTransport creates the http.Transport the upstream proxies send requests through.
+// The default transport keeps only two idle connections per host, so under concurrent load
+// most requests to a service would open a fresh TCP connection.
+func NewTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// bufferSize is the size of the buffers proxies copy response bodies through.
+const bufferSize = 32 * 1024
+
+// bufferPool recycles the buffers proxies copy response bodies through. Without it every
+// proxied request allocates a fresh 32 KiB buffer. The pool holds array pointers, which Put
+// gets back from the slice without allocating, unlike a pointer to the slice header.
+type bufferPool struct {
+	pool sync.Pool
+}
+
+func newBufferPool() *bufferPool {
+	return &bufferPool{pool: sync.Pool{New: func() any { return new([bufferSize]byte) }}}
+}
+
+func (p *bufferPool) Get() []byte { return p.pool.Get().(*[bufferSize]byte)[:] }
+
+func (p *bufferPool) Put(buf []byte) {
+	if len(buf) == bufferSize {
+		p.pool.Put((*[bufferSize]byte)(buf))
+	}
+}
+
+// sharedBuffers is used by every Upstream.
+var sharedBuffers = newBufferPool()
+
+// Upstream is a downstream service behind the gateway. Its reverse proxy is built once and
+// reused for every request, and its circuit breaker turns callers away while it is failing.
+type Upstream struct {
+	Name    string
+	Target  *url.URL
+	Timeout time.Duration // Bounds each proxied request; 0 leaves only the transport's limits
+	root    string
+	proxy   *httputil.ReverseProxy
+	client  *http.Client
+
+	breaker *circuit.Breaker
+	health  probeResult
+	healthM sync.RWMutex
+}
+
+// NewUpstream creates the proxy for one service.
+// `apiPathPrefix` is the path on the API Gateway (e.g., "/api/customers")
+// `downstreamRootPath` is the root path on the target service (e.g., "/customers")
+func NewUpstream(name string, target *url.URL, apiPathPrefix, downstreamRootPath string, transport http.RoundTripper, breaker *circuit.Breaker) *Upstream {
+	u := &Upstream{Name: name, Target: target, root: downstreamRootPath, breaker: breaker}
+	u.client = &http.Client{Transport: transport}
+	u.proxy = newReverseProxy(target, apiPathPrefix, downstreamRootPath, transport)
+	u.proxy.BufferPool = sharedBuffers
+	u.proxy.ModifyResponse = u.recordResponse
+	u.proxy.ErrorHandler = u.handleProxyError
+	return u
+}
+
+// statusClientClosedRequest is the status a proxied request is logged with when the client
+// went away before the service answered.
+const statusClientClosedRequest = 499
+
+// Handler proxies the request to the upstream, or fails fast with 503 while its circuit is open.
+func (u *Upstream) Handler(c *gin.Context) {
+	t, ok := u.breaker.Allow()
+	if !ok {
+		metrics.UpstreamError(u.Name, metrics.ReasonCircuitOpen)
+		retryAfter := int(u.breaker.RetryAfter().Round(time.Second).Seconds())
+		c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		u.unavailable(c.Writer)
+		return
+	}
+	// The outcome is judged from the status the gateway answered with, so the ticket needs no
+	// request context to reach the proxy callbacks; deriving one would copy the request.
+	defer func() { u.settle(t, c.Writer.Status()) }()
+	if u.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), u.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+	}
+	start := time.Now()
+	u.proxy.ServeHTTP(c.Writer, c.Request)
+	metrics.ObserveUpstream(u.Name, c.Writer.Status(), start)
+}
+
+// ErrCircuitOpen is returned by Get while the upstream's circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// Get requests path below the upstream's root on the gateway's own behalf, e.g. to build a
+// composite view. It goes through the same circuit breaker and metrics as proxied requests.
+// The caller must close the response body.
+func (u *Upstream) Get(ctx context.Context, path string, query url.Values, header http.Header) (*http.Response, error) {
+	t, ok := u.breaker.Allow()
+	if !ok {
+		metrics.UpstreamError(u.Name, metrics.ReasonCircuitOpen)
+		return nil, ErrCircuitOpen
+	}
+	target := u.Target.JoinPath(u.root, path)
+	target.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s request: %w", u.Name, err)
+	}
+	req.Header = header.Clone()
+
+	start := time.Now()
+	resp, err := u.client.Do(req)
+	if err != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			metrics.UpstreamError(u.Name, metrics.ReasonTimeout)
+			u.breaker.Failure(t)
+		case ctx.Err() == nil:
+			metrics.UpstreamError(u.Name, metrics.ReasonUnreachable)
+			u.breaker.Failure(t)
+		default:
+			// The caller gave up; that says nothing about the upstream's health.
+			u.breaker.Release(t)
+		}
+		return nil, err
+	}
+	metrics.ObserveUpstream(u.Name, resp.StatusCode, start)
+	_ = u.recordResponse(resp)
+	u.settle(t, resp.StatusCode)
+	return resp, nil
+}
+
+// failed reports whether a status is a gateway-level 5xx, which counts against the service.
+// Other errors are the service doing its job, so they count as successes.
+func failed(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// settle reports the outcome of a request let through with t to the circuit breaker. A client
+// that went away says nothing about the service's health, so its ticket is only released.
+func (u *Upstream) settle(t circuit.Ticket, status int) {
+	switch {
+	case status == statusClientClosedRequest:
+		u.breaker.Release(t)
+	case failed(status):
+		u.breaker.Failure(t)
+	default:
+		u.breaker.Success(t)
+	}
+}
+
+// recordResponse counts gateway-level 5xx answers from the service. The service echoes the
+// request ID the gateway has already set on the response, so its copy is dropped.
+func (u *Upstream) recordResponse(resp *http.Response) error {
+	resp.Header.Del(logging.RequestIDHeader)
+	if failed(resp.StatusCode) {
+		metrics.UpstreamError(u.Name, metrics.ReasonBadStatus)
+	}
+	return nil
+}
+
+// handleProxyError answers with a JSON 503 when the service cannot be reached, or a 504 when
+// it did not answer within the route's timeout. The upstream URL and the raw error are logged
+// only, never sent to the client.
+func (u *Upstream) handleProxyError(rw http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		metrics.UpstreamError(u.Name, metrics.ReasonTimeout)
+		logging.Logger(req.Context()).Warn("proxy request timed out", "upstream", u.Name, "timeout", u.Timeout.String(),
+			"method", req.Method, "path", req.URL.Path)
+		writeError(rw, http.StatusGatewayTimeout, u.Name+" did not respond in time", "upstream_timeout")
+		return
+	}
+	if req.Context().Err() != nil {
+		logging.Logger(req.Context()).Info("proxy request cancelled by client", "upstream", u.Name, "method", req.Method, "path", req.URL.Path)
+		rw.WriteHeader(statusClientClosedRequest)
+		return
+	}
+	metrics.UpstreamError(u.Name, metrics.ReasonUnreachable)
+	logging.Logger(req.Context()).Error("proxy request failed", "upstream", u.Name, "target", u.Target.String(),
+		"method", req.Method, "path", req.URL.Path, "error", err)
+	u.unavailable(rw)
+}
+
+func (u *Upstream) unavailable(rw http.ResponseWriter) {
+	writeError(rw, http.StatusServiceUnavailable, u.Name+" is temporarily unavailable", "upstream_unavailable")
+}
+
+func writeError(rw http.ResponseWriter, status int, message, code string) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	body, _ := json.Marshal(gin.H{"error": message, "code": code})
+	_, _ = rw.Write(body)
+}
+
+func newReverseProxy(targetURL *url.URL, apiPathPrefix, downstreamRootPath string, transport http.RoundTripper) *httputil.ReverseProxy {
+	// Ensure downstreamRootPath does not have a trailing slash for concatenation
+	cleanedDownstreamRootPath := strings.TrimSuffix(downstreamRootPath, "/")
+
+	director := func(req *http.Request) {
+		originalPath := req.URL.Path
+
+		// Extract the portion of the path that comes after the API Gateway's prefix.
+		// Example: if originalPath is "/api/customers/123" and apiPathPrefix is "/api/customers"
+		// then proxyPathSegment will be "/123".
+		// If originalPath is "/api/customers" or "/api/customers/", proxyPathSegment will be "" or "/".
+		proxyPathSegment := strings.TrimPrefix(originalPath, apiPathPrefix)
+
+		// Normalize the proxyPathSegment and construct the final path for the downstream service.
+		if proxyPathSegment == "" || proxyPathSegment == "/" {
+			// If client requested /api/customers or /api/customers/,
+			// send just /customers to the downstream service (no trailing slash).
+			req.URL.Path = downstreamRootPath
+		} else {
+			// If client requested /api/customers/123, send /customers/123.
+			req.URL.Path = cleanedDownstreamRootPath + "/" + strings.TrimPrefix(proxyPathSegment, "/")
+		}
+		req.URL.RawPath = "" // Query parameters are left untouched on req.URL
+
+		// Important: Set the Host header to the target service's host (e.g., "customer-service:8087")
+		// This is crucial for Docker's internal DNS resolution.
+		req.Host = targetURL.Host
+		req.URL.Scheme = targetURL.Scheme
+		req.URL.Host = targetURL.Host
+		if _, ok := req.Header["User-Agent"]; !ok {
+			// Keep net/http from adding its own User-Agent, as NewSingleHostReverseProxy does.
+			req.Header.Set("User-Agent", "")
+		}
+	}
+
+	return &httputil.ReverseProxy{
+		Director:  director,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"api-gateway/circuit"
+	"api-gateway/config"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The benchmarks compare the gateway's previous behaviour, a new reverse proxy on the default
+// transport for every request, with one long-lived Upstream on the tuned transport. Run with
+//
+//	go test ./controller -run '^$' -bench Proxy -benchmem -cpu 1,8
+//
+// On a Linux VM with Go 1.27 the shared upstream took about 55µs, 13.8 KB and 104 allocations per
+// request at -cpu 1 against 80µs, 46.4 KB and 101 for a proxy per request; at -cpu 8, 85µs and
+// 106 allocations against 135µs and 128, with 0.03 instead of 0.4 new connections per request.
+// Four of the shared upstream's allocations are the timer net/http arms for the transport's
+// ResponseHeaderTimeout, which the default transport does not set.
+func TestMain(m *testing.M) {
+	config.Cfg = &config.Config{
+		BreakerFailureThreshold:    5,
+		BreakerOpenSeconds:         30,
+		ViewUpstreamTimeoutSeconds: 1,
+		ViewNameCacheSeconds:       60,
+	}
+	gin.SetMode(gin.ReleaseMode)
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newBenchmarkBackend starts a stand-in service and returns its URL and a count of the
+// connections opened to it.
+func newBenchmarkBackend(b *testing.B) (*url.URL, *atomic.Int64) {
+	b.Helper()
+	conns := &atomic.Int64{}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"id":"6ad27f5899789d609895b0dd","name":"Main","location":"Dock 1","capacity":100}]`)
+	}))
+	backend.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	backend.Start()
+	b.Cleanup(backend.Close)
+	target, err := url.Parse(backend.URL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return target, conns
+}
+
+// recorder adds the CloseNotify method httputil.ReverseProxy calls through gin's response writer.
+type recorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (recorder) CloseNotify() <-chan bool { return nil }
+
+// benchmarkParallelism is the number of concurrent clients per CPU, enough to need more
+// connections than the default transport keeps idle per host.
+const benchmarkParallelism = 16
+
+// runProxyBenchmark serves GET /api/warehouses/:id through handler from concurrent clients and
+// reports how many upstream connections were opened per request.
+func runProxyBenchmark(b *testing.B, conns *atomic.Int64, handler gin.HandlerFunc) {
+	router := gin.New()
+	router.GET("/api/warehouses/*proxyPath", handler)
+
+	b.ReportAllocs()
+	b.SetParallelism(benchmarkParallelism)
+	b.ResetTimer()
+	opened := conns.Load()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rec := recorder{httptest.NewRecorder()}
+			req := httptest.NewRequest(http.MethodGet, "/api/warehouses/6ad27f5899789d609895b0dd?limit=10", nil)
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				b.Errorf("unexpected status %d", rec.Code)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(conns.Load()-opened)/float64(b.N), "conns/op")
+}
+
+func BenchmarkProxyPerRequest(b *testing.B) {
+	target, conns := newBenchmarkBackend(b)
+	runProxyBenchmark(b, conns, func(c *gin.Context) {
+		proxy := newReverseProxy(target, "/api/warehouses", "/warehouses", http.DefaultTransport)
+		proxy.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+func BenchmarkProxyShared(b *testing.B) {
+	target, conns := newBenchmarkBackend(b)
+	transport := NewTransport(TransportConfig{
+		MaxIdleConns:          256,
+		MaxIdleConnsPerHost:   64,
+		IdleConnTimeout:       90 * time.Second,
+		DialTimeout:           5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+	})
+	b.Cleanup(transport.CloseIdleConnections)
+	upstream := NewUpstream("warehouse-service", target, "/api/warehouses", "/warehouses", transport, circuit.NewBreaker(5, 30*time.Second))
+	runProxyBenchmark(b, conns, upstream.Handler)
+}
+
+func TestCancelledTrialFreesTheCircuit(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(backend.Close)
+	target, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker := circuit.NewBreaker(1, time.Hour)
+	upstream := NewUpstream("warehouse-service", target, "/api/warehouses", "/warehouses", http.DefaultTransport, breaker)
+
+	// A failed probe opens the circuit and a passing one half-opens it for a trial.
+	breaker.Probe(false)
+	breaker.Probe(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := upstream.Get(ctx, "", nil, http.Header{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Get error = %v, want %v", err, context.Canceled)
+	}
+	if state, _ := breaker.State(); state != circuit.HalfOpen {
+		t.Fatalf("state after a cancelled trial = %s, want half-open", state)
+	}
+
+	resp, err := upstream.Get(context.Background(), "", nil, http.Header{})
+	if err != nil {
+		t.Fatalf("Get after a cancelled trial: %v", err)
+	}
+	resp.Body.Close()
+	if state, _ := breaker.State(); state != circuit.Closed {
+		t.Errorf("state after a successful trial = %s, want closed", state)
+	}
+}
+
+func TestHandlerSettlesTheTrial(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int  // Answered by the service
+		down       bool // The service cannot be reached
+		cancelled  bool // The client went away first
+		wantStatus int
+		wantState  circuit.State
+	}{
+		{name: "service answers", status: http.StatusOK, wantStatus: http.StatusOK, wantState: circuit.Closed},
+		{name: "service rejects the request", status: http.StatusNotFound, wantStatus: http.StatusNotFound, wantState: circuit.Closed},
+		{name: "service unavailable", status: http.StatusServiceUnavailable, wantStatus: http.StatusServiceUnavailable, wantState: circuit.Open},
+		{name: "service down", down: true, wantStatus: http.StatusServiceUnavailable, wantState: circuit.Open},
+		{name: "client gone", status: http.StatusOK, cancelled: true, wantStatus: statusClientClosedRequest, wantState: circuit.HalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(tt.status) }))
+			t.Cleanup(backend.Close)
+			target, err := url.Parse(backend.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.down {
+				backend.Close()
+			}
+			breaker := circuit.NewBreaker(1, time.Hour)
+			upstream := NewUpstream("warehouse-service", target, "/api/warehouses", "/warehouses", http.DefaultTransport, breaker)
+			breaker.Probe(false)
+			breaker.Probe(true)
+
+			router := gin.New()
+			router.GET("/api/warehouses/*proxyPath", upstream.Handler)
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelled {
+				cancel()
+			}
+			defer cancel()
+			rec := recorder{httptest.NewRecorder()}
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/warehouses/", nil).WithContext(ctx))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if state, _ := breaker.State(); state != tt.wantState {
+				t.Errorf("state after the trial = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
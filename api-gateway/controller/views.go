@@ -0,0 +1,355 @@
+package controller
+
+import (
+	"api-gateway/auth"
+	"api-gateway/config"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"wms-common/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Composite views find the services they read from by route name in the routing table.
+const (
+	inventoryUpstream = "inventory-service"
+	commodityUpstream = "commodity-service"
+	warehouseUpstream = "warehouse-service"
+)
+
+const (
+	// nextCursorHeader carries the cursor for the following page of a list, as on the services.
+	nextCursorHeader = "X-Next-Cursor"
+	// idsFilter is the list filter of the lookup services that reads a known set of records.
+	idsFilter = "ids"
+	// maxCachedNames bounds the names cached per service; expired ones are dropped beyond it.
+	maxCachedNames = 10000
+)
+
+// StockRow is one inventory record with the names of its commodity and warehouse. A name is
+// left out when it could not be resolved.
+type StockRow struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"productId"`
+	CommodityName string    `json:"commodityName,omitempty"`
+	WarehouseID   string    `json:"warehouseId"`
+	WarehouseName string    `json:"warehouseName,omitempty"`
+	Quantity      int       `json:"quantity"`
+	Reserved      int       `json:"reserved"`
+	Available     int       `json:"available"`
+	Location      string    `json:"location"`
+	LastUpdated   time.Time `json:"lastUpdated"`
+}
+
+// StockView is the body of GET /api/views/stock. When a lookup service fails the rows are
+// still returned without the names it would have supplied, Partial is set and Errors says
+// which service failed and why.
+type StockView struct {
+	Items   []StockRow        `json:"items"`
+	Partial bool              `json:"partial"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// namedEntity is the part of a commodity or warehouse record a view needs.
+type namedEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// upstreamStatusError is an answer from a service other than 200 OK.
+type upstreamStatusError struct {
+	status int
+	body   []byte
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("returned status %d", e.status)
+}
+
+// StockView handles GET /api/views/stock. It reads a page of inventory, then looks up the names
+// of the commodities and warehouses on that page with one request to each service, sent
+// concurrently under their own timeouts, and joins them into rows. Names are cached for VIEW_NAME_CACHE_SECONDS, so a
+// rename may take that long to show.
+// The query string (limit, after, sort and filters) is passed to the Inventory service, whose
+// X-Next-Cursor is returned for paging. Inventory is required; the lookups are best-effort.
+func (gc *GatewayController) StockView(c *gin.Context) {
+	timeout := time.Duration(config.Cfg.ViewUpstreamTimeoutSeconds) * time.Second
+	header := forwardedHeader(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	inventory, nextCursor, err := gc.fetchInventory(ctx, c.Request.URL.Query(), header)
+	cancel()
+	if err != nil {
+		gc.inventoryFailed(c, err)
+		return
+	}
+
+	var productIDs, warehouseIDs []string
+	for _, row := range inventory {
+		productIDs = append(productIDs, row.ProductID)
+		warehouseIDs = append(warehouseIDs, row.WarehouseID)
+	}
+	var (
+		wg                         sync.WaitGroup
+		commodityErr, warehouseErr error
+		commodities, warehouses    map[string]string
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		commodities, commodityErr = gc.lookupNames(ctx, commodityUpstream, productIDs, header)
+	}()
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		warehouses, warehouseErr = gc.lookupNames(ctx, warehouseUpstream, warehouseIDs, header)
+	}()
+	wg.Wait()
+
+	view := StockView{Items: inventory}
+	for name, err := range map[string]error{commodityUpstream: commodityErr, warehouseUpstream: warehouseErr} {
+		if err != nil {
+			if view.Errors == nil {
+				view.Errors = map[string]string{}
+			}
+			view.Partial = true
+			view.Errors[name] = describeUpstreamError(err)
+			logging.Logger(c.Request.Context()).Warn("stock view lookup failed", "upstream", name, "error", err)
+		}
+	}
+	for i := range view.Items {
+		row := &view.Items[i]
+		row.CommodityName = commodities[row.ProductID]
+		row.WarehouseName = warehouses[row.WarehouseID]
+	}
+
+	if nextCursor != "" {
+		c.Header(nextCursorHeader, nextCursor)
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// fetchInventory reads one page of inventory.
+func (gc *GatewayController) fetchInventory(ctx context.Context, query url.Values, header http.Header) ([]StockRow, string, error) {
+	upstream, err := gc.upstream(inventoryUpstream)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := upstream.Get(ctx, "", query, header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, "", &upstreamStatusError{status: resp.StatusCode, body: body}
+	}
+	rows := []StockRow{}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, "", fmt.Errorf("failed to decode inventory: %w", err)
+	}
+	return rows, resp.Header.Get(nextCursorHeader), nil
+}
+
+// nameCache keeps the names composite views have looked up, per service and ID, so that a page
+// only costs requests for the IDs not seen lately.
+type nameCache struct {
+	mu      sync.Mutex
+	now     func() time.Time // nil means time.Now
+	entries map[string]map[string]cachedName
+}
+
+// cachedName is the name of one commodity or warehouse. An ID the service did not know is cached
+// with an empty name, so a deleted record does not cost a request on every view.
+type cachedName struct {
+	name    string
+	expires time.Time
+}
+
+func (c *nameCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// get returns the cached name of an ID of the named service, if it has not expired.
+func (c *nameCache) get(service, id string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[service][id]
+	if !ok || !c.clock().Before(entry.expires) {
+		return "", false
+	}
+	return entry.name, true
+}
+
+// put caches the name of an ID of the named service for ttl. A service holding more than
+// maxCachedNames names first has its expired ones dropped, or all of them if none has expired.
+func (c *nameCache) put(service, id, name string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]map[string]cachedName{}
+	}
+	names := c.entries[service]
+	if len(names) >= maxCachedNames {
+		now := c.clock()
+		for cachedID, entry := range names {
+			if !now.Before(entry.expires) {
+				delete(names, cachedID)
+			}
+		}
+		if len(names) >= maxCachedNames {
+			names = nil
+		}
+	}
+	if names == nil {
+		names = map[string]cachedName{}
+		c.entries[service] = names
+	}
+	names[id] = cachedName{name: name, expires: c.clock().Add(ttl)}
+}
+
+// lookupNames maps each of ids to its name in the named service. Names looked up within the last
+// VIEW_NAME_CACHE_SECONDS come from the cache; the others are read in one request. IDs the
+// service does not know are left out. If the request fails, the cached names are returned
+// together with the error.
+func (gc *GatewayController) lookupNames(ctx context.Context, name string, ids []string, header http.Header) (map[string]string, error) {
+	ttl := time.Duration(config.Cfg.ViewNameCacheSeconds) * time.Second
+	names := map[string]string{}
+	seen := map[string]bool{}
+	missing := []string{}
+	for _, id := range ids {
+		if seen[id] || !isObjectID(id) {
+			continue
+		}
+		seen[id] = true
+		if ttl > 0 {
+			if cached, ok := gc.names.get(name, id); ok {
+				if cached != "" {
+					names[id] = cached
+				}
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return names, nil
+	}
+	upstream, err := gc.upstream(name)
+	if err != nil {
+		return names, err
+	}
+	found, err := fetchNames(ctx, upstream, missing, header)
+	if err != nil {
+		return names, err
+	}
+	for _, id := range missing {
+		if found[id] != "" {
+			names[id] = found[id]
+		}
+		if ttl > 0 {
+			gc.names.put(name, id, found[id], ttl)
+		}
+	}
+	return names, nil
+}
+
+// fetchNames reads the records with the given IDs from a lookup service's list, filtered by
+// ids=, and maps each ID to its name. An inventory page has at most as many rows as a list
+// page may, so the IDs of one always fit a single request.
+func fetchNames(ctx context.Context, upstream *Upstream, ids []string, header http.Header) (map[string]string, error) {
+	query := url.Values{idsFilter: {strings.Join(ids, ",")}, "limit": {fmt.Sprint(len(ids))}}
+	resp, err := upstream.Get(ctx, "", query, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &upstreamStatusError{status: resp.StatusCode}
+	}
+	var entities []namedEntity
+	if err := json.NewDecoder(resp.Body).Decode(&entities); err != nil {
+		return nil, fmt.Errorf("failed to decode %s list: %w", upstream.Name, err)
+	}
+	names := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		names[entity.ID] = entity.Name
+	}
+	return names, nil
+}
+
+// isObjectID reports whether id looks like a MongoDB ObjectID. Anything else from an inventory
+// record is kept out of the ids= filter, which the service would refuse as a whole.
+func isObjectID(id string) bool {
+	if len(id) != 24 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// upstream finds a service in the current routing table.
+func (gc *GatewayController) upstream(name string) (*Upstream, error) {
+	for _, route := range gc.Routes() {
+		if route.Name == name {
+			return route.Upstream, nil
+		}
+	}
+	return nil, fmt.Errorf("no route named %s", name)
+}
+
+// inventoryFailed answers when the inventory page could not be read. The Inventory service's
+// own error, such as a 400 for a bad query, is passed through as it is.
+func (gc *GatewayController) inventoryFailed(c *gin.Context, err error) {
+	var statusErr *upstreamStatusError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.status < http.StatusInternalServerError:
+		c.Data(statusErr.status, "application/json; charset=utf-8", statusErr.body)
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": inventoryUpstream + " did not respond in time", "code": "upstream_timeout"})
+	default:
+		logging.Logger(c.Request.Context()).Error("stock view inventory request failed", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": inventoryUpstream + " is temporarily unavailable", "code": "upstream_unavailable"})
+	}
+}
+
+// describeUpstreamError turns a lookup failure into a short reason for clients, without URLs.
+func describeUpstreamError(err error) string {
+	var statusErr *upstreamStatusError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit open"
+	case errors.As(err, &statusErr):
+		return statusErr.Error()
+	default:
+		return "unavailable"
+	}
+}
+
+// forwardedHeader carries the caller's identity and request ID to the services, as the proxy would.
+func forwardedHeader(c *gin.Context) http.Header {
+	header := http.Header{"Accept": {"application/json"}}
+	for _, name := range []string{auth.UserIDHeader, auth.UserRoleHeader, logging.RequestIDHeader} {
+		if value := c.Request.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return header
+}
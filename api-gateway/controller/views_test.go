@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"api-gateway/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	widgetID = "6ad27f5899789d609895b0d1"
+	gadgetID = "6ad27f5899789d609895b0d2"
+	goneID   = "6ad27f5899789d609895b0d3"
+	mainID   = "6ad27f5899789d609895b0e1"
+)
+
+// fakeLookup is a stand-in Commodity or Warehouse service whose list serves the records of
+// names filtered by ids=. It counts the requests and how often each ID was asked for.
+type fakeLookup struct {
+	mu     sync.Mutex
+	names  map[string]string
+	calls  int
+	hits   map[string]int
+	status int // answered instead of the list when set
+}
+
+func (f *fakeLookup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.hits == nil {
+		f.hits = map[string]int{}
+	}
+	for _, id := range ids {
+		f.hits[id]++
+	}
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	if limit := r.URL.Query().Get("limit"); limit != fmt.Sprint(len(ids)) {
+		http.Error(w, "limit "+limit+" does not fit the ids", http.StatusBadRequest)
+		return
+	}
+	entities := []namedEntity{}
+	for _, id := range ids {
+		if name, ok := f.names[id]; ok {
+			entities = append(entities, namedEntity{ID: id, Name: name})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(entities)
+}
+
+func (f *fakeLookup) requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeLookup) asked(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hits[id]
+}
+
+// newViewController routes the three services a stock view reads to stand-ins and returns a
+// router serving GET /api/views/stock.
+func newViewController(t *testing.T, inventory http.Handler, commodities, warehouses *fakeLookup) *gin.Engine {
+	t.Helper()
+	var routes []config.Route
+	for _, service := range []struct {
+		name, root string
+		handler    http.Handler
+	}{
+		{inventoryUpstream, "/inventory", inventory},
+		{commodityUpstream, "/commodities", commodities},
+		{warehouseUpstream, "/warehouses", warehouses},
+	} {
+		server := httptest.NewServer(service.handler)
+		t.Cleanup(server.Close)
+		routes = append(routes, config.Route{Name: service.name, Prefix: "/api" + service.root, URL: server.URL, Root: service.root})
+	}
+
+	gc := NewGatewayController()
+	bound, err := gc.BindRoutes(routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc.SetRoutes(bound)
+	router := gin.New()
+	router.GET("/api/views/stock", gc.StockView)
+	return router
+}
+
+// inventoryPage serves a fixed page of inventory with a cursor for the next one.
+func inventoryPage(rows ...StockRow) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(nextCursorHeader, "next-page")
+		_ = json.NewEncoder(w).Encode(rows)
+	})
+}
+
+func getStockView(t *testing.T, router *gin.Engine) (*httptest.ResponseRecorder, StockView) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/views/stock?limit=3", nil))
+	var view StockView
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+			t.Fatalf("failed to decode view: %v", err)
+		}
+	}
+	return rec, view
+}
+
+func TestStockViewJoinsNamesAndCachesThem(t *testing.T) {
+	commodities := &fakeLookup{names: map[string]string{widgetID: "Widget", gadgetID: "Gadget"}}
+	warehouses := &fakeLookup{names: map[string]string{mainID: "Main"}}
+	router := newViewController(t, inventoryPage(
+		StockRow{ID: "1", ProductID: widgetID, WarehouseID: mainID, Quantity: 5},
+		StockRow{ID: "2", ProductID: gadgetID, WarehouseID: mainID, Quantity: 7},
+		StockRow{ID: "3", ProductID: goneID, WarehouseID: "not-an-id", Quantity: 1},
+	), commodities, warehouses)
+
+	for round := 1; round <= 2; round++ {
+		rec, view := getStockView(t, router)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("round %d: status %d: %s", round, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get(nextCursorHeader); got != "next-page" {
+			t.Errorf("round %d: next cursor %q, want next-page", round, got)
+		}
+		if view.Partial || len(view.Errors) != 0 {
+			t.Errorf("round %d: unexpected partial view: %v", round, view.Errors)
+		}
+		var got []string
+		for _, row := range view.Items {
+			got = append(got, fmt.Sprintf("%s:%s@%s", row.ID, row.CommodityName, row.WarehouseName))
+		}
+		if want := "1:Widget@Main 2:Gadget@Main 3:@"; strings.Join(got, " ") != want {
+			t.Errorf("round %d: rows %q, want %q", round, strings.Join(got, " "), want)
+		}
+	}
+
+	// The first view reads each service once; the second comes from the cache, unknown IDs included.
+	for name, lookup := range map[string]*fakeLookup{"commodity": commodities, "warehouse": warehouses} {
+		if n := lookup.requests(); n != 1 {
+			t.Errorf("%s service requested %d times, want 1", name, n)
+		}
+	}
+	for _, id := range []string{widgetID, gadgetID, goneID} {
+		if n := commodities.asked(id); n != 1 {
+			t.Errorf("commodity %s asked for %d times, want 1", id, n)
+		}
+	}
+	if n := warehouses.asked("not-an-id"); n != 0 {
+		t.Errorf("malformed warehouse ID asked for %d times, want 0", n)
+	}
+}
+
+func TestStockViewFailures(t *testing.T) {
+	rows := []StockRow{{ID: "1", ProductID: widgetID, WarehouseID: mainID, Quantity: 5}}
+	tests := []struct {
+		name          string
+		inventory     http.Handler
+		warehouseCode int
+		wantStatus    int
+		wantCommodity string
+		wantErrors    map[string]string
+	}{
+		{
+			name:          "warehouse lookup fails",
+			inventory:     inventoryPage(rows...),
+			warehouseCode: http.StatusInternalServerError,
+			wantStatus:    http.StatusOK,
+			wantCommodity: "Widget",
+			wantErrors:    map[string]string{warehouseUpstream: "returned status 500"},
+		},
+		{
+			name: "inventory rejects the query",
+			inventory: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"bad sort","code":"invalid_argument"}`))
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "inventory fails",
+			inventory: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commodities := &fakeLookup{names: map[string]string{widgetID: "Widget"}}
+			warehouses := &fakeLookup{names: map[string]string{mainID: "Main"}, status: tt.warehouseCode}
+			rec, view := getStockView(t, newViewController(t, tt.inventory, commodities, warehouses))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if !view.Partial || fmt.Sprint(view.Errors) != fmt.Sprint(tt.wantErrors) {
+				t.Errorf("partial %v errors %v, want errors %v", view.Partial, view.Errors, tt.wantErrors)
+			}
+			if len(view.Items) != 1 || view.Items[0].CommodityName != tt.wantCommodity || view.Items[0].WarehouseName != "" {
+				t.Errorf("rows %+v, want commodity %q and no warehouse name", view.Items, tt.wantCommodity)
+			}
+		})
+	}
+}
+
+func TestNameCacheExpiresAndStaysBounded(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := nameCache{now: func() time.Time { return now }}
+
+	cache.put(commodityUpstream, widgetID, "Widget", time.Minute)
+	if name, ok := cache.get(commodityUpstream, widgetID); !ok || name != "Widget" {
+		t.Fatalf("get = %q, %v; want Widget, true", name, ok)
+	}
+	if _, ok := cache.get(warehouseUpstream, widgetID); ok {
+		t.Error("names of one service must not answer for another")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := cache.get(commodityUpstream, widgetID); ok {
+		t.Error("expired name was returned")
+	}
+
+	for i := 0; i < maxCachedNames+10; i++ {
+		cache.put(commodityUpstream, fmt.Sprintf("%024x", i), "x", time.Hour)
+	}
+	if n := len(cache.entries[commodityUpstream]); n > maxCachedNames {
+		t.Errorf("cache holds %d names, want at most %d", n, maxCachedNames)
+	}
+}
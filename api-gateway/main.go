@@ -1,96 +1,117 @@
 package main
 
 import (
+	"api-gateway/auth"
 	"api-gateway/config"
 	"api-gateway/controller"
+	"api-gateway/ratelimit"
+	"api-gateway/routes"
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"wms-common/logging"
+	"wms-common/metrics"
+	"wms-common/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	if err := logging.Setup("api-gateway"); err != nil {
+		log.Fatalf("Error setting up logging: %v", err)
+	}
+
 	err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	gin.SetMode(config.Cfg.GinMode)
-	router := gin.Default()
-
-	// CRITICAL: Disable Gin's automatic trailing slash redirects and fixed path redirects on API Gateway.
-	// This ensures our explicit routing and proxy.Director have full control over path normalization.
-	router.RedirectTrailingSlash = false
-	router.RedirectFixedPath = false
-
-	// --- Robust CORS Configuration for API Gateway ---
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000"}, // Allow React dev server origins
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	tracingConfig, err := tracing.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Error loading tracing config: %v", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "api-gateway", tracingConfig)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Error flushing traces: %v", err)
+		}
+	}()
 
+	gin.SetMode(config.Cfg.GinMode)
 	gatewayController := controller.NewGatewayController()
 
-	// Health check endpoint for the API Gateway itself
-	router.GET("/health", controller.HealthCheck)
+	// Every /api request must carry a valid JWT whose role the policy allows for the route.
+	var authMiddleware gin.HandlerFunc
+	if config.Cfg.AuthDisabled {
+		log.Println("WARNING: authentication is disabled (AUTH_DISABLED=true); do not run like this in production.")
+		authMiddleware = auth.StripIdentity()
+	} else {
+		authenticator, err := auth.NewAuthenticator(auth.Options{
+			HMACSecret: []byte(config.Cfg.JWTHMACSecret),
+			JWKSFile:   config.Cfg.JWTJWKSFile,
+			Issuer:     config.Cfg.JWTIssuer,
+			Audience:   config.Cfg.JWTAudience,
+		})
+		if err != nil {
+			log.Fatalf("Error configuring authentication: %v", err)
+		}
+		authMiddleware = auth.Middleware(authenticator, auth.DefaultPolicy)
+	}
 
-	// Group API routes under "/api" prefix.
-	apiGroup := router.Group("/api")
-	{
-		// --- CUSTOMER SERVICE ROUTES ---
-		// Explicitly define routes for the root collection path (e.g., /api/customers)
-		// This handles GET/POST/PUT/DELETE/OPTIONS requests directly to /api/customers
-		apiGroup.GET("/customers", gatewayController.ProxyToCustomerService)
-		apiGroup.POST("/customers", gatewayController.ProxyToCustomerService)
-		apiGroup.PUT("/customers", gatewayController.ProxyToCustomerService)     // Not typically used for root, but covers all methods
-		apiGroup.DELETE("/customers", gatewayController.ProxyToCustomerService)  // Not typically used for root, but covers all methods
-		apiGroup.OPTIONS("/customers", gatewayController.ProxyToCustomerService) // For CORS preflight
-
-		// Use Any for paths with a wildcard (e.g., /api/customers/123)
-		apiGroup.Any("/customers/*proxyPath", gatewayController.ProxyToCustomerService)
-
-		// --- WAREHOUSE SERVICE ROUTES ---
-		apiGroup.GET("/warehouses", gatewayController.ProxyToWarehouseService)
-		apiGroup.POST("/warehouses", gatewayController.ProxyToWarehouseService)
-		apiGroup.PUT("/warehouses", gatewayController.ProxyToWarehouseService)
-		apiGroup.DELETE("/warehouses", gatewayController.ProxyToWarehouseService)
-		apiGroup.OPTIONS("/warehouses", gatewayController.ProxyToWarehouseService)
-
-		apiGroup.Any("/warehouses/*proxyPath", gatewayController.ProxyToWarehouseService)
-
-		// --- COMMODITY SERVICE ROUTES ---
-		apiGroup.GET("/commodities", gatewayController.ProxyToCommoditiesService)
-		apiGroup.POST("/commodities", gatewayController.ProxyToCommoditiesService)
-		apiGroup.PUT("/commodities", gatewayController.ProxyToCommoditiesService)
-		apiGroup.DELETE("/commodities", gatewayController.ProxyToCommoditiesService)
-		apiGroup.OPTIONS("/commodities", gatewayController.ProxyToCommoditiesService)
-
-		apiGroup.Any("/commodities/*proxyPath", gatewayController.ProxyToCommoditiesService)
-
-		// --- INVENTORY SERVICE ROUTES ---
-		apiGroup.GET("/inventory", gatewayController.ProxyToInventoryService)
-		apiGroup.POST("/inventory", gatewayController.ProxyToInventoryService)
-		apiGroup.PUT("/inventory", gatewayController.ProxyToInventoryService)
-		apiGroup.DELETE("/inventory", gatewayController.ProxyToInventoryService)
-		apiGroup.OPTIONS("/inventory", gatewayController.ProxyToInventoryService)
-
-		apiGroup.Any("/inventory/*proxyPath", gatewayController.ProxyToInventoryService)
+	// Throttle each client per route group. The limiter runs after authentication so that its
+	// buckets are keyed on verified identities that a client cannot vary at will.
+	limiter, err := newRateLimiter()
+	if err != nil {
+		log.Fatalf("Error configuring rate limits: %v", err)
 	}
 
+	// The routing table comes from ROUTES_FILE (or the built-in services) and is reloaded on
+	// SIGHUP. Each load builds a fresh router; the limiter and upstreams carry over.
+	handler := &routes.Switch{}
+	applyRoutes := func() error {
+		table, err := config.LoadRoutes()
+		if err != nil {
+			return err
+		}
+		bound, err := gatewayController.BindRoutes(table)
+		if err != nil {
+			return err
+		}
+		router, err := newRouter(gatewayController, bound, authMiddleware, limiter)
+		if err != nil {
+			return err
+		}
+		handler.Store(router)
+		gatewayController.SetRoutes(bound)
+		return nil
+	}
+	if err := applyRoutes(); err != nil {
+		log.Fatalf("Error loading routes: %v", err)
+	}
+
+	// Probe every upstream in the background so failing services trip their circuit breakers
+	// even without traffic. /health reports the gateway and each upstream's state.
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	defer stopHealthChecks()
+	gatewayController.StartHealthChecks(healthCtx, controller.HealthProbe{
+		Path:     config.Cfg.UpstreamHealthPath,
+		Interval: time.Duration(config.Cfg.HealthCheckIntervalSeconds) * time.Second,
+		Timeout:  time.Duration(config.Cfg.HealthCheckTimeoutSeconds) * time.Second,
+	})
+
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.Cfg.APIGatewayPort),
-		Handler:      router,
+		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
@@ -104,12 +125,22 @@ func main() {
 		}
 	}()
 
-	// Graceful shutdown
+	// SIGHUP reloads the routes; SIGINT and SIGTERM shut down gracefully.
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if err := applyRoutes(); err != nil {
+			slog.Error("failed to reload routes; keeping the current table", "error", err)
+			continue
+		}
+		slog.Info("routes reloaded", "routes", len(gatewayController.Routes()))
+	}
 
 	log.Println("Shutting down API Gateway gracefully...")
+	stopHealthChecks()
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -118,3 +149,86 @@ func main() {
 	}
 	log.Println("API Gateway stopped.")
 }
+
+// newRateLimiter builds the gateway's rate limiter from the RATE_LIMIT_* settings.
+func newRateLimiter() (*ratelimit.Limiter, error) {
+	defaultLimit, err := ratelimit.ParseLimit(config.Cfg.RateLimitDefault)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_DEFAULT: %w", err)
+	}
+	groups, err := ratelimit.ParseLimits(config.Cfg.RateLimitGroups)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_GROUPS: %w", err)
+	}
+	apiKeys, err := ratelimit.ParseAPIKeys(config.Cfg.RateLimitAPIKeys)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_API_KEYS: %w", err)
+	}
+	subjects, err := ratelimit.ParseLimits(config.Cfg.RateLimitSubjects)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_SUBJECTS: %w", err)
+	}
+	ips, err := ratelimit.ParseLimits(config.Cfg.RateLimitIPs)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_IPS: %w", err)
+	}
+	return ratelimit.New(ratelimit.Config{
+		Default:    defaultLimit,
+		Groups:     groups,
+		APIKeys:    apiKeys,
+		Subjects:   subjects,
+		IPs:        ips,
+		MaxBuckets: config.Cfg.RateLimitMaxBuckets,
+	}), nil
+}
+
+// newRouter builds the gateway's router for a routing table.
+func newRouter(gatewayController *controller.GatewayController, table []controller.Route, authMiddleware gin.HandlerFunc, limiter *ratelimit.Limiter) (*gin.Engine, error) {
+	router := gin.New()
+
+	// Only the configured proxies may report a client's address in X-Forwarded-For; otherwise a
+	// client could pick the IP its requests are logged and rate limited under.
+	if err := router.SetTrustedProxies(config.Cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	// CRITICAL: Disable Gin's automatic trailing slash redirects and fixed path redirects on API Gateway.
+	// This ensures our explicit routing and proxy.Director have full control over path normalization.
+	router.RedirectTrailingSlash = false
+	router.RedirectFixedPath = false
+	// A method a route does not list gets a 405 rather than a 404.
+	router.HandleMethodNotAllowed = true
+	router.NoMethod(func(c *gin.Context) {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method not allowed", "code": "method_not_allowed"})
+	})
+
+	// A span per request; proxied calls become its children and carry the trace downstream
+	router.Use(tracing.Middleware("api-gateway"))
+	// One JSON log line per request, tagged with the X-Request-ID forwarded to the services
+	router.Use(logging.Middleware("/health", "/metrics"), logging.Recovery())
+
+	// --- Robust CORS Configuration for API Gateway ---
+	router.Use(cors.New(cors.Config{
+		AllowOrigins: []string{"http://localhost:3000", "http://127.0.0.1:3000"}, // Allow React dev server origins
+		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", ratelimit.APIKeyHeader, logging.RequestIDHeader},
+		// X-Next-Cursor pages through list endpoints; the rate limit headers let clients back off;
+		// X-Request-ID lets a client quote the request when reporting a problem.
+		ExposeHeaders:    []string{"Content-Length", "X-Next-Cursor", logging.RequestIDHeader, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Request counts and latencies per route, scraped from /metrics
+	router.Use(metrics.Middleware())
+	router.GET("/metrics", metrics.Handler())
+	router.GET("/health", gatewayController.HealthCheck)
+
+	// Group API routes under "/api" prefix.
+	apiGroup := router.Group("/api", authMiddleware, ratelimit.Middleware(limiter))
+	routes.SetupGatewayRoutes(apiGroup, table)
+
+	// Composite views join data from several services in one response.
+	apiGroup.GET("/views/stock", gatewayController.StockView)
+	return router, nil
+}
@@ -0,0 +1,62 @@
+// Package metrics exports the gateway's own Prometheus metrics: latency and failures per
+// proxied upstream service. Request counts and latencies per route, and the /metrics handler,
+// come from wms-common/metrics like in every other service.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons an upstream request failed, used as the "reason" label of gateway_upstream_errors_total.
+const (
+	// ReasonUnreachable means the request could not be sent or no response came back.
+	ReasonUnreachable = "unreachable"
+	// ReasonCircuitOpen means the request was turned away because the upstream's circuit was open.
+	ReasonCircuitOpen = "circuit_open"
+	// ReasonBadStatus means the upstream answered 502, 503 or 504.
+	ReasonBadStatus = "bad_status"
+	// ReasonTimeout means the upstream did not answer within its route's timeout.
+	ReasonTimeout = "timeout"
+)
+
+var (
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_upstream_request_duration_seconds",
+		Help:    "Time taken by proxied requests, by upstream service and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"upstream", "status"})
+
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_upstream_errors_total",
+		Help: "Proxied requests that failed, by upstream service and reason.",
+	}, []string{"upstream", "reason"})
+)
+
+// statusLabels holds the "status" label of every valid HTTP status code, so recording a
+// request's latency does not format one each time.
+var statusLabels = func() (labels [600]string) {
+	for status := 100; status < len(labels); status++ {
+		labels[status] = strconv.Itoa(status)
+	}
+	return labels
+}()
+
+// ObserveUpstream records the latency of one proxied request.
+func ObserveUpstream(upstream string, status int, start time.Time) {
+	var label string
+	if status >= 100 && status < len(statusLabels) {
+		label = statusLabels[status]
+	} else {
+		label = strconv.Itoa(status)
+	}
+	upstreamDuration.WithLabelValues(upstream, label).Observe(time.Since(start).Seconds())
+}
+
+// UpstreamError counts a failed proxied request.
+func UpstreamError(upstream, reason string) {
+	upstreamErrors.WithLabelValues(upstream, reason).Inc()
+}
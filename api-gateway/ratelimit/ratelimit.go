@@ -0,0 +1,316 @@
+// Package ratelimit throttles API clients with token buckets kept per client and route group.
+//
+// A client is identified by an X-API-Key header listed in Config.APIKeys, else by the subject
+// authentication verified, else by its IP address. Unlisted API keys are ignored, so a caller
+// cannot dodge its limit by sending a fresh key with every request, nor borrow another client's
+// limit by sending its subject or IP address as a key. API keys, subjects and IP addresses are
+// kept in separate namespaces, and every (client, route group) pair gets its own bucket, so a
+// script hammering /api/inventory does not use up the same client's allowance for /api/customers.
+package ratelimit
+
+import (
+	"api-gateway/auth"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader identifies a client independently of its IP address.
+const APIKeyHeader = "X-API-Key"
+
+// DefaultMaxBuckets bounds the buckets a Limiter keeps when Config.MaxBuckets is not set.
+const DefaultMaxBuckets = 10000
+
+// MinAPIKeyLength is the shortest API key ParseAPIKeys accepts, so that keys are secrets rather
+// than guessable names.
+const MinAPIKeyLength = 16
+
+// Kinds of client identity. Each kind is its own namespace of limits and buckets.
+const (
+	KindAPIKey  = "key"
+	KindSubject = "sub"
+	KindIP      = "ip"
+)
+
+// Client identifies the caller a bucket belongs to.
+type Client struct {
+	Kind string // KindAPIKey, KindSubject or KindIP
+	ID   string
+}
+
+func (c Client) String() string { return c.Kind + ":" + c.ID }
+
+// overflowClient shares one bucket per route group among the clients that arrive while the
+// Limiter is full, so a flood of new clients is throttled together instead of growing memory.
+const overflowClient = "*"
+
+// idleTTL is how long an untouched bucket is kept before it is dropped; by then it has refilled anyway.
+const idleTTL = 10 * time.Minute
+
+// Limit is a sustained request rate with room for short bursts. A Rate of zero or less means unlimited.
+type Limit struct {
+	Rate  float64 // tokens added per second
+	Burst int     // bucket size
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (l Limit) Unlimited() bool { return l.Rate <= 0 }
+
+// ParseLimit reads a limit written as "rate:burst", e.g. "10:20". A bare "rate" uses the rate as burst.
+func ParseLimit(s string) (Limit, error) {
+	rateStr, burstStr, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil {
+		return Limit{}, fmt.Errorf("invalid rate %q", rateStr)
+	}
+	burst := int(math.Ceil(rate))
+	if hasBurst {
+		if burst, err = strconv.Atoi(burstStr); err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid burst %q", burstStr)
+		}
+	}
+	return Limit{Rate: rate, Burst: max(burst, 1)}, nil
+}
+
+// ParseLimits reads a comma-separated list of "name=rate:burst" entries, e.g.
+// "inventory=20:40,customers=5:10".
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid rate limit entry %q, want name=rate:burst", entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit for %s: %w", name, err)
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits, nil
+}
+
+// ParseAPIKeys reads API keys and their limits written as for ParseLimits, rejecting keys shorter
+// than MinAPIKeyLength.
+func ParseAPIKeys(s string) (map[string]Limit, error) {
+	keys, err := ParseLimits(s)
+	if err != nil {
+		return nil, err
+	}
+	for key := range keys {
+		if len(key) < MinAPIKeyLength {
+			return nil, fmt.Errorf("API key %q is too short to be a secret, want at least %d characters", key, MinAPIKeyLength)
+		}
+	}
+	return keys, nil
+}
+
+// Config chooses the limit for a request. A client override wins over a route group limit,
+// which wins over the default.
+type Config struct {
+	Default Limit
+	// Groups holds limits per route group, keyed by the first path segment after /api.
+	Groups map[string]Limit
+	// APIKeys holds limits per API key. Its keys are the only X-API-Key values the Limiter
+	// accepts as a client's identity.
+	APIKeys map[string]Limit
+	// Subjects holds limits per authenticated subject.
+	Subjects map[string]Limit
+	// IPs holds limits per client IP address.
+	IPs map[string]Limit
+	// MaxBuckets caps the buckets kept at once; zero means DefaultMaxBuckets.
+	MaxBuckets int
+}
+
+func (c Config) limitFor(client Client, group string) Limit {
+	var clients map[string]Limit
+	switch client.Kind {
+	case KindAPIKey:
+		clients = c.APIKeys
+	case KindSubject:
+		clients = c.Subjects
+	case KindIP:
+		clients = c.IPs
+	}
+	if limit, ok := clients[client.ID]; ok {
+		return limit
+	}
+	if limit, ok := c.Groups[group]; ok {
+		return limit
+	}
+	return c.Default
+}
+
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// full reports whether b has refilled completely by now.
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst)
+}
+
+// Limiter holds the token buckets for every client and route group seen recently.
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	lastDrop  time.Time
+}
+
+// New creates a Limiter for cfg.
+func New(cfg Config) *Limiter {
+	if cfg.MaxBuckets <= 0 {
+		cfg.MaxBuckets = DefaultMaxBuckets
+	}
+	return &Limiter{cfg: cfg, now: time.Now, buckets: map[string]*bucket{}}
+}
+
+// Decision is the outcome of one Allow call.
+type Decision struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Allow takes one token from the bucket for client on group, if there is one.
+func (l *Limiter) Allow(client Client, group string) Decision {
+	limit := l.cfg.limitFor(client, group)
+	if limit.Unlimited() {
+		return Decision{Allowed: true, Limit: limit}
+	}
+
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	key := group + "|" + client.String()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.cfg.MaxBuckets {
+			l.dropFull(now)
+		}
+		if len(l.buckets) >= l.cfg.MaxBuckets {
+			key = group + "|" + overflowClient
+			b, ok = l.buckets[key]
+		}
+	}
+	if !ok {
+		b = &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	decision := Decision{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return decision
+}
+
+// sweep drops buckets idle for longer than idleTTL. Callers hold l.mu.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// dropFull drops buckets that have refilled completely; forgetting them changes no client's
+// allowance. It scans at most once a second, so a full Limiter stays cheap. Callers hold l.mu.
+func (l *Limiter) dropFull(now time.Time) {
+	if now.Sub(l.lastDrop) < time.Second {
+		return
+	}
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastDrop = now
+}
+
+// Middleware rejects requests over their client's limit with 429 and reports the limit state in
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset on every response.
+// CORS preflight requests are not counted. It must run after authentication, so that buckets
+// belong to verified subjects rather than to whatever identity a client claims.
+func Middleware(l *Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == http.MethodOptions {
+			ctx.Next()
+			return
+		}
+
+		decision := l.Allow(l.clientID(ctx), auth.RouteGroup(ctx.Request.URL.Path))
+		if decision.Limit.Unlimited() {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit.Burst))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		ctx.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+		if !decision.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "rate limit exceeded, retry later",
+				"code":  "rate_limited",
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// clientID identifies the caller by a configured API key, then by its authenticated subject,
+// then by its IP address. ClientIP only honours forwarding headers from trusted proxies.
+func (l *Limiter) clientID(ctx *gin.Context) Client {
+	if key := ctx.GetHeader(APIKeyHeader); key != "" {
+		if _, ok := l.cfg.APIKeys[key]; ok {
+			return Client{Kind: KindAPIKey, ID: key}
+		}
+	}
+	if subject := ctx.Request.Header.Get(auth.UserIDHeader); subject != "" {
+		return Client{Kind: KindSubject, ID: subject}
+	}
+	return Client{Kind: KindIP, ID: ctx.ClientIP()}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"api-gateway/auth"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	os.Exit(m.Run())
+}
+
+// clock is a settable time source for a Limiter.
+type clock struct{ now time.Time }
+
+func (c *clock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestLimiter creates a Limiter for cfg whose time only moves through the returned clock.
+func newTestLimiter(cfg Config) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(cfg)
+	l.now = func() time.Time { return c.now }
+	return l, c
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "10:20", want: Limit{Rate: 10, Burst: 20}},
+		{in: "2.5", want: Limit{Rate: 2.5, Burst: 3}},
+		{in: "0", want: Limit{Rate: 0, Burst: 1}},
+		{in: "fast", wantErr: true},
+		{in: "10:0", wantErr: true},
+		{in: "10:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseLimit = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "k3y-0123456789abcdef=100:200"},
+		{in: ""},
+		{in: "alice=100:200", wantErr: true},
+		{in: "10.9.9.9=1000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if _, err := ParseAPIKeys(tt.in); (err != nil) != tt.wantErr {
+				t.Errorf("ParseAPIKeys error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllowRefills(t *testing.T) {
+	alice := Client{Kind: KindSubject, ID: "alice"}
+	tests := []struct {
+		name          string
+		wait          time.Duration // Before the request, after the burst was used up
+		wantAllowed   bool
+		wantRetry     time.Duration
+		wantRemaining int
+	}{
+		{name: "empty bucket", wantRetry: time.Second},
+		{name: "half a token later", wait: 500 * time.Millisecond, wantRetry: 500 * time.Millisecond},
+		{name: "one token later", wait: time.Second, wantAllowed: true},
+		{name: "refilled past the burst", wait: time.Hour, wantAllowed: true, wantRemaining: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(Config{Default: Limit{Rate: 1, Burst: 2}})
+			for range 2 {
+				if !l.Allow(alice, "inventory").Allowed {
+					t.Fatal("request within the burst refused")
+				}
+			}
+
+			c.advance(tt.wait)
+			decision := l.Allow(alice, "inventory")
+			if decision.Allowed != tt.wantAllowed || decision.RetryAfter != tt.wantRetry || decision.Remaining != tt.wantRemaining {
+				t.Errorf("decision = %+v, want allowed %v, retry after %v, %d remaining",
+					decision, tt.wantAllowed, tt.wantRetry, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestAllowKeepsBucketsApart(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		Default:  Limit{Rate: 1, Burst: 1},
+		Groups:   map[string]Limit{"customers": {Rate: 1, Burst: 3}},
+		Subjects: map[string]Limit{"10.9.9.9": {Rate: 1, Burst: 5}},
+		IPs:      map[string]Limit{"10.9.9.9": {Rate: 1000, Burst: 1000}},
+	})
+	alice := Client{Kind: KindSubject, ID: "alice"}
+	named := Client{Kind: KindSubject, ID: "10.9.9.9"}
+	ip := Client{Kind: KindIP, ID: "10.9.9.9"}
+
+	l.Allow(alice, "inventory")
+	if !l.Allow(alice, "customers").Allowed {
+		t.Error("a used-up bucket for one route group refused a request to another")
+	}
+	if got := l.Allow(alice, "customers").Limit; got.Burst != 3 {
+		t.Errorf("group limit burst = %d, want 3", got.Burst)
+	}
+	if got := l.Allow(named, "inventory").Limit; got.Burst != 5 {
+		t.Errorf("a subject named like an IP address got burst %d, want its own 5", got.Burst)
+	}
+	if got := l.Allow(ip, "inventory").Limit; got.Burst != 1000 {
+		t.Errorf("IP limit burst = %d, want 1000", got.Burst)
+	}
+}
+
+func TestAllowOverflowAndEviction(t *testing.T) {
+	l, c := newTestLimiter(Config{Default: Limit{Rate: 1, Burst: 2}, MaxBuckets: 2})
+	client := func(id string) Client { return Client{Kind: KindIP, ID: id} }
+
+	l.Allow(client("a"), "inventory")
+	l.Allow(client("b"), "inventory")
+	// The Limiter is full, so c and d share one overflow bucket of 2 tokens.
+	l.Allow(client("c"), "inventory")
+	l.Allow(client("d"), "inventory")
+	if l.Allow(client("c"), "inventory").Allowed {
+		t.Error("overflow clients were not throttled together")
+	}
+	if len(l.buckets) != 3 {
+		t.Errorf("%d buckets, want the 2 allowed plus the overflow bucket", len(l.buckets))
+	}
+
+	// Once buckets have refilled they can be dropped, making room for a bucket of its own.
+	c.advance(2 * time.Second)
+	l.Allow(client("e"), "inventory")
+	if _, ok := l.buckets["inventory|"+client("e").String()]; !ok {
+		t.Error("a new client got no bucket of its own after full buckets could be dropped")
+	}
+
+	// Idle buckets are swept however many there are.
+	c.advance(idleTTL + time.Second)
+	l.Allow(client("f"), "inventory")
+	if len(l.buckets) != 1 {
+		t.Errorf("%d buckets after the idle sweep, want only the new one", len(l.buckets))
+	}
+}
+
+// serve sends a request through Middleware(l), as authenticated for subject if it is not empty,
+// and returns the response.
+func serve(l *Limiter, method, subject string, header http.Header) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		if subject != "" {
+			ctx.Request.Header.Set(auth.UserIDHeader, subject)
+		}
+	}, Middleware(l))
+	router.Handle(method, "/api/warehouses", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(method, "/api/warehouses", nil)
+	req.RemoteAddr = "10.1.1.1:4000"
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	l, _ := newTestLimiter(Config{Default: Limit{Rate: 0.5, Burst: 2}})
+
+	tests := []struct {
+		wantStatus    int
+		wantRemaining string
+		wantReset     string
+		wantRetry     string
+	}{
+		{wantStatus: http.StatusOK, wantRemaining: "1", wantReset: "2"},
+		{wantStatus: http.StatusOK, wantRemaining: "0", wantReset: "4"},
+		{wantStatus: http.StatusTooManyRequests, wantRemaining: "0", wantReset: "4", wantRetry: "2"},
+	}
+	for i, tt := range tests {
+		rec := serve(l, http.MethodGet, "alice", nil)
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("request %d: status %d, want %d", i+1, rec.Code, tt.wantStatus)
+		}
+		got := []string{
+			rec.Header().Get("X-RateLimit-Limit"), rec.Header().Get("X-RateLimit-Remaining"),
+			rec.Header().Get("X-RateLimit-Reset"), rec.Header().Get("Retry-After"),
+		}
+		want := []string{"2", tt.wantRemaining, tt.wantReset, tt.wantRetry}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("request %d: limit, remaining, reset, retry-after = %q, want %q", i+1, got, want)
+		}
+	}
+
+	if rec := serve(l, http.MethodOptions, "alice", nil); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("preflight: status %d with limit %q, want it let through uncounted", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestMiddlewareIdentity(t *testing.T) {
+	const key = "k3y-0123456789abcdef"
+	tests := []struct {
+		name      string
+		subject   string
+		apiKey    string
+		wantLimit string
+	}{
+		{name: "configured API key", subject: "mallory", apiKey: key, wantLimit: "100"},
+		{name: "subject", subject: "bob", wantLimit: "3"},
+		{name: "IP address", wantLimit: "50"},
+		{name: "subject sent as an API key", subject: "mallory", apiKey: "alice", wantLimit: "1"},
+		{name: "IP address sent as an API key", subject: "mallory", apiKey: "10.9.9.9", wantLimit: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(Config{
+				Default:  Limit{Rate: 1, Burst: 1},
+				APIKeys:  map[string]Limit{key: {Rate: 100, Burst: 100}},
+				Subjects: map[string]Limit{"alice": {Rate: 2, Burst: 2}, "bob": {Rate: 3, Burst: 3}},
+				IPs:      map[string]Limit{"10.1.1.1": {Rate: 50, Burst: 50}, "10.9.9.9": {Rate: 1000, Burst: 1000}},
+			})
+			header := http.Header{}
+			if tt.apiKey != "" {
+				header.Set(APIKeyHeader, tt.apiKey)
+			}
+
+			if got := serve(l, http.MethodGet, tt.subject, header).Header().Get("X-RateLimit-Limit"); got != tt.wantLimit {
+				t.Errorf("X-RateLimit-Limit = %q, want %q", got, tt.wantLimit)
+			}
+			// Whatever another caller sends as a key, alice keeps her own allowance.
+			if rec := serve(l, http.MethodGet, "alice", nil); rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != "1" {
+				t.Errorf("alice: status %d with %q remaining, want her bucket untouched",
+					rec.Code, rec.Header().Get("X-RateLimit-Remaining"))
+			}
+		})
+	}
+}
@@ -1,18 +1,38 @@
 package routes
 
 import (
-	"api-gateway/controller" // FIXED: Corrected import path
+	"api-gateway/controller"
+	"net/http"
+	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupGatewayRoutes sets up the API routes for the API Gateway.
-func SetupGatewayRoutes(router *gin.Engine, gatewayController *controller.GatewayController) {
-	apiGroup := router.Group("/api")
-	{
-		apiGroup.Any("/customers/*proxyPath", gatewayController.ProxyToCustomerService)
-		apiGroup.Any("/warehouses/*proxyPath", gatewayController.ProxyToWarehouseService)
-		apiGroup.Any("/commodities/*proxyPath", gatewayController.ProxyToCommoditiesService)
-		apiGroup.Any("/inventory/*proxyPath", gatewayController.ProxyToInventoryService)
+// SetupGatewayRoutes registers every route of the table on apiGroup, which must be the /api
+// group. Each route answers at its prefix and everything below it, for its methods only.
+func SetupGatewayRoutes(apiGroup *gin.RouterGroup, table []controller.Route) {
+	for _, route := range table {
+		path := strings.TrimPrefix(route.Prefix, "/api")
+		for _, method := range route.Methods {
+			apiGroup.Handle(method, path, route.Upstream.Handler)
+			apiGroup.Handle(method, path+"/*proxyPath", route.Upstream.Handler)
+		}
 	}
 }
+
+// Switch is an http.Handler that serves every request with the most recently stored router.
+// Gin cannot drop routes from a running engine, so a new routing table gets a new engine,
+// swapped in while requests already in flight finish on the old one.
+type Switch struct {
+	current atomic.Pointer[gin.Engine]
+}
+
+// Store makes router serve all subsequent requests.
+func (s *Switch) Store(router *gin.Engine) {
+	s.current.Store(router)
+}
+
+func (s *Switch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.current.Load().ServeHTTP(w, r)
+}
@@ -12,8 +12,17 @@ type Config struct { // Ensure struct is named Config
 	GinMode      string `json:"gin_mode"` // This field must exist
 	MongoDBURI   string `json:"mongodb_uri"`
 	DatabaseName string `json:"database_name"`
+
+	// Where repositories keep their data: StorageBackendMongo (default) or StorageBackendMemory
+	StorageBackend string `json:"storage_backend"`
 }
 
+// Storage backends selectable through STORAGE_BACKEND.
+const (
+	StorageBackendMongo  = "mongo"
+	StorageBackendMemory = "memory" // Data lives in process memory and is lost on restart
+)
+
 // Cfg is the global configuration instance.
 var Cfg *Config
 
@@ -24,6 +33,8 @@ func LoadConfig() error {
 		GinMode:      "debug",                     // Default value
 		MongoDBURI:   "mongodb://localhost:27017", // For individual testing outside Docker
 		DatabaseName: "wms_commodities_db",
+
+		StorageBackend: StorageBackendMongo,
 	}
 
 	if portStr := os.Getenv("PORT"); portStr != "" {
@@ -41,8 +52,20 @@ func LoadConfig() error {
 		Cfg.DatabaseName = dbName
 	}
 
-	fmt.Printf("Commodity Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s\n",
-		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName)
+	if storage := os.Getenv("STORAGE_BACKEND"); storage != "" {
+		Cfg.StorageBackend = storage
+	}
+	if Cfg.StorageBackend != StorageBackendMongo && Cfg.StorageBackend != StorageBackendMemory {
+		return fmt.Errorf("unknown STORAGE_BACKEND %q, expected %q or %q", Cfg.StorageBackend, StorageBackendMongo, StorageBackendMemory)
+	}
+
+	fmt.Printf("Commodity Service Configuration: Port=%d, GinMode=%s, MongoDBURI=%s, DatabaseName=%s, StorageBackend=%s\n",
+		Cfg.Port, Cfg.GinMode, Cfg.MongoDBURI, Cfg.DatabaseName, Cfg.StorageBackend)
 
 	return nil
 }
+
+// UseMemoryStorage reports whether repositories keep their data in process memory instead of MongoDB.
+func (c *Config) UseMemoryStorage() bool {
+	return c.StorageBackend == StorageBackendMemory
+}
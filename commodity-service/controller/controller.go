@@ -2,10 +2,13 @@ package controller
 
 import (
 	"commodity-service/model"
+	"commodity-service/repository"
 	"commodity-service/service"
 	"context"
 	"net/http"
 	"time"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +27,7 @@ func NewCommodityController(s service.CommodityService) *CommodityController {
 func (c *CommodityController) CreateCommodity(ctx *gin.Context) {
 	var commodity model.Commodity
 	if err := ctx.ShouldBindJSON(&commodity); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -33,22 +36,32 @@ func (c *CommodityController) CreateCommodity(ctx *gin.Context) {
 
 	createdCommodity, err := c.commodityService.CreateCommodity(timeoutCtx, &commodity)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, createdCommodity)
 }
 
 // GetAllCommodities handles GET /commodities requests.
+// Supports ?limit=, ?after=, ?sort= and the filters in repository.CommodityListSpec.
 func (c *CommodityController) GetAllCommodities(ctx *gin.Context) {
+	params, err := pagination.Parse(ctx.Request.URL.Query(), repository.CommodityListSpec)
+	if err != nil {
+		apperrors.Respond(ctx, err)
+		return
+	}
+
 	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	commodities, err := c.commodityService.GetAllCommodities(timeoutCtx)
+	commodities, nextCursor, err := c.commodityService.GetAllCommodities(timeoutCtx, params)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, err)
 		return
 	}
+	if nextCursor != "" {
+		ctx.Header(pagination.NextCursorHeader, nextCursor)
+	}
 	ctx.JSON(http.StatusOK, commodities)
 }
 
@@ -61,11 +74,7 @@ func (c *CommodityController) GetCommodityByID(ctx *gin.Context) {
 
 	commodity, err := c.commodityService.GetCommodityByID(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "commodity not found" || err.Error() == "invalid commodity ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, commodity)
@@ -76,7 +85,7 @@ func (c *CommodityController) UpdateCommodity(ctx *gin.Context) {
 	id := ctx.Param("id")
 	var commodity model.Commodity
 	if err := ctx.ShouldBindJSON(&commodity); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperrors.Respond(ctx, apperrors.Validation(err.Error()))
 		return
 	}
 
@@ -85,11 +94,7 @@ func (c *CommodityController) UpdateCommodity(ctx *gin.Context) {
 
 	updatedCommodity, err := c.commodityService.UpdateCommodity(timeoutCtx, id, &commodity)
 	if err != nil {
-		if err.Error() == "commodity not found" || err.Error() == "invalid commodity ID format" || err.Error() == "commodity not found or no changes made" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, updatedCommodity)
@@ -104,11 +109,7 @@ func (c *CommodityController) DeleteCommodity(ctx *gin.Context) {
 
 	err := c.commodityService.DeleteCommodity(timeoutCtx, id)
 	if err != nil {
-		if err.Error() == "commodity not found" || err.Error() == "invalid commodity ID format" {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		apperrors.Respond(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusNoContent, nil)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"time"
+	"wms-common/tracing"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -19,7 +20,8 @@ func ConnectDB() (*mongo.Client, error) { // No arguments here
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.Cfg.MongoDBURI))
+	// The monitor records a trace span for every MongoDB command.
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.Cfg.MongoDBURI).SetMonitor(tracing.MongoMonitor()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
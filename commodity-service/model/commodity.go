@@ -4,7 +4,8 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 // Commodity represents a commodity in the database.
 type Commodity struct {
-	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name   string             `bson:"name" json:"name"`
-	Amount int                `bson:"amount" json:"amount"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Amount     int                `bson:"amount" json:"amount"`
+	UnitVolume int                `bson:"unit_volume" json:"unitVolume"` // Storage taken by one unit, in the unit of Warehouse.Storage (0 counts as 1)
 }
@@ -1,22 +1,41 @@
 package repository
 
 import (
+	"commodity-service/config"
 	"commodity-service/database"
 	"commodity-service/model"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"wms-common/apperrors"
+	"wms-common/instrument"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrCommodityNotFound is returned when no commodity matches the given ID.
+var ErrCommodityNotFound = apperrors.NotFound("commodity not found")
+
+// CommodityListSpec lists the fields clients may sort and filter commodities on.
+var CommodityListSpec = pagination.Spec{
+	SortFields: map[string]string{
+		"name":   "name",
+		"amount": "amount",
+	},
+	FilterFields: map[string]pagination.Field{
+		"name": {BSON: "name", Kind: pagination.String},
+		"ids":  {BSON: "_id", Kind: pagination.ObjectIDs},
+	},
+}
+
 // CommodityRepository defines the interface for commodity data operations.
 type CommodityRepository interface {
 	CreateCommodity(ctx context.Context, commodity *model.Commodity) (*model.Commodity, error)
-	GetAllCommodities(ctx context.Context) ([]model.Commodity, error)
+	GetAllCommodities(ctx context.Context, params *pagination.Params) ([]model.Commodity, string, error)
 	GetCommodityByID(ctx context.Context, id primitive.ObjectID) (*model.Commodity, error)
 	UpdateCommodity(ctx context.Context, id primitive.ObjectID, commodity *model.Commodity) (*model.Commodity, error)
 	DeleteCommodity(ctx context.Context, id primitive.ObjectID) error
@@ -27,8 +46,12 @@ type commodityRepositoryImpl struct {
 	collection *mongo.Collection
 }
 
-// NewCommodityRepository creates a new instance of CommodityRepository.
+// NewCommodityRepository creates a new instance of CommodityRepository, backed by MongoDB
+// or, with STORAGE_BACKEND=memory, by process memory.
 func NewCommodityRepository() CommodityRepository {
+	if config.Cfg.UseMemoryStorage() {
+		return NewInMemoryCommodityRepository()
+	}
 	if database.Client == nil {
 		log.Fatal("MongoDB client is not initialized. Call database.ConnectDB() first.")
 	}
@@ -37,6 +60,9 @@ func NewCommodityRepository() CommodityRepository {
 }
 
 func (r *commodityRepositoryImpl) CreateCommodity(ctx context.Context, commodity *model.Commodity) (*model.Commodity, error) {
+	ctx, done := instrument.Repository(ctx, "commodity", "CreateCommodity")
+	defer done()
+
 	result, err := r.collection.InsertOne(ctx, commodity)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create commodity in repository: %w", err)
@@ -45,26 +71,33 @@ func (r *commodityRepositoryImpl) CreateCommodity(ctx context.Context, commodity
 	return commodity, nil
 }
 
-func (r *commodityRepositoryImpl) GetAllCommodities(ctx context.Context) ([]model.Commodity, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// GetAllCommodities returns one page of commodities and the cursor for the next page.
+func (r *commodityRepositoryImpl) GetAllCommodities(ctx context.Context, params *pagination.Params) ([]model.Commodity, string, error) {
+	ctx, done := instrument.Repository(ctx, "commodity", "GetAllCommodities")
+	defer done()
+
+	cursor, err := r.collection.Find(ctx, params.Filter(), params.FindOptions())
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve commodities from repository: %w", err)
+		return nil, "", fmt.Errorf("failed to retrieve commodities from repository: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var commodities []model.Commodity
+	commodities := []model.Commodity{}
 	if err = cursor.All(ctx, &commodities); err != nil {
-		return nil, fmt.Errorf("failed to decode commodities from cursor: %w", err)
+		return nil, "", fmt.Errorf("failed to decode commodities from cursor: %w", err)
 	}
-	return commodities, nil
+	return pagination.Page(commodities, params)
 }
 
 func (r *commodityRepositoryImpl) GetCommodityByID(ctx context.Context, id primitive.ObjectID) (*model.Commodity, error) {
+	ctx, done := instrument.Repository(ctx, "commodity", "GetCommodityByID")
+	defer done()
+
 	var commodity model.Commodity
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&commodity)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errors.New("commodity not found in repository")
+			return nil, ErrCommodityNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve commodity by ID from repository: %w", err)
 	}
@@ -72,10 +105,14 @@ func (r *commodityRepositoryImpl) GetCommodityByID(ctx context.Context, id primi
 }
 
 func (r *commodityRepositoryImpl) UpdateCommodity(ctx context.Context, id primitive.ObjectID, commodity *model.Commodity) (*model.Commodity, error) {
+	ctx, done := instrument.Repository(ctx, "commodity", "UpdateCommodity")
+	defer done()
+
 	updateDoc := bson.M{
 		"$set": bson.M{
-			"name":   commodity.Name,
-			"amount": commodity.Amount,
+			"name":        commodity.Name,
+			"amount":      commodity.Amount,
+			"unit_volume": commodity.UnitVolume,
 		},
 	}
 
@@ -83,20 +120,23 @@ func (r *commodityRepositoryImpl) UpdateCommodity(ctx context.Context, id primit
 	if err != nil {
 		return nil, fmt.Errorf("failed to update commodity in repository: %w", err)
 	}
-	if result.ModifiedCount == 0 {
-		return nil, errors.New("commodity not found or no changes made in repository")
+	if result.MatchedCount == 0 {
+		return nil, ErrCommodityNotFound
 	}
 
 	return r.GetCommodityByID(ctx, id)
 }
 
 func (r *commodityRepositoryImpl) DeleteCommodity(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := instrument.Repository(ctx, "commodity", "DeleteCommodity")
+	defer done()
+
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
 		return fmt.Errorf("failed to delete commodity from repository: %w", err)
 	}
 	if result.DeletedCount == 0 {
-		return errors.New("commodity not found in repository")
+		return ErrCommodityNotFound
 	}
 	return nil
 }
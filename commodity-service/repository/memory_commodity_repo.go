@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"commodity-service/model"
+	"context"
+	"sync"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryCommodityRepository is a CommodityRepository backed by a map, used with
+// STORAGE_BACKEND=memory and for exercising the service layer without MongoDB.
+type InMemoryCommodityRepository struct {
+	mu          sync.RWMutex
+	commodities map[primitive.ObjectID]model.Commodity
+}
+
+// NewInMemoryCommodityRepository creates an empty InMemoryCommodityRepository.
+func NewInMemoryCommodityRepository() *InMemoryCommodityRepository {
+	return &InMemoryCommodityRepository{commodities: map[primitive.ObjectID]model.Commodity{}}
+}
+
+func (r *InMemoryCommodityRepository) CreateCommodity(ctx context.Context, commodity *model.Commodity) (*model.Commodity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if commodity.ID.IsZero() {
+		commodity.ID = primitive.NewObjectID()
+	}
+	r.commodities[commodity.ID] = *commodity
+	return commodity, nil
+}
+
+func (r *InMemoryCommodityRepository) GetAllCommodities(ctx context.Context, params *pagination.Params) ([]model.Commodity, string, error) {
+	r.mu.RLock()
+	commodities := make([]model.Commodity, 0, len(r.commodities))
+	for _, commodity := range r.commodities {
+		commodities = append(commodities, commodity)
+	}
+	r.mu.RUnlock()
+
+	return pagination.Slice(commodities, params)
+}
+
+func (r *InMemoryCommodityRepository) GetCommodityByID(ctx context.Context, id primitive.ObjectID) (*model.Commodity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commodity, ok := r.commodities[id]
+	if !ok {
+		return nil, ErrCommodityNotFound
+	}
+	return &commodity, nil
+}
+
+func (r *InMemoryCommodityRepository) UpdateCommodity(ctx context.Context, id primitive.ObjectID, commodity *model.Commodity) (*model.Commodity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.commodities[id]
+	if !ok {
+		return nil, ErrCommodityNotFound
+	}
+	stored.Name = commodity.Name
+	stored.Amount = commodity.Amount
+	stored.UnitVolume = commodity.UnitVolume
+	r.commodities[id] = stored
+	return &stored, nil
+}
+
+func (r *InMemoryCommodityRepository) DeleteCommodity(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.commodities[id]; !ok {
+		return ErrCommodityNotFound
+	}
+	delete(r.commodities, id)
+	return nil
+}
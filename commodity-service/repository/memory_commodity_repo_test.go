@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"commodity-service/model"
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"testing"
+	"wms-common/pagination"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInMemoryCommodityRepository(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryCommodityRepository()
+
+	created, err := r.CreateCommodity(ctx, &model.Commodity{Name: "Pallet", Amount: 4, UnitVolume: 2})
+	if err != nil {
+		t.Fatalf("CreateCommodity: %v", err)
+	}
+	if created.ID.IsZero() {
+		t.Fatal("CreateCommodity did not assign an ID")
+	}
+
+	got, err := r.GetCommodityByID(ctx, created.ID)
+	if err != nil || *got != *created {
+		t.Fatalf("GetCommodityByID = %+v, %v; want %+v", got, err, created)
+	}
+
+	updated, err := r.UpdateCommodity(ctx, created.ID, &model.Commodity{ID: primitive.NewObjectID(), Name: "Crate", Amount: 7, UnitVolume: 3})
+	if err != nil {
+		t.Fatalf("UpdateCommodity: %v", err)
+	}
+	want := model.Commodity{ID: created.ID, Name: "Crate", Amount: 7, UnitVolume: 3}
+	if *updated != want {
+		t.Errorf("UpdateCommodity = %+v, want %+v: the ID is kept", updated, want)
+	}
+	if got, _ := r.GetCommodityByID(ctx, created.ID); *got != want {
+		t.Errorf("stored commodity = %+v, want %+v", got, want)
+	}
+
+	// The caller's copy is not the stored one.
+	updated.Name = "Changed"
+	if got, _ := r.GetCommodityByID(ctx, created.ID); got.Name != "Crate" {
+		t.Errorf("stored name = %q after changing a returned commodity, want Crate", got.Name)
+	}
+
+	if err := r.DeleteCommodity(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteCommodity: %v", err)
+	}
+	if _, err := r.GetCommodityByID(ctx, created.ID); !errors.Is(err, ErrCommodityNotFound) {
+		t.Errorf("GetCommodityByID after delete error = %v, want %v", err, ErrCommodityNotFound)
+	}
+}
+
+func TestInMemoryCommodityRepositoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryCommodityRepository()
+	missing := primitive.NewObjectID()
+
+	if _, err := r.GetCommodityByID(ctx, missing); !errors.Is(err, ErrCommodityNotFound) {
+		t.Errorf("GetCommodityByID error = %v, want %v", err, ErrCommodityNotFound)
+	}
+	if _, err := r.UpdateCommodity(ctx, missing, &model.Commodity{Name: "Crate"}); !errors.Is(err, ErrCommodityNotFound) {
+		t.Errorf("UpdateCommodity error = %v, want %v", err, ErrCommodityNotFound)
+	}
+	if err := r.DeleteCommodity(ctx, missing); !errors.Is(err, ErrCommodityNotFound) {
+		t.Errorf("DeleteCommodity error = %v, want %v", err, ErrCommodityNotFound)
+	}
+}
+
+func TestInMemoryCommodityRepositoryPages(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemoryCommodityRepository()
+	ids := map[string]primitive.ObjectID{}
+	for i, name := range []string{"Crate", "Barrel", "Pallet", "Drum", "Bin"} {
+		created, err := r.CreateCommodity(ctx, &model.Commodity{Name: name, Amount: i})
+		if err != nil {
+			t.Fatalf("CreateCommodity: %v", err)
+		}
+		ids[name] = created.ID
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{name: "by name", query: url.Values{"sort": {"name"}, "limit": {"2"}}, want: []string{"Barrel", "Bin", "Crate", "Drum", "Pallet"}},
+		{name: "by amount descending", query: url.Values{"sort": {"-amount"}, "limit": {"3"}}, want: []string{"Bin", "Drum", "Pallet", "Barrel", "Crate"}},
+		{name: "filtered by name", query: url.Values{"name": {"Drum"}}, want: []string{"Drum"}},
+		{name: "filtered by IDs", query: url.Values{"sort": {"name"}, "limit": {"1"}, "ids": {ids["Pallet"].Hex() + "," + ids["Bin"].Hex()}}, want: []string{"Bin", "Pallet"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			query := tt.query
+			for {
+				params, err := pagination.Parse(query, CommodityListSpec)
+				if err != nil {
+					t.Fatalf("Parse: %v", err)
+				}
+				commodities, next, err := r.GetAllCommodities(ctx, params)
+				if err != nil {
+					t.Fatalf("GetAllCommodities: %v", err)
+				}
+				if len(commodities) > params.Limit {
+					t.Fatalf("page of %d commodities, want at most %d", len(commodities), params.Limit)
+				}
+				for _, commodity := range commodities {
+					got = append(got, commodity.Name)
+				}
+				if next == "" {
+					break
+				}
+				query = maps.Clone(tt.query)
+				query.Set("after", next)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commodities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"commodity-service/model"
 	"commodity-service/repository"
 	"context"
-	"errors"
+	"wms-common/apperrors"
+	"wms-common/pagination"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidCommodityID is returned when a commodity ID is not a valid ObjectID.
+var ErrInvalidCommodityID = apperrors.InvalidID("invalid commodity ID format")
+
+// ErrNegativeUnitVolume is returned when a commodity declares that a unit takes negative storage.
+var ErrNegativeUnitVolume = apperrors.Validation("unit volume must not be negative")
+
 // CommodityService defines the interface for commodity business logic.
 type CommodityService interface {
 	CreateCommodity(ctx context.Context, commodity *model.Commodity) (*model.Commodity, error)
-	GetAllCommodities(ctx context.Context) ([]model.Commodity, error)
+	GetAllCommodities(ctx context.Context, params *pagination.Params) ([]model.Commodity, string, error)
 	GetCommodityByID(ctx context.Context, id string) (*model.Commodity, error)
 	UpdateCommodity(ctx context.Context, id string, commodity *model.Commodity) (*model.Commodity, error)
 	DeleteCommodity(ctx context.Context, id string) error
@@ -23,23 +30,37 @@ type commodityServiceImpl struct {
 	repository repository.CommodityRepository
 }
 
+// Dependencies groups the collaborators a CommodityService is built from.
+type Dependencies struct {
+	Commodities repository.CommodityRepository
+}
+
 // NewCommodityService creates a new instance of CommodityService.
 func NewCommodityService() CommodityService {
-	return &commodityServiceImpl{repository: repository.NewCommodityRepository()}
+	return NewCommodityServiceWithDependencies(Dependencies{Commodities: repository.NewCommodityRepository()})
+}
+
+// NewCommodityServiceWithDependencies creates a CommodityService from explicit collaborators,
+// so tests can substitute an in-memory repository.
+func NewCommodityServiceWithDependencies(deps Dependencies) CommodityService {
+	return &commodityServiceImpl{repository: deps.Commodities}
 }
 
 func (s *commodityServiceImpl) CreateCommodity(ctx context.Context, commodity *model.Commodity) (*model.Commodity, error) {
+	if commodity.UnitVolume < 0 {
+		return nil, ErrNegativeUnitVolume
+	}
 	return s.repository.CreateCommodity(ctx, commodity)
 }
 
-func (s *commodityServiceImpl) GetAllCommodities(ctx context.Context) ([]model.Commodity, error) {
-	return s.repository.GetAllCommodities(ctx)
+func (s *commodityServiceImpl) GetAllCommodities(ctx context.Context, params *pagination.Params) ([]model.Commodity, string, error) {
+	return s.repository.GetAllCommodities(ctx, params)
 }
 
 func (s *commodityServiceImpl) GetCommodityByID(ctx context.Context, id string) (*model.Commodity, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid commodity ID format")
+		return nil, ErrInvalidCommodityID
 	}
 	return s.repository.GetCommodityByID(ctx, objID)
 }
@@ -47,7 +68,10 @@ func (s *commodityServiceImpl) GetCommodityByID(ctx context.Context, id string)
 func (s *commodityServiceImpl) UpdateCommodity(ctx context.Context, id string, commodity *model.Commodity) (*model.Commodity, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, errors.New("invalid commodity ID format")
+		return nil, ErrInvalidCommodityID
+	}
+	if commodity.UnitVolume < 0 {
+		return nil, ErrNegativeUnitVolume
 	}
 	return s.repository.UpdateCommodity(ctx, objID, commodity)
 }
@@ -55,7 +79,7 @@ func (s *commodityServiceImpl) UpdateCommodity(ctx context.Context, id string, c
 func (s *commodityServiceImpl) DeleteCommodity(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return errors.New("invalid commodity ID format")
+		return ErrInvalidCommodityID
 	}
 	return s.repository.DeleteCommodity(ctx, objID)
 }
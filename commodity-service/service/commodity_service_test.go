@@ -0,0 +1,58 @@
+package service
+
+import (
+	"commodity-service/model"
+	"commodity-service/repository"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"wms-common/apperrors"
+	"wms-common/pagination"
+)
+
+func TestUnitVolume(t *testing.T) {
+	tests := []struct {
+		name       string
+		unitVolume int
+		wantErr    error
+	}{
+		{name: "positive", unitVolume: 3},
+		{name: "zero counts as one", unitVolume: 0},
+		{name: "negative", unitVolume: -1, wantErr: ErrNegativeUnitVolume},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewCommodityServiceWithDependencies(Dependencies{Commodities: repository.NewInMemoryCommodityRepository()})
+			existing, err := s.CreateCommodity(ctx, &model.Commodity{Name: "Crate", UnitVolume: 2})
+			if err != nil {
+				t.Fatalf("CreateCommodity: %v", err)
+			}
+
+			_, createErr := s.CreateCommodity(ctx, &model.Commodity{Name: "Pallet", UnitVolume: tt.unitVolume})
+			_, updateErr := s.UpdateCommodity(ctx, existing.ID.Hex(), &model.Commodity{Name: "Crate", UnitVolume: tt.unitVolume})
+			for operation, err := range map[string]error{"create": createErr, "update": updateErr} {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", operation, err, tt.wantErr)
+				}
+				if status, _ := apperrors.Status(err); tt.wantErr != nil && status != http.StatusBadRequest {
+					t.Errorf("%s status = %d, want %d", operation, status, http.StatusBadRequest)
+				}
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if stored, _ := s.GetCommodityByID(ctx, existing.ID.Hex()); stored.UnitVolume != 2 {
+				t.Errorf("unit volume after the refused update = %d, want 2", stored.UnitVolume)
+			}
+			params, err := pagination.Parse(nil, repository.CommodityListSpec)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if commodities, _, _ := s.GetAllCommodities(ctx, params); len(commodities) != 1 {
+				t.Errorf("%d commodities, want only the existing one", len(commodities))
+			}
+		})
+	}
+}
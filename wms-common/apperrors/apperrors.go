@@ -0,0 +1,135 @@
+// Package apperrors defines the kinds of domain error shared by the WMS services and the one
+// place where they are mapped to HTTP status codes and the JSON error envelope.
+//
+// Services declare their own errors with the constructors below, e.g.
+//
+//	var ErrWarehouseNotFound = apperrors.NotFound("warehouse not found")
+//
+// and controllers answer every failure with apperrors.Respond(ctx, err). Wrapping with
+// fmt.Errorf("%w: ...") keeps both the specific error and its kind visible to errors.Is.
+// Errors of no known kind are answered as internal errors without their message, which is
+// only logged.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code is the machine-readable error code sent in the envelope.
+type Code string
+
+const (
+	CodeNotFound         Code = "not_found"
+	CodeInvalidID        Code = "invalid_id"
+	CodeValidation       Code = "validation_failed"
+	CodeInvalidReference Code = "invalid_reference"
+	CodeConflict         Code = "conflict"
+	CodeUnavailable      Code = "upstream_unavailable"
+	CodeInternal         Code = "internal_error"
+)
+
+// Kinds of domain error. Match them with errors.Is.
+var (
+	// ErrNotFound means the addressed resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidID means an ID in the request is not well-formed.
+	ErrInvalidID = errors.New("invalid ID")
+	// ErrValidation means the request body or query is malformed or incomplete.
+	ErrValidation = errors.New("validation failed")
+	// ErrInvalidReference means the request is well-formed but refers to something that does not exist,
+	// such as a product or warehouse owned by another service.
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrConflict means the request clashes with the current state, e.g. not enough stock.
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable means another service the request depends on could not be reached or failed.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is a domain error of one of the kinds above, carrying its own message.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap exposes the kind, so errors.Is(err, ErrNotFound) holds for every not-found error.
+func (e *Error) Unwrap() error { return e.Kind }
+
+// New creates an error of the given kind.
+func New(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// NotFound creates an ErrNotFound error.
+func NotFound(message string) error { return New(ErrNotFound, message) }
+
+// InvalidID creates an ErrInvalidID error.
+func InvalidID(message string) error { return New(ErrInvalidID, message) }
+
+// Validation creates an ErrValidation error.
+func Validation(message string) error { return New(ErrValidation, message) }
+
+// InvalidReference creates an ErrInvalidReference error.
+func InvalidReference(message string) error { return New(ErrInvalidReference, message) }
+
+// Conflict creates an ErrConflict error.
+func Conflict(message string) error { return New(ErrConflict, message) }
+
+// Unavailable creates an ErrUnavailable error.
+func Unavailable(message string) error { return New(ErrUnavailable, message) }
+
+// Body is the JSON error envelope every service responds with.
+type Body struct {
+	Error string `json:"error"`
+	Code  Code   `json:"code"`
+}
+
+var statuses = []struct {
+	kind   error
+	status int
+	code   Code
+}{
+	{ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrInvalidID, http.StatusBadRequest, CodeInvalidID},
+	{ErrValidation, http.StatusBadRequest, CodeValidation},
+	{ErrInvalidReference, http.StatusUnprocessableEntity, CodeInvalidReference},
+	{ErrConflict, http.StatusConflict, CodeConflict},
+	{ErrUnavailable, http.StatusServiceUnavailable, CodeUnavailable},
+}
+
+// Status returns the HTTP status and error code for err. Errors of no known kind are internal errors.
+func Status(err error) (int, Code) {
+	for _, s := range statuses {
+		if errors.Is(err, s.kind) {
+			return s.status, s.code
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// internalMessage replaces the message of an internal error, which may name databases,
+// hosts or queries, in the envelope.
+const internalMessage = "internal server error"
+
+// Response returns the status and envelope for err, shaped to be passed straight to gin's ctx.JSON.
+func Response(err error) (int, Body) {
+	status, code := Status(err)
+	if code == CodeInternal {
+		return status, Body{Error: internalMessage, Code: code}
+	}
+	return status, Body{Error: err.Error(), Code: code}
+}
+
+// Respond answers the request with the status and envelope for err. An internal error is also
+// attached to the request, so the request log records what the client is not told.
+func Respond(ctx *gin.Context, err error) {
+	status, body := Response(err)
+	if body.Code == CodeInternal {
+		_ = ctx.Error(err)
+	}
+	ctx.JSON(status, body)
+}
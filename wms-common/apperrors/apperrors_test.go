@@ -0,0 +1,98 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	os.Exit(m.Run())
+}
+
+func TestRespond(t *testing.T) {
+	errWarehouseNotFound := NotFound("warehouse not found")
+	tests := []struct {
+		name       string
+		err        error
+		wantKind   error
+		wantStatus int
+		wantBody   Body
+		wantLogged bool
+	}{
+		{name: "not found", err: errWarehouseNotFound, wantKind: ErrNotFound, wantStatus: http.StatusNotFound, wantBody: Body{Error: "warehouse not found", Code: CodeNotFound}},
+		{name: "invalid ID", err: InvalidID("invalid warehouse ID format"), wantKind: ErrInvalidID, wantStatus: http.StatusBadRequest, wantBody: Body{Error: "invalid warehouse ID format", Code: CodeInvalidID}},
+		{name: "validation", err: Validation("name is required"), wantKind: ErrValidation, wantStatus: http.StatusBadRequest, wantBody: Body{Error: "name is required", Code: CodeValidation}},
+		{name: "invalid reference", err: InvalidReference("product does not exist"), wantKind: ErrInvalidReference, wantStatus: http.StatusUnprocessableEntity, wantBody: Body{Error: "product does not exist", Code: CodeInvalidReference}},
+		{name: "conflict", err: Conflict("not enough stock"), wantKind: ErrConflict, wantStatus: http.StatusConflict, wantBody: Body{Error: "not enough stock", Code: CodeConflict}},
+		{name: "unavailable", err: Unavailable("inventory service is unavailable"), wantKind: ErrUnavailable, wantStatus: http.StatusServiceUnavailable, wantBody: Body{Error: "inventory service is unavailable", Code: CodeUnavailable}},
+		{name: "bare kind", err: ErrConflict, wantKind: ErrConflict, wantStatus: http.StatusConflict, wantBody: Body{Error: "conflict", Code: CodeConflict}},
+		{
+			name:       "wrapped",
+			err:        fmt.Errorf("%w: %s", errWarehouseNotFound, "6ad27f5899789d609895b0dd"),
+			wantKind:   ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantBody:   Body{Error: "warehouse not found: 6ad27f5899789d609895b0dd", Code: CodeNotFound},
+		},
+		{
+			name:       "wrapped twice",
+			err:        fmt.Errorf("failed to move stock: %w", fmt.Errorf("%w: 4 units short", Conflict("not enough stock"))),
+			wantKind:   ErrConflict,
+			wantStatus: http.StatusConflict,
+			wantBody:   Body{Error: "failed to move stock: not enough stock: 4 units short", Code: CodeConflict},
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("failed to find warehouse: connection refused to mongodb://mongo:27017"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   Body{Error: internalMessage, Code: CodeInternal},
+			wantLogged: true,
+		},
+		{
+			name:       "unknown wrapping an unknown",
+			err:        fmt.Errorf("failed to update inventory: %w", errors.New("write conflict on inventory_db.inventory")),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   Body{Error: internalMessage, Code: CodeInternal},
+			wantLogged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantKind != nil && !errors.Is(tt.err, tt.wantKind) {
+				t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.wantKind)
+			}
+
+			rec := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rec)
+			Respond(ctx, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body Body
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q is not an error envelope: %v", rec.Body, err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %+v, want %+v", body, tt.wantBody)
+			}
+			if tt.wantStatus == http.StatusInternalServerError && strings.Contains(rec.Body.String(), tt.err.Error()) {
+				t.Errorf("body %s leaks the internal error", rec.Body)
+			}
+			if logged := len(ctx.Errors) > 0; logged != tt.wantLogged {
+				t.Errorf("error attached to the request = %v, want %v", logged, tt.wantLogged)
+			}
+			if tt.wantLogged && !errors.Is(ctx.Errors.Last().Err, tt.err) {
+				t.Errorf("attached error = %v, want %v", ctx.Errors.Last().Err, tt.err)
+			}
+		})
+	}
+}
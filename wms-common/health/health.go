@@ -0,0 +1,141 @@
+// Package health serves the liveness and readiness endpoints of the WMS services.
+//
+// /healthz only says the process is up and serving HTTP. /readyz runs every registered check
+// (MongoDB ping, reachability of the services this one calls) and answers 503 when any fails,
+// so compose and orchestrators keep traffic away until the service can actually handle it.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// CheckTimeout bounds each readiness check.
+const CheckTimeout = 2 * time.Second
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check reports whether one component the service depends on is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// ComponentStatus is the result of one check.
+type ComponentStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// Report is the JSON body of /healthz and /readyz.
+type Report struct {
+	Status        string                     `json:"status"`
+	Service       string                     `json:"service"`
+	UptimeSeconds int64                      `json:"uptimeSeconds"`
+	Checks        map[string]ComponentStatus `json:"checks,omitempty"`
+}
+
+var started = time.Now()
+
+// Liveness answers 200 as long as the process can serve requests.
+func Liveness(service string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK, Service: service, UptimeSeconds: uptime()})
+	}
+}
+
+// Readiness runs the checks concurrently and answers 200 when all pass, 503 otherwise.
+func Readiness(service string, checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := Report{Status: StatusOK, Service: service, UptimeSeconds: uptime(), Checks: map[string]ComponentStatus{}}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+				defer cancel()
+
+				start := time.Now()
+				err := check.Run(ctx)
+				status := ComponentStatus{Status: StatusOK, LatencyMs: time.Since(start).Milliseconds()}
+				if err != nil {
+					status.Status, status.Error = StatusUnavailable, err.Error()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				report.Checks[check.Name] = status
+				if err != nil {
+					report.Status = StatusUnavailable
+				}
+			}()
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(w, code, report)
+	}
+}
+
+// MongoCheck pings the primary of the client's deployment.
+func MongoCheck(client *mongo.Client) Check {
+	return Check{Name: "mongodb", Run: func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("not connected")
+		}
+		return client.Ping(ctx, readpref.Primary())
+	}}
+}
+
+var probeClient = &http.Client{Timeout: CheckTimeout}
+
+// ServiceCheck requires the named service at baseURL to answer its /healthz with 200.
+// It uses liveness rather than readiness so services that call each other cannot hold
+// one another unready.
+func ServiceCheck(name, baseURL string) Check {
+	target := strings.TrimSuffix(baseURL, "/") + "/healthz"
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := probeClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("unreachable")
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("health check returned %d", resp.StatusCode)
+		}
+		return nil
+	}}
+}
+
+func uptime() int64 {
+	return int64(time.Since(started).Seconds())
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadiness(t *testing.T) {
+	var (
+		passing = Check{Name: "mongodb", Run: func(context.Context) error { return nil }}
+		failing = Check{Name: "commodity-service", Run: func(context.Context) error { return errors.New("unreachable") }}
+	)
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus int
+		wantReport string
+		wantChecks map[string]ComponentStatus
+	}{
+		{
+			name:       "all pass",
+			checks:     []Check{passing},
+			wantStatus: http.StatusOK,
+			wantReport: StatusOK,
+			wantChecks: map[string]ComponentStatus{"mongodb": {Status: StatusOK}},
+		},
+		{
+			name:       "one fails",
+			checks:     []Check{passing, failing},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: StatusUnavailable,
+			wantChecks: map[string]ComponentStatus{
+				"mongodb":           {Status: StatusOK},
+				"commodity-service": {Status: StatusUnavailable, Error: "unreachable"},
+			},
+		},
+		{
+			name:       "a down service",
+			checks:     []Check{passing, ServiceCheck("warehouse-service", downService(t))},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: StatusUnavailable,
+			wantChecks: map[string]ComponentStatus{
+				"mongodb":           {Status: StatusOK},
+				"warehouse-service": {Status: StatusUnavailable, Error: "health check returned 500"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Readiness("inventory-service", tt.checks...).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("body %q is not a report: %v", rec.Body, err)
+			}
+			if report.Status != tt.wantReport || report.Service != "inventory-service" {
+				t.Errorf("report is %q for %q, want %q for inventory-service", report.Status, report.Service, tt.wantReport)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %+v, want %+v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				got := report.Checks[name]
+				got.LatencyMs = 0
+				if got != want {
+					t.Errorf("check %s = %+v, want %+v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// downService starts a service whose /healthz fails and returns its base URL.
+func downService(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/"
+}
+
+func TestLiveness(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Liveness("inventory-service").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("body %q is not a report: %v", rec.Body, err)
+	}
+	if rec.Code != http.StatusOK || report.Status != StatusOK || len(report.Checks) != 0 {
+		t.Errorf("liveness = %d %+v, want 200 ok without checks", rec.Code, report)
+	}
+}
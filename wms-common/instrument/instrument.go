@@ -0,0 +1,23 @@
+// Package instrument wraps repository methods in a trace span and a latency metric at once:
+//
+//	ctx, done := instrument.Repository(ctx, "inventory", "GetInventoryByID")
+//	defer done()
+package instrument
+
+import (
+	"context"
+	"time"
+	"wms-common/metrics"
+	"wms-common/tracing"
+)
+
+// Repository starts a span for a MongoDB repository method and returns the context to run its
+// queries in. Calling done ends the span and records the method's duration.
+func Repository(ctx context.Context, repository, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.StartRepository(ctx, repository, method)
+	return ctx, func() {
+		span.End()
+		metrics.ObserveMongo(repository, method, start)
+	}
+}
@@ -0,0 +1,152 @@
+// Package logging gives the WMS services structured JSON logs tied together by request ID.
+//
+// The gateway stamps every request with an X-Request-ID header (or keeps the client's) and
+// forwards it; each service logs one line per request carrying that ID, so a gateway line and
+// the service lines behind it can be found together. Calls between services forward it too.
+//
+// Setup makes a JSON slog logger the default, so the standard log package writes through it
+// as well. LOG_LEVEL (debug, info, warn or error; default info) sets the minimum level.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID between the gateway, the services and the client.
+const RequestIDHeader = "X-Request-ID"
+
+// UserHeader names the authenticated user; the gateway sets it after checking the token.
+const UserHeader = "X-User-ID"
+
+// maxRequestIDLength bounds request IDs taken from callers, which end up in every log line.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// Setup installs a JSON logger tagged with the service name as the slog and log default.
+func Setup(service string) error {
+	level := slog.LevelInfo
+	if levelStr := os.Getenv("LOG_LEVEL"); levelStr != "" {
+		if err := level.UnmarshalText([]byte(levelStr)); err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", levelStr)
+		}
+	}
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler).With("service", service))
+	return nil
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Logger returns the default logger tagged with the request and trace IDs carried by ctx.
+func Logger(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if requestID := RequestID(ctx); requestID != "" {
+		logger = logger.With("request_id", requestID)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		logger = logger.With("trace_id", spanContext.TraceID().String())
+	}
+	return logger
+}
+
+// Middleware takes the request ID from the X-Request-ID header, generating one if it is
+// missing or malformed, and puts it back on the request headers, so the gateway's proxy
+// forwards the ID it logged. It echoes the ID in the response and logs the request once it
+// completes. Requests to quietPaths, such as health probes, are logged at debug level.
+func Middleware(quietPaths ...string) gin.HandlerFunc {
+	quiet := make(map[string]bool, len(quietPaths))
+	for _, path := range quietPaths {
+		quiet[path] = true
+	}
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Request.Header.Set(RequestIDHeader, requestID)
+		ctx.Request = ctx.Request.WithContext(WithRequestID(ctx.Request.Context(), requestID))
+		ctx.Header(RequestIDHeader, requestID)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case quiet[ctx.Request.URL.Path]:
+			level = slog.LevelDebug
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("user", ctx.GetHeader(UserHeader)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", ctx.Errors.String()))
+		}
+		Logger(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 and logs it with the request ID.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, recovered any) {
+		Logger(ctx.Request.Context()).Error("panic while handling request", "panic", fmt.Sprint(recovered),
+			"method", ctx.Request.Method, "path", ctx.Request.URL.Path)
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// Transport wraps base so outgoing requests carry the request ID of the request that caused them.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if requestID := RequestID(req.Context()); requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+			req = req.Clone(req.Context())
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		return base.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// validRequestID accepts short IDs made of letters, digits and the punctuation common in
+// UUIDs and trace IDs, so callers cannot inject arbitrary text into the logs.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	return strings.IndexFunc(requestID, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r))
+	}) < 0
+}
@@ -0,0 +1,65 @@
+// Package metrics exports the Prometheus metrics the WMS services have in common: request
+// counts and latencies per route, and MongoDB operation timings per repository method.
+//
+// Each service mounts Middleware on its router and serves Handler at /metrics. Repositories
+// time their MongoDB calls through package instrument, which traces them as well.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// unmatchedRoute labels requests no route matched, so probing random URLs cannot blow up label cardinality.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongodb_operation_duration_seconds",
+		Help:    "Time taken by MongoDB operations, by repository and method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+)
+
+// Middleware records the count and latency of every request under its route pattern,
+// e.g. /inventory/:id rather than the concrete path.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		httpRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// ObserveMongo records the time since start against a repository method.
+func ObserveMongo(repository, method string, start time.Time) {
+	mongoDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
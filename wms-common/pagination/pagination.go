@@ -0,0 +1,293 @@
+// Package pagination implements cursor-based paging, sorting and simple equality
+// filters for the list endpoints of the WMS services.
+//
+// Clients pass ?limit=, ?after=, ?sort= and any filter the resource allows. Pages are
+// keyset-based: the cursor encodes the sort value and _id of the last item returned,
+// so paging stays stable while documents are inserted. It also records the sort it was
+// made for, and is refused with any other. Documents missing the sort field sort as null,
+// first in ascending order and last in descending order, as MongoDB sorts them.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"wms-common/apperrors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultLimit is the page size used when the client does not ask for one.
+	DefaultLimit = 50
+	// MaxLimit caps the page size a client may request.
+	MaxLimit = 500
+
+	// NextCursorHeader carries the cursor for the following page; it is absent on the last page.
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+// ErrInvalidQuery is wrapped by every error Parse returns for a malformed list query.
+var ErrInvalidQuery = apperrors.Validation("invalid list query")
+
+// Kind describes how a filter's query string value is converted before matching.
+type Kind int
+
+const (
+	// String matches the value as-is.
+	String Kind = iota
+	// ObjectID matches a hex-encoded MongoDB ObjectID.
+	ObjectID
+	// Int matches a base-10 integer.
+	Int
+	// ObjectIDs matches any of a comma-separated list of at most MaxLimit hex-encoded
+	// ObjectIDs, e.g. to read a known set of records in one request.
+	ObjectIDs
+)
+
+// Field maps a query parameter onto a BSON document field.
+type Field struct {
+	BSON string
+	Kind Kind
+}
+
+// Spec lists what a resource allows clients to sort and filter on, keyed by query parameter name.
+type Spec struct {
+	SortFields   map[string]string
+	FilterFields map[string]Field
+	// DefaultSort is used when the client sends no ?sort=, e.g. "-timestamp". Empty means "_id".
+	DefaultSort string
+}
+
+// Cursor marks the last item of the previous page, and the sort that page was read with.
+type Cursor struct {
+	Field      string             `bson:"f"`
+	Descending bool               `bson:"d"`
+	Value      bson.RawValue      `bson:"v"`
+	ID         primitive.ObjectID `bson:"id"`
+}
+
+// Params describes one page request against a list endpoint.
+type Params struct {
+	Limit      int
+	After      *Cursor
+	SortField  string
+	Descending bool
+	Filters    bson.M
+}
+
+// Parse reads limit, after, sort and the filters allowed by spec from a query string.
+func Parse(query url.Values, spec Spec) (*Params, error) {
+	params := &Params{Limit: DefaultLimit, SortField: "_id", Filters: bson.M{}}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("%w: limit must be a positive integer", ErrInvalidQuery)
+		}
+		params.Limit = min(limit, MaxLimit)
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	if sort != "" {
+		name := strings.TrimPrefix(sort, "-")
+		params.Descending = strings.HasPrefix(sort, "-")
+		field, ok := spec.SortFields[name]
+		if !ok && name != "id" {
+			return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, name)
+		}
+		if ok {
+			params.SortField = field
+		}
+	}
+
+	if after := query.Get("after"); after != "" {
+		cursor, err := decodeCursor(after)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+		}
+		if cursor.Field != params.SortField || cursor.Descending != params.Descending {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidQuery)
+		}
+		params.After = cursor
+	}
+
+	for name, field := range spec.FilterFields {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := convert(raw, field.Kind)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid value for %s", ErrInvalidQuery, name)
+		}
+		params.Filters[field.BSON] = value
+	}
+	return params, nil
+}
+
+// Filter returns the MongoDB filter for the page, combining the client's filters with the
+// keyset condition that skips everything up to and including the cursor.
+//
+// MongoDB's $gt and $lt only compare values of the same type, so they never match null or a
+// missing field. Nulls sort before every other value, so they are matched explicitly: after a
+// null cursor value, ascending pages continue with the remaining nulls and then every non-null
+// value; descending pages, which end with the nulls, pick them up after any non-null cursor.
+func (p *Params) Filter() bson.M {
+	filter := bson.M{}
+	for key, value := range p.Filters {
+		filter[key] = value
+	}
+	if p.After == nil {
+		return filter
+	}
+
+	op := "$gt"
+	if p.Descending {
+		op = "$lt"
+	}
+	if p.SortField == "_id" {
+		filter["_id"] = bson.M{op: p.After.ID}
+		return filter
+	}
+	sameValue := bson.M{p.SortField: p.After.Value, "_id": bson.M{op: p.After.ID}}
+	isNull := p.After.Value.Type == bson.TypeNull || p.After.Value.Type == bson.TypeUndefined
+	switch {
+	case isNull && p.Descending:
+		filter["$or"] = bson.A{sameValue}
+	case isNull:
+		filter["$or"] = bson.A{
+			bson.M{p.SortField: nil, "_id": bson.M{op: p.After.ID}},
+			bson.M{p.SortField: bson.M{"$ne": nil}},
+		}
+	case p.Descending:
+		filter["$or"] = bson.A{
+			bson.M{p.SortField: bson.M{op: p.After.Value}},
+			sameValue,
+			bson.M{p.SortField: nil},
+		}
+	default:
+		filter["$or"] = bson.A{
+			bson.M{p.SortField: bson.M{op: p.After.Value}},
+			sameValue,
+		}
+	}
+	return filter
+}
+
+// FindOptions returns the sort and limit for the page. One extra document is fetched so
+// Page can tell whether another page follows.
+func (p *Params) FindOptions() *options.FindOptions {
+	direction := 1
+	if p.Descending {
+		direction = -1
+	}
+	sort := bson.D{{Key: p.SortField, Value: direction}}
+	if p.SortField != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+	return options.Find().SetSort(sort).SetLimit(int64(p.Limit) + 1)
+}
+
+// Page trims the look-ahead item fetched by FindOptions and returns the cursor for the
+// following page, or "" when items is the last page.
+func Page[T any](items []T, p *Params) ([]T, string, error) {
+	if len(items) <= p.Limit {
+		return items, "", nil
+	}
+	items = items[:p.Limit]
+
+	raw, err := bson.Marshal(items[len(items)-1])
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build next cursor: %w", err)
+	}
+	cursor, err := cursorFor(bson.Raw(raw), p.SortField)
+	if err != nil {
+		return nil, "", err
+	}
+	cursor.Descending = p.Descending
+	next, err := encodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+// cursorFor captures the sort value and _id of a marshalled document.
+func cursorFor(doc bson.Raw, sortField string) (*Cursor, error) {
+	id, ok := doc.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return nil, errors.New("failed to build next cursor: item has no ObjectID")
+	}
+	value, err := doc.LookupErr(strings.Split(sortField, ".")...)
+	if err != nil {
+		// Documents missing the sort field sort as null.
+		value = bson.RawValue{Type: bson.TypeNull}
+	}
+	return &Cursor{Field: sortField, Value: value, ID: id}, nil
+}
+
+func encodeCursor(cursor *Cursor) (string, error) {
+	raw, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor decodes a cursor sent back by a client. Cursors are not signed, so one is only
+// accepted if it could have come from Page: it names a field, and its value is of a type the
+// services sort on rather than, say, a document of query operators.
+func decodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var cursor Cursor
+	if err := bson.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Field == "" {
+		return nil, errors.New("cursor names no field")
+	}
+	switch cursor.Value.Type {
+	case bson.TypeNull, bson.TypeInt32, bson.TypeInt64, bson.TypeDouble, bson.TypeString,
+		bson.TypeObjectID, bson.TypeBoolean, bson.TypeDateTime:
+	default:
+		return nil, fmt.Errorf("cursor value of type %s", cursor.Value.Type)
+	}
+	return &cursor, nil
+}
+
+func convert(raw string, kind Kind) (interface{}, error) {
+	switch kind {
+	case ObjectID:
+		return primitive.ObjectIDFromHex(raw)
+	case ObjectIDs:
+		parts := strings.Split(raw, ",")
+		if len(parts) > MaxLimit {
+			return nil, fmt.Errorf("more than %d values", MaxLimit)
+		}
+		ids := make(bson.A, 0, len(parts))
+		for _, part := range parts {
+			id, err := primitive.ObjectIDFromHex(part)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return bson.M{"$in": ids}, nil
+	case Int:
+		return strconv.Atoi(raw)
+	default:
+		return raw, nil
+	}
+}
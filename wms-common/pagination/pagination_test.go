@@ -0,0 +1,372 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// item is a stored document. Qty is left out when nil and Name is stored as null, so both ways
+// a document can lack a sort value are covered.
+type item struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Zone string             `bson:"zone"`
+	Qty  *int               `bson:"qty,omitempty"`
+	Name *string            `bson:"name"`
+}
+
+var spec = Spec{
+	SortFields:   map[string]string{"zone": "zone", "qty": "qty", "name": "name"},
+	FilterFields: map[string]Field{"zone": {BSON: "zone"}, "qty": {BSON: "qty", Kind: Int}, "owner": {BSON: "owner_id", Kind: ObjectID}, "ids": {BSON: "_id", Kind: ObjectIDs}},
+}
+
+func oid(n int) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(fmt.Sprintf("%024x", n))
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// items are numbered by their _id, which is also the order they were inserted in.
+var items = []item{
+	{ID: oid(1), Zone: "A", Qty: ptr(5), Name: ptr("b")},
+	{ID: oid(2), Zone: "B"},
+	{ID: oid(3), Zone: "A", Qty: ptr(2)},
+	{ID: oid(4), Zone: "B", Qty: ptr(5), Name: ptr("a")},
+	{ID: oid(5), Zone: "A", Name: ptr("b")},
+	{ID: oid(6), Zone: "B", Qty: ptr(2), Name: ptr("c")},
+}
+
+func parse(t *testing.T, query string) *Params {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := Parse(values, spec)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", query, err)
+	}
+	return params
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		wantLimit      int
+		wantSort       string
+		wantDescending bool
+		wantFilters    bson.M
+	}{
+		{name: "defaults", query: "", wantLimit: DefaultLimit, wantSort: "_id", wantFilters: bson.M{}},
+		{name: "limit is capped", query: "limit=10000", wantLimit: MaxLimit, wantSort: "_id", wantFilters: bson.M{}},
+		{name: "descending sort", query: "limit=3&sort=-qty", wantLimit: 3, wantSort: "qty", wantDescending: true, wantFilters: bson.M{}},
+		{name: "sort by id", query: "sort=-id", wantLimit: DefaultLimit, wantSort: "_id", wantDescending: true, wantFilters: bson.M{}},
+		{
+			name:        "filters are converted",
+			query:       "zone=A&qty=5&owner=" + oid(7).Hex() + "&color=red",
+			wantLimit:   DefaultLimit,
+			wantSort:    "_id",
+			wantFilters: bson.M{"zone": "A", "qty": 5, "owner_id": oid(7)},
+		},
+		{
+			name:        "list filter",
+			query:       "ids=" + oid(3).Hex() + "," + oid(1).Hex(),
+			wantLimit:   DefaultLimit,
+			wantSort:    "_id",
+			wantFilters: bson.M{"_id": bson.M{"$in": bson.A{oid(3), oid(1)}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := parse(t, tt.query)
+			if params.Limit != tt.wantLimit || params.SortField != tt.wantSort || params.Descending != tt.wantDescending {
+				t.Errorf("got limit %d sort %q descending %v, want %d %q %v",
+					params.Limit, params.SortField, params.Descending, tt.wantLimit, tt.wantSort, tt.wantDescending)
+			}
+			if fmt.Sprint(params.Filters) != fmt.Sprint(tt.wantFilters) {
+				t.Errorf("filters = %v, want %v", params.Filters, tt.wantFilters)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	// A real cursor for the second page by ascending qty.
+	_, next, err := Slice(items, parse(t, "sort=qty&limit=2"))
+	if err != nil || next == "" {
+		t.Fatalf("Slice: next %q, %v", next, err)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := slices.Clone(raw)
+	tampered[0]++ // The document length no longer matches its bytes.
+	forged, err := encodeCursor(&Cursor{Field: "owner_id", Value: bson.RawValue{Type: bson.TypeNull}, ID: oid(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operators, err := bson.Marshal(bson.M{"$ne": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withOperators, err := encodeCursor(&Cursor{Field: "qty", Value: bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: operators}, ID: oid(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutField, err := encodeCursor(&Cursor{Value: bson.RawValue{Type: bson.TypeNull}, ID: oid(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "zero limit", query: "limit=0"},
+		{name: "non-numeric limit", query: "limit=ten"},
+		{name: "unknown sort field", query: "sort=owner"},
+		{name: "cursor that is not base64", query: "after=%21%21%21"},
+		{name: "cursor that is not a document", query: "after=" + base64.RawURLEncoding.EncodeToString([]byte("qty"))},
+		{name: "tampered cursor", query: "after=" + base64.RawURLEncoding.EncodeToString(tampered)},
+		{name: "truncated cursor", query: "after=" + next[:len(next)/2]},
+		{name: "cursor reused with the other direction", query: "sort=-qty&after=" + next},
+		{name: "cursor reused with another field", query: "sort=name&after=" + next},
+		{name: "cursor reused with the default sort", query: "after=" + next},
+		{name: "cursor for a field that cannot be sorted on", query: "sort=qty&after=" + forged},
+		{name: "cursor holding query operators", query: "sort=qty&after=" + withOperators},
+		{name: "cursor without a field", query: "after=" + withoutField},
+		{name: "filter that is not an integer", query: "qty=many"},
+		{name: "filter that is not an ObjectID", query: "owner=42"},
+		{name: "list filter with a value that is not an ObjectID", query: "ids=" + oid(1).Hex() + ",42"},
+		{name: "list filter with an empty value", query: "ids=" + oid(1).Hex() + ","},
+		{name: "list filter that is too long", query: "ids=" + strings.Repeat(oid(1).Hex()+",", MaxLimit) + oid(1).Hex()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Parse(values, spec); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.query, err, ErrInvalidQuery)
+			}
+		})
+	}
+}
+
+func TestPaging(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []int // _ids in the order they are listed
+	}{
+		{query: "", want: []int{1, 2, 3, 4, 5, 6}},
+		{query: "sort=-id", want: []int{6, 5, 4, 3, 2, 1}},
+		// Missing values sort first ascending and last descending; ties go by _id in the same direction.
+		{query: "sort=qty", want: []int{2, 5, 3, 6, 1, 4}},
+		{query: "sort=-qty", want: []int{4, 1, 6, 3, 5, 2}},
+		// Null values behave like missing ones.
+		{query: "sort=name", want: []int{2, 3, 4, 1, 5, 6}},
+		{query: "sort=-name", want: []int{6, 5, 1, 4, 3, 2}},
+		{query: "sort=zone", want: []int{1, 3, 5, 2, 4, 6}},
+		{query: "sort=-zone", want: []int{6, 4, 2, 5, 3, 1}},
+		{query: "sort=-qty&zone=A", want: []int{1, 3, 5}},
+		{query: "sort=name&zone=B", want: []int{2, 4, 6}},
+		{query: "qty=2", want: []int{3, 6}},
+		{query: "sort=-qty&ids=" + oid(2).Hex() + "," + oid(4).Hex() + "," + oid(6).Hex() + "," + oid(9).Hex(), want: []int{4, 6, 2}},
+	}
+	backends := map[string]func(*testing.T, *Params) ([]item, string, error){
+		"Slice":  func(_ *testing.T, p *Params) ([]item, string, error) { return Slice(items, p) },
+		"Filter": func(t *testing.T, p *Params) ([]item, string, error) { return mongoFind(t, items, p) },
+	}
+	for _, tt := range tests {
+		for name, find := range backends {
+			// Every page size puts page boundaries on nulls, on ties and between them.
+			for limit := 1; limit <= len(items); limit++ {
+				t.Run(fmt.Sprintf("%s/%s/limit=%d", name, tt.query, limit), func(t *testing.T) {
+					var got []int
+					after := ""
+					for pages := 0; pages <= len(items); pages++ {
+						query := fmt.Sprintf("%s&limit=%d&after=%s", tt.query, limit, url.QueryEscape(after))
+						page, next, err := find(t, parse(t, query))
+						if err != nil {
+							t.Fatalf("page %d: %v", pages+1, err)
+						}
+						if len(page) > limit {
+							t.Fatalf("page %d has %d items, want at most %d", pages+1, len(page), limit)
+						}
+						for _, it := range page {
+							got = append(got, int(it.ID[11]))
+						}
+						if after = next; after == "" {
+							break
+						}
+					}
+					if !slices.Equal(got, tt.want) {
+						t.Errorf("listed %v, want %v", got, tt.want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestSliceFiltersInsideArrays(t *testing.T) {
+	type line struct {
+		SKU string `bson:"sku"`
+	}
+	type order struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Lines []line             `bson:"lines"`
+	}
+	orders := []order{
+		{ID: oid(1), Lines: []line{{SKU: "a"}, {SKU: "b"}}},
+		{ID: oid(2), Lines: []line{{SKU: "c"}}},
+		{ID: oid(3)},
+		{ID: oid(4), Lines: []line{{SKU: "b"}}},
+	}
+	page, _, err := Slice(orders, &Params{Limit: MaxLimit, SortField: "_id", Filters: bson.M{"lines.sku": "b"}})
+	if err != nil {
+		t.Fatalf("Slice: %v", err)
+	}
+	var got []int
+	for _, o := range page {
+		got = append(got, int(o.ID[11]))
+	}
+	if want := []int{1, 4}; !slices.Equal(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+}
+
+// mongoFind runs a page request over items as MongoDB would run the query built from Filter
+// and FindOptions, then pages the result with Page.
+func mongoFind(t *testing.T, items []item, p *Params) ([]item, string, error) {
+	t.Helper()
+	type entry struct {
+		item item
+		doc  bson.Raw
+	}
+	var found []entry
+	filter := p.Filter()
+	for _, it := range items {
+		doc, err := bson.Marshal(it)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mongoMatch(t, doc, filter) {
+			found = append(found, entry{item: it, doc: doc})
+		}
+	}
+
+	opts := p.FindOptions()
+	keys := opts.Sort.(bson.D)
+	sort.SliceStable(found, func(i, j int) bool {
+		for _, key := range keys {
+			result := compareValues(lookup(found[i].doc, key.Key), lookup(found[j].doc, key.Key))
+			if result != 0 {
+				return result*key.Value.(int) < 0
+			}
+		}
+		return false
+	})
+	page := []item{}
+	for _, e := range found {
+		if int64(len(page)) == *opts.Limit {
+			break
+		}
+		page = append(page, e.item)
+	}
+	return Page(page, p)
+}
+
+// mongoMatch reports whether MongoDB would match doc against filter, for the operators
+// Filter uses. As in MongoDB, a missing field equals null, and $gt and $lt only compare
+// values of the same type.
+func mongoMatch(t *testing.T, doc bson.Raw, filter bson.M) bool {
+	t.Helper()
+	for key, condition := range filter {
+		if key == "$or" {
+			matched := false
+			for _, alternative := range condition.(bson.A) {
+				matched = matched || mongoMatch(t, doc, alternative.(bson.M))
+			}
+			if !matched {
+				return false
+			}
+			continue
+		}
+
+		got, err := doc.LookupErr(key)
+		isNull := err != nil || got.Type == bson.TypeNull
+		operators, ok := condition.(bson.M)
+		if !ok {
+			operators = bson.M{"$eq": condition}
+		}
+		for op, operand := range operators {
+			if raw, ok := operand.(bson.RawValue); ok && raw.Type == bson.TypeNull {
+				operand = nil
+			}
+			var matched bool
+			switch {
+			case op == "$in":
+				for _, element := range operand.(bson.A) {
+					want := rawValue(t, element)
+					matched = matched || (!isNull && typeRank(got.Type) == typeRank(want.Type) && compareValues(got, want) == 0)
+				}
+			case operand == nil && op == "$eq":
+				matched = isNull
+			case operand == nil && op == "$ne":
+				matched = !isNull
+			case isNull:
+				matched = false
+			default:
+				want := rawValue(t, operand)
+				if typeRank(got.Type) != typeRank(want.Type) {
+					matched = false
+					break
+				}
+				result := compareValues(got, want)
+				switch op {
+				case "$eq":
+					matched = result == 0
+				case "$gt":
+					matched = result > 0
+				case "$lt":
+					matched = result < 0
+				default:
+					t.Fatalf("unexpected operator %s", op)
+				}
+			}
+			if !matched {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func rawValue(t *testing.T, v interface{}) bson.RawValue {
+	t.Helper()
+	if raw, ok := v.(bson.RawValue); ok {
+		return raw
+	}
+	typ, data, err := bson.MarshalValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bson.RawValue{Type: typ, Value: data}
+}
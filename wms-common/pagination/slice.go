@@ -0,0 +1,239 @@
+package pagination
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Slice applies the page request to an in-memory collection the way Filter and FindOptions
+// apply it to a MongoDB query: filters match by equality (or membership for array fields, and
+// any of the values of an ObjectIDs filter), items are ordered by the sort field then _id, and the page starts after the cursor.
+// Items are compared through their BSON encoding, so the BSON field names in p apply.
+func Slice[T any](items []T, p *Params) ([]T, string, error) {
+	type entry struct {
+		item T
+		doc  bson.Raw
+	}
+
+	filters := make(map[string][]bson.RawValue, len(p.Filters))
+	for key, value := range p.Filters {
+		values := bson.A{value}
+		if operators, ok := value.(bson.M); ok {
+			if in, ok := operators["$in"].(bson.A); ok {
+				values = in
+			}
+		}
+		for _, value := range values {
+			t, data, err := bson.MarshalValue(value)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to encode filter %s: %w", key, err)
+			}
+			filters[key] = append(filters[key], bson.RawValue{Type: t, Value: data})
+		}
+	}
+
+	entries := make([]entry, 0, len(items))
+	for _, item := range items {
+		raw, err := bson.Marshal(item)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode item: %w", err)
+		}
+		doc := bson.Raw(raw)
+		if matches(doc, filters) {
+			entries = append(entries, entry{item: item, doc: doc})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return p.compareDocs(entries[i].doc, entries[j].doc) < 0
+	})
+
+	page := make([]T, 0, p.Limit+1)
+	for _, e := range entries {
+		if p.After != nil && !p.isAfterCursor(e.doc) {
+			continue
+		}
+		page = append(page, e.item)
+		if len(page) > p.Limit {
+			break
+		}
+	}
+	return Page(page, p)
+}
+
+// matches reports whether, for every filter, the document's field or, for arrays, one of its
+// elements equals one of the filter's values. A dotted field reaches into arrays of documents.
+func matches(doc bson.Raw, filters map[string][]bson.RawValue) bool {
+	for key, wanted := range filters {
+		candidates := fieldValues(bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: doc}, strings.Split(key, "."))
+		if len(candidates) == 0 {
+			candidates = []bson.RawValue{{Type: bson.TypeNull}}
+		}
+		found := false
+		for _, candidate := range candidates {
+			for _, want := range wanted {
+				if compareValues(candidate, want) == 0 {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValues returns the values path leads to from value, as a MongoDB query sees them: an
+// array stands for its elements, and the rest of the path is followed into each of them.
+func fieldValues(value bson.RawValue, path []string) []bson.RawValue {
+	if value.Type == bson.TypeArray {
+		elements, err := value.Array().Values()
+		if err != nil {
+			return nil
+		}
+		if len(path) == 0 {
+			return elements
+		}
+		var found []bson.RawValue
+		for _, element := range elements {
+			found = append(found, fieldValues(element, path)...)
+		}
+		return found
+	}
+	if len(path) == 0 {
+		return []bson.RawValue{value}
+	}
+	if value.Type != bson.TypeEmbeddedDocument {
+		return nil
+	}
+	next, err := value.Document().LookupErr(path[0])
+	if err != nil {
+		return nil
+	}
+	return fieldValues(next, path[1:])
+}
+
+// compareDocs orders two documents by the page's sort field and direction, breaking ties on _id.
+func (p *Params) compareDocs(a, b bson.Raw) int {
+	result := compareValues(lookup(a, p.SortField), lookup(b, p.SortField))
+	if result == 0 && p.SortField != "_id" {
+		result = compareValues(a.Lookup("_id"), b.Lookup("_id"))
+	}
+	if p.Descending {
+		return -result
+	}
+	return result
+}
+
+// isAfterCursor reports whether doc sorts strictly after the page's cursor.
+func (p *Params) isAfterCursor(doc bson.Raw) bool {
+	t, data, err := bson.MarshalValue(p.After.ID)
+	if err != nil {
+		return false
+	}
+	id := bson.RawValue{Type: t, Value: data}
+
+	result := compareValues(doc.Lookup("_id"), id)
+	if p.SortField != "_id" {
+		if byField := compareValues(lookup(doc, p.SortField), p.After.Value); byField != 0 {
+			result = byField
+		}
+	}
+	if p.Descending {
+		return result < 0
+	}
+	return result > 0
+}
+
+// lookup returns a possibly dotted field of doc, or null when it is missing.
+func lookup(doc bson.Raw, field string) bson.RawValue {
+	value, err := doc.LookupErr(strings.Split(field, ".")...)
+	if err != nil {
+		return bson.RawValue{Type: bson.TypeNull}
+	}
+	return value
+}
+
+// compareValues orders BSON values following MongoDB's comparison order for the types the
+// services store: null, numbers, strings, ObjectIDs, booleans, then dates.
+func compareValues(a, b bson.RawValue) int {
+	if rankA, rankB := typeRank(a.Type), typeRank(b.Type); rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch a.Type {
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble:
+		x, y := asFloat(a), asFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case bson.TypeString:
+		return strings.Compare(a.StringValue(), b.StringValue())
+	case bson.TypeObjectID:
+		x, y := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(x[:], y[:])
+	case bson.TypeBoolean:
+		x, y := a.Boolean(), b.Boolean()
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case bson.TypeDateTime:
+		x, y := a.DateTime(), b.DateTime()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case bson.TypeNull, bson.TypeUndefined:
+		return 0
+	}
+	return bytes.Compare(a.Value, b.Value)
+}
+
+func typeRank(t bsontype.Type) int {
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		return 0
+	case bson.TypeInt32, bson.TypeInt64, bson.TypeDouble:
+		return 1
+	case bson.TypeString:
+		return 2
+	case bson.TypeEmbeddedDocument:
+		return 3
+	case bson.TypeArray:
+		return 4
+	case bson.TypeObjectID:
+		return 5
+	case bson.TypeBoolean:
+		return 6
+	case bson.TypeDateTime:
+		return 7
+	}
+	return 8
+}
+
+func asFloat(v bson.RawValue) float64 {
+	switch v.Type {
+	case bson.TypeInt32:
+		return float64(v.Int32())
+	case bson.TypeInt64:
+		return float64(v.Int64())
+	}
+	return v.Double()
+}
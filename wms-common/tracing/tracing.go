@@ -0,0 +1,156 @@
+// Package tracing sets up OpenTelemetry tracing for the WMS services.
+//
+// Trace context travels between services in W3C traceparent headers. Spans are exported
+// according to the environment:
+//
+//	TRACING_EXPORTER      none (default), stdout or otlp
+//	TRACING_FILE          with stdout, write spans to this file instead of standard output
+//	TRACING_SAMPLE_RATIO  fraction of new traces to sample, 0 to 1 (default 1)
+//
+// The otlp exporter sends OTLP over HTTP and reads the standard OTEL_EXPORTER_OTLP_ENDPOINT
+// (default http://localhost:4318) and related OTEL_EXPORTER_OTLP_* variables.
+// Even with no exporter, incoming trace context is passed on to downstream calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config chooses where spans go.
+type Config struct {
+	Exporter    string
+	File        string
+	SampleRatio float64
+}
+
+// ConfigFromEnv reads the TRACING_* variables.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Exporter: ExporterNone, File: os.Getenv("TRACING_FILE"), SampleRatio: 1}
+	if exporter := os.Getenv("TRACING_EXPORTER"); exporter != "" {
+		cfg.Exporter = exporter
+	}
+	switch cfg.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		return Config{}, fmt.Errorf("invalid TRACING_EXPORTER %q: must be %q, %q or %q", cfg.Exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+	if ratioStr := os.Getenv("TRACING_SAMPLE_RATIO"); ratioStr != "" {
+		ratio, err := strconv.ParseFloat(ratioStr, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return Config{}, fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q: must be between 0 and 1", ratioStr)
+		}
+		cfg.SampleRatio = ratio
+	}
+	return cfg, nil
+}
+
+// Setup installs the W3C propagator and, unless the exporter is "none", a tracer provider
+// exporting spans for serviceName. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, serviceName string, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch cfg.Exporter {
+	case ExporterStdout:
+		var out io.Writer = os.Stdout
+		if cfg.File != "" {
+			file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open trace file: %w", err)
+			}
+			out, closeFile = file, file.Close
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		exporter = stdout
+	case ExporterOTLP:
+		otlp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		exporter = otlp
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			if closeErr := closeFile(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Middleware starts a server span for every request, continuing the caller's trace.
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
+
+// StartRepository starts a span for a repository method, e.g. "inventory.GetInventoryByID".
+// The MongoDB commands it issues appear as its children.
+func StartRepository(ctx context.Context, repository, method string) (context.Context, trace.Span) {
+	return otel.Tracer("wms-common/tracing").Start(ctx, repository+"."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(semconv.CodeFunction(method), semconv.CodeNamespace(repository+"Repository")),
+	)
+}
+
+// Transport wraps base so outgoing requests get a client span and carry the trace context.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// ProxyTransport wraps base like Transport, but names each client span after the upstream it
+// proxies to, e.g. "proxy inventory-service GET", as the gateway reports them.
+func ProxyTransport(upstream string, base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base, otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		return "proxy " + upstream + " " + req.Method
+	}))
+}
+
+// MongoMonitor creates a command monitor that records a span for every MongoDB command.
+func MongoMonitor() *event.CommandMonitor {
+	return otelmongo.NewMonitor()
+}
@@ -188,10 +188,18 @@ const BoxIcon = () => (
 // The API base URL. This is now an ABSOLUTE URL.
 const API_BASE_URL = 'http://localhost:8080/api'; 
 
+// Bearer token for the gateway's JWT check. Leave unset when the gateway runs with AUTH_DISABLED=true.
+const API_TOKEN = process.env.REACT_APP_API_TOKEN;
+
+// Adds the Authorization header to request options when a token is configured.
+const withAuth = (options = {}) => (
+  API_TOKEN ? { ...options, headers: { ...options.headers, Authorization: `Bearer ${API_TOKEN}` } } : options
+);
+
 // Generic fetch utility to handle API calls
 const fetchData = async (url, options = {}) => {
   try {
-    const response = await fetch(url, options);
+    const response = await fetch(url, withAuth(options));
     if (!response.ok) {
       // Attempt to parse JSON error message from backend
       const errorData = await response.json().catch(() => ({ message: `HTTP error! status: ${response.status}` }));
@@ -208,10 +216,30 @@ const fetchData = async (url, options = {}) => {
   }
 };
 
+// Fetch one page of a list endpoint. The cursor for the following page comes back in
+// the X-Next-Cursor response header and is null on the last page. Gateway views wrap their
+// rows in { items, partial, errors }; plain list endpoints return a bare array.
+const fetchPage = async (url, after = null) => {
+  const pageUrl = after ? `${url}${url.includes('?') ? '&' : '?'}after=${encodeURIComponent(after)}` : url;
+  const response = await fetch(pageUrl, withAuth());
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({ message: `HTTP error! status: ${response.status}` }));
+    throw new Error(errorData.error || errorData.message || `Failed to fetch data from ${url}. Status: ${response.status}`);
+  }
+  const body = await response.json();
+  const items = Array.isArray(body) ? body : body.items;
+  if (body.partial) {
+    console.warn('Partial response from', url, body.errors);
+  }
+  return { items, nextCursor: response.headers.get('X-Next-Cursor') };
+};
+
 // Base component for displaying lists with add/edit/delete functionality
 // Now uses a modal for forms
-const CrudPage = ({ title, fields, apiUrl, initialFormState, idField = 'id', children }) => { // Changed idField default to 'id'
+// listUrl, when given, is read for the table instead of apiUrl (e.g. a gateway view); changes still go to apiUrl.
+const CrudPage = ({ title, fields, apiUrl, listUrl = apiUrl, initialFormState, idField = 'id', children }) => { // Changed idField default to 'id'
   const [items, setItems] = useState([]);
+  const [nextCursor, setNextCursor] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const [form, setForm] = useState(initialFormState);
@@ -225,16 +253,28 @@ const CrudPage = ({ title, fields, apiUrl, initialFormState, idField = 'id', chi
     setLoading(true);
     setError(null);
     try {
-      const data = await fetchData(apiUrl);
-      setItems(data || []); // Ensure data is an array
+      const page = await fetchPage(listUrl);
+      setItems(page.items || []); // Ensure data is an array
+      setNextCursor(page.nextCursor);
     } catch (err) {
       setError(err.message);
     } finally {
       setLoading(false);
     }
-  }, [apiUrl]);
+  }, [listUrl]);
+
+  // Append the next page of items to the list
+  const loadMore = async () => {
+    try {
+      const page = await fetchPage(listUrl, nextCursor);
+      setItems(prev => [...prev, ...(page.items || [])]);
+      setNextCursor(page.nextCursor);
+    } catch (err) {
+      setError(err.message);
+    }
+  };
 
-  // Fetch items on component mount and when apiUrl changes
+  // Fetch items on component mount and when listUrl changes
   useEffect(() => {
     fetchItems();
   }, [fetchItems]);
@@ -421,6 +461,11 @@ const CrudPage = ({ title, fields, apiUrl, initialFormState, idField = 'id', chi
           </tbody>
         </table>
       </div>
+      {nextCursor && (
+        <div className="flex justify-center mt-4">
+          <Button variant="secondary" onClick={loadMore}>Load more</Button>
+        </div>
+      )}
 
       {/* Modal for Add/Edit Form */}
       <Modal show={showModal} onClose={() => setShowModal(false)} title={isEditing ? `Edit ${title.slice(0, -1)}` : `Add New ${title.slice(0, -1)}`}>
@@ -512,24 +557,30 @@ const CommodityPage = () => (
     fields={[
       { name: 'name', label: 'Name' },
       { name: 'amount', label: 'Amount (quantity)', type: 'number' },
+      { name: 'unitVolume', label: 'Unit Volume (storage per unit)', type: 'number' },
     ]}
-    initialFormState={{ name: '', amount: '' }}
+    initialFormState={{ name: '', amount: '', unitVolume: '' }}
   />
 );
 
 // Inventory Page - Now much cleaner using CrudPage and dynamic options
 const InventoryPage = () => {
   const [commodities, setCommodities] = useState([]);
+  const [warehouses, setWarehouses] = useState([]);
   const [loadingLookups, setLoadingLookups] = useState(true);
   const [lookupError, setLookupError] = useState(null);
 
-  // Fetch commodities for the dropdown
+  // Fetch commodities and warehouses for the form's dropdowns
   const fetchLookupData = useCallback(async () => {
     setLoadingLookups(true);
     setLookupError(null);
     try {
-      const commData = await fetchData(`${API_BASE_URL}/commodities`);
+      const [commData, warehouseData] = await Promise.all([
+        fetchData(`${API_BASE_URL}/commodities?limit=500`),
+        fetchData(`${API_BASE_URL}/warehouses?limit=500`),
+      ]);
       setCommodities(commData || []);
+      setWarehouses(warehouseData || []);
     } catch (err) {
       setLookupError(err.message);
     } finally {
@@ -544,16 +595,11 @@ const InventoryPage = () => {
   // Inventory fields now match the Go Inventory model
   const inventoryFields = [
     { name: 'productId', label: 'Product (Commodity)', options: commodities.map(c => ({ value: c.id, label: c.name })) }, // Use 'id' for value
+    { name: 'warehouseId', label: 'Warehouse', options: warehouses.map(w => ({ value: w.id, label: w.name })) },
     { name: 'quantity', label: 'Quantity', type: 'number' },
     { name: 'location', label: 'Location' },
   ];
 
-  // Helper to get entity names for display in inventory table
-  const getCommodityName = (productId) => {
-    const commodity = commodities.find(c => c.id === productId); // Use 'id' to match
-    return commodity ? commodity.name : 'N/A';
-  };
-
   if (loadingLookups) return <div className="text-center py-12 text-gray-600">Loading Inventory Lookup Data...</div>;
   if (lookupError) return <div className="text-center py-12 text-red-600 font-semibold">Error loading lookup data: {lookupError}</div>;
 
@@ -562,14 +608,17 @@ const InventoryPage = () => {
     <CrudPage
       title="Inventory"
       apiUrl={`${API_BASE_URL}/inventory`}
+      listUrl={`${API_BASE_URL}/views/stock`}
       fields={inventoryFields}
-      initialFormState={{ productId: '', quantity: '', location: '' }} // Match Go model fields
+      initialFormState={{ productId: '', warehouseId: '', quantity: '', location: '' }} // Match Go model fields
     >
-      {/* Custom rendering for table cells to show commodity name instead of ID */}
+      {/* Rows come from the stock view, which joins in commodity and warehouse names */}
       {(item, fieldName) => {
         switch (fieldName) {
           case 'productId':
-            return getCommodityName(item.productId);
+            return item.commodityName || 'N/A';
+          case 'warehouseId':
+            return item.warehouseName || 'N/A';
           case 'quantity':
             return item.quantity;
           case 'location':